@@ -0,0 +1,84 @@
+/**
+ * @Time    :2026/10/18 23:59
+ * @Author  :Xiaoyu.Zhang
+ */
+
+// osssync 将一个存储桶中的对象同步到另一个存储桶，两者可以是不同的服务商
+//
+//	osssync -src aliyun.yaml -dst minio.yaml -prefix photos/ -delete -dry-run
+//
+// 源与目标的配置文件格式与oss.LoadConfig相同，分别使用OSS_SRC_与OSS_DST_开头的环境变量覆盖，
+// 如OSS_SRC_BUCKET、OSS_DST_SECRET_KEY；OSS_开头的环境变量不会生效
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	srcPath := flag.String("src", "", "源存储桶的配置文件")
+	dstPath := flag.String("dst", "", "目标存储桶的配置文件")
+	prefix := flag.String("prefix", "", "只同步以此开头的对象")
+	del := flag.Bool("delete", false, "删除目标存储桶中源存储桶没有的对象")
+	dryRun := flag.Bool("dry-run", false, "只输出计划执行的操作，不修改目标存储桶")
+	concurrency := flag.Int("concurrency", 4, "并发复制或删除的对象数")
+	flag.Parse()
+	if *srcPath == "" || *dstPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*srcPath, *dstPath, ossmod.SyncOptions{
+		Prefix:      *prefix,
+		Delete:      *del,
+		DryRun:      *dryRun,
+		Concurrency: *concurrency,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "osssync:", err)
+		os.Exit(1)
+	}
+}
+
+func run(srcPath, dstPath string, opts ossmod.SyncOptions) (err error) {
+	var src, dst oss.ClientI
+	if src, err = newClient(srcPath, "OSS_SRC_"); err != nil {
+		return
+	}
+	if dst, err = newClient(dstPath, "OSS_DST_"); err != nil {
+		return
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	prefix := ""
+	if opts.DryRun {
+		prefix = "(dry run) "
+	}
+	opts.OnAction = func(action ossmod.SyncAction) {
+		if action.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s failed: %v\n", action.Op, action.Key, action.Err)
+			return
+		}
+		fmt.Printf("%s%s %s (%s, %d bytes)\n", prefix, action.Op, action.Key, action.Reason, action.Size)
+	}
+	var result ossmod.SyncResult
+	result, err = oss.Sync(ctx, src, dst, opts)
+	fmt.Printf("%scopied %d objects (%d bytes), deleted %d, skipped %d, failed %d\n",
+		prefix, result.Copied, result.CopiedBytes, result.Deleted, result.Skipped, len(result.Failed))
+	return
+}
+
+// newClient 从配置文件与envPrefix开头的环境变量创建客户端
+func newClient(filePath, envPrefix string) (client oss.ClientI, err error) {
+	var cfg oss.Config
+	cfg, err = oss.LoadConfigWithEnvPrefix(filePath, envPrefix)
+	if err != nil {
+		return
+	}
+	client, err = oss.NewClientFromConfig(cfg)
+	return
+}
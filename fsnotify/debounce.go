@@ -0,0 +1,140 @@
+/**
+ * @Time    :2026/10/19 01:10
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package fsnotify
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pendingEvent 等待文件写入完成的事件
+type pendingEvent struct {
+	callback FileWatch
+	// op 首个事件为Create时为Create，否则为Write
+	op fsnotify.Op
+	// size、modTime 上次检查时文件的大小与修改时间
+	size    int64
+	modTime time.Time
+	// due 计时结束的时间；计时器已触发后被重置时会多触发一次，早于due的触发被忽略
+	due   time.Time
+	timer *time.Timer
+}
+
+// debouncer 合并同一文件在短时间内的多个Create、Write事件：
+// 文件在window内没有新的事件且大小与修改时间不再变化时，才作为一个事件分发
+type debouncer struct {
+	window  time.Duration
+	mu      sync.Mutex
+	pending map[string]*pendingEvent
+	// settled 计时结束的文件，由事件循环检查是否已写入完成，使回调始终在事件循环中调用
+	settled chan string
+	// done 关闭后计时器不再发送到settled
+	done chan struct{}
+}
+
+func newDebouncer(window time.Duration) *debouncer {
+	return &debouncer{
+		window:  window,
+		pending: make(map[string]*pendingEvent),
+		settled: make(chan string),
+		done:    make(chan struct{}),
+	}
+}
+
+// stat 返回文件的大小与修改时间，文件不存在时ok为false
+func stat(name string) (size int64, modTime time.Time, ok bool) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return
+	}
+	return fi.Size(), fi.ModTime(), true
+}
+
+// add 记录文件的Create或Write事件，重新开始计时
+func (d *debouncer) add(e fsnotify.Event, callback FileWatch) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// due须在启动计时器之前确定，保证计时器触发时不早于due
+	due := time.Now().Add(d.window)
+	p, ok := d.pending[e.Name]
+	if !ok {
+		name := e.Name
+		p = &pendingEvent{callback: callback, op: fsnotify.Write}
+		p.timer = time.AfterFunc(d.window, func() {
+			select {
+			case d.settled <- name:
+			case <-d.done:
+			}
+		})
+		d.pending[name] = p
+	} else {
+		p.timer.Reset(d.window)
+	}
+	if e.Op == fsnotify.Create {
+		p.op = fsnotify.Create
+	}
+	p.due = due
+	p.size, p.modTime, _ = stat(e.Name)
+}
+
+// cancel 丢弃name及其下级路径上等待中的事件，用于文件被删除或重命名时
+func (d *debouncer) cancel(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for path, p := range d.pending {
+		if path == name || strings.HasPrefix(path, name+string(filepath.Separator)) {
+			p.timer.Stop()
+			delete(d.pending, path)
+		}
+	}
+}
+
+// stop 停止全部计时器并丢弃等待中的事件
+func (d *debouncer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	close(d.done)
+	for path, p := range d.pending {
+		p.timer.Stop()
+		delete(d.pending, path)
+	}
+}
+
+// settle
+/**
+ *  @Description: 计时结束后检查文件是否已写入完成，大小或修改时间仍在变化时重新计时
+ *  @receiver d
+ *  @param name
+ *  @return e 合并后的事件
+ *  @return callback
+ *  @return ok 为false时不分发事件
+ */
+func (d *debouncer) settle(name string) (e Event, callback FileWatch, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p, exist := d.pending[name]
+	if !exist || time.Now().Before(p.due) {
+		return
+	}
+	size, modTime, found := stat(name)
+	if !found {
+		// 文件已被删除，等待Remove事件
+		delete(d.pending, name)
+		return
+	}
+	if size != p.size || !modTime.Equal(p.modTime) {
+		p.size, p.modTime = size, modTime
+		p.due = time.Now().Add(d.window)
+		p.timer.Reset(d.window)
+		return
+	}
+	delete(d.pending, name)
+	return Event{Event: fsnotify.Event{Name: name, Op: p.op}}, p.callback, true
+}
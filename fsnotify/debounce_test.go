@@ -0,0 +1,64 @@
+/**
+ * @Time    :2026/10/19 01:30
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package fsnotify
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDebouncerSettle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	writeFile(t, path, "a")
+	d := newDebouncer(time.Hour)
+	defer d.stop()
+	rw := &recordWatch{root: dir}
+	d.add(fsnotify.Event{Name: path, Op: fsnotify.Create}, rw)
+	d.add(fsnotify.Event{Name: path, Op: fsnotify.Write}, rw)
+	// 计时未结束
+	if _, _, ok := d.settle(path); ok {
+		t.Error("settle before the window elapsed: want not ok")
+	}
+	// 计时结束但文件仍在变化时重新计时
+	d.pending[path].due = time.Now()
+	writeFile(t, path, "ab")
+	if _, _, ok := d.settle(path); ok {
+		t.Error("settle after the file changed: want not ok")
+	}
+	if p := d.pending[path]; p == nil || p.size != 2 || !p.due.After(time.Now()) {
+		t.Errorf("pending after the file changed = %+v, want rescheduled with size 2", p)
+	}
+	d.pending[path].due = time.Now()
+	ev, callback, ok := d.settle(path)
+	if !ok || ev.Op != fsnotify.Create || ev.Name != path || callback != rw {
+		t.Errorf("settle = %+v, %v, %v, want a create for %s", ev, callback, ok, path)
+	}
+	if len(d.pending) != 0 {
+		t.Errorf("pending after settle = %v", d.pending)
+	}
+
+	// 文件已被删除时丢弃事件
+	d.add(fsnotify.Event{Name: path, Op: fsnotify.Write}, rw)
+	d.pending[path].due = time.Now()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok = d.settle(path); ok || len(d.pending) != 0 {
+		t.Errorf("settle a removed file = %v, pending %v", ok, d.pending)
+	}
+
+	// 取消目录时一并取消目录下的事件
+	d.add(fsnotify.Event{Name: filepath.Join(dir, "sub", "b.txt"), Op: fsnotify.Create}, rw)
+	d.add(fsnotify.Event{Name: filepath.Join(dir, "subway.txt"), Op: fsnotify.Create}, rw)
+	d.cancel(filepath.Join(dir, "sub"))
+	if _, ok = d.pending[filepath.Join(dir, "subway.txt")]; !ok || len(d.pending) != 1 {
+		t.Errorf("pending after cancel = %v, want only subway.txt", d.pending)
+	}
+}
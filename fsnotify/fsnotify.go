@@ -6,12 +6,17 @@
 package fsnotify
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
 // 参考文档
@@ -20,42 +25,275 @@ import (
 
 type Event struct {
 	fsnotify.Event
+	// OldName 重命名前的路径，只在RenameCallback中设置：不为空时Name为重命名后的路径；
+	// 为空时Name为重命名前的路径，新路径不在同一监听目录下、被忽略或无法确认是同一个文件
+	OldName string
+	// IsDir Name是否为目录；Remove与Rename事件中为路径被删除前是否为已监听的目录
+	IsDir bool
 }
 
 // FileWatch 定义接口，调用文件监控必须实现此接口
+//
+// 同时包含多个操作的事件按Create、Write、Remove、Rename、Chmod的顺序分别调用对应的回调，
+// 传入的Event.Op只包含一个操作
 type FileWatch interface {
 	InitCallback(dir string) (err error)
 	CreateCallback(ev Event)
 	WriteCallback(ev Event)
 	RemoveCallback(ev Event)
+	// RenameCallback 重命名后的路径在同一监听目录下时只调用RenameCallback，不再调用新路径的CreateCallback；
+	// 重命名的是目录时目录下的文件不再单独分发事件
 	RenameCallback(ev Event)
 	ChmodCallback(ev Event)
 	OtherCallback(ev Event)
 }
 
+// WatchOptions 监听的可选参数
+//
+// 模式使用path.Match的语法：不含"/"的模式匹配文件名，如"*.tmp"、".git"；
+// 含"/"的模式匹配相对于监听目录的路径，如"logs/*.log"
+type WatchOptions struct {
+	// Include 只处理匹配任一模式的文件，为空时处理全部文件；不影响目录的监听
+	Include []string
+	// Exclude 忽略匹配任一模式的文件与目录，被忽略的目录及其子目录不会被监听
+	Exclude []string
+	// Debounce 大于0时合并同一文件的Create与Write事件：文件在Debounce内没有新的事件且大小与修改时间不再变化后，
+	// 只分发一次，新建的文件分发CreateCallback，已有的文件分发WriteCallback；为0时每个事件立即分发
+	Debounce time.Duration
+	// Logger 不为nil时输出添加与移除的监听以及收到的事件；未实现ErrorCallback时错误也输出到Logger
+	Logger *log.Logger
+}
+
+// ErrorCallback 可由FileWatch的实现选择实现，用于接收监听中的错误；
+// 未实现时错误输出到日志
+type ErrorCallback interface {
+	ErrorCallback(err error)
+}
+
+// renameWindow Rename事件等待配对的Create事件的时间
+const renameWindow = 50 * time.Millisecond
+
+// knownOps FileWatch中有对应回调的操作
+const knownOps = fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename | fsnotify.Chmod
+
+// pendingRename 等待配对的Rename事件；fsnotify的Rename事件只携带原路径，新路径随后以Create事件出现
+type pendingRename struct {
+	name  string
+	isDir bool
+	// info 重命名前记录的文件信息，未记录时为nil，此时不与Create配对
+	info     os.FileInfo
+	root     string
+	callback FileWatch
+	timer    *time.Timer
+}
+
+// CloseCallback 可由FileWatch的实现选择实现，Watch关闭时调用，用于中断进行中的回调
+type CloseCallback interface {
+	CloseCallback()
+}
+
+// ErrClosed Watch已关闭
+var ErrClosed = errors.New("fsnotify: watch closed")
+
+// Watch 递归监听多个目录，每个目录的事件分发给Add时注册的FileWatch
+//
+//	w, err := fsnotify.New(fsnotify.WatchOptions{})
+//	err = w.Add(dir, callback)
+//	err = w.Start(ctx)
+//	...
+//	w.Close()
+//	w.Wait()
+//
+// Watch可按值传递，副本共享同一个监听；零值不可用，须通过New、NewWatch或NewWatchWithOptions创建
 type Watch struct {
+	*watcher
+}
+
+// watcher Watch的状态
+type watcher struct {
 	watch *fsnotify.Watcher
+	opts  WatchOptions
+	mu    sync.Mutex
+	// roots 监听的根目录及其回调
+	roots map[string]FileWatch
+	// dirs 已监听的目录；目录被删除后inotify会自动移除监听，无法再通过WatchList判断被删除的路径是否为目录
+	dirs map[string]bool
+	// infos 监听范围内未被忽略的文件与目录的信息，用于确认Rename与随后的Create是否为同一个文件
+	infos map[string]os.FileInfo
+	// debounce 未设置Debounce时为nil
+	debounce *debouncer
+	// rename 等待与随后的Create配对的Rename事件，只在事件循环中访问
+	rename  *pendingRename
+	started bool
+	// done Close或Start的ctx结束后关闭，exited 事件循环退出后关闭
+	done      chan struct{}
+	exited    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
 }
 
-func NewWatch(callBack FileWatch, dirs ...string) (w Watch, err error) {
+// New
+/**
+ *  @Description: 创建Watch，通过Add添加监听的目录，Start后开始分发事件
+ *  @param opts
+ *  @return w
+ *  @return err
+ */
+func New(opts WatchOptions) (w Watch, err error) {
+	for _, pattern := range append(append([]string(nil), opts.Include...), opts.Exclude...) {
+		if _, err = path.Match(pattern, ""); err != nil {
+			err = fmt.Errorf("fsnotify: invalid pattern %q: %w", pattern, err)
+			return
+		}
+	}
 	var watch *fsnotify.Watcher
 	watch, err = fsnotify.NewWatcher()
 	if err != nil {
 		return
 	}
-	w = Watch{watch: watch}
+	w.watcher = &watcher{
+		watch:  watch,
+		opts:   opts,
+		roots:  make(map[string]FileWatch),
+		dirs:   make(map[string]bool),
+		infos:  make(map[string]os.FileInfo),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	if opts.Debounce > 0 {
+		w.debounce = newDebouncer(opts.Debounce)
+	}
+	return
+}
+
+func NewWatch(callBack FileWatch, dirs ...string) (w Watch, err error) {
+	return NewWatchWithOptions(callBack, WatchOptions{}, dirs...)
+}
+
+// NewWatchWithOptions
+/**
+ *  @Description: 递归监听dirs，全部目录使用同一个回调，创建后立即开始分发事件，通过Close停止
+ *  @param callBack
+ *  @param opts
+ *  @param dirs
+ *  @return w
+ *  @return err
+ */
+func NewWatchWithOptions(callBack FileWatch, opts WatchOptions, dirs ...string) (w Watch, err error) {
+	w, err = New(opts)
+	if err != nil {
+		return
+	}
 	// 监听注册文件夹
 	for _, dir := range dirs {
-		err = w.watchDir(dir, callBack)
-		if err != nil {
+		if err = w.Add(dir, callBack); err != nil {
 			break
 		}
 	}
+	if err == nil {
+		err = w.Start(context.Background())
+	}
+	if err != nil {
+		w.Close()
+		w = Watch{}
+	}
 	return
 }
 
-func (w *Watch) walkPath(path string, info os.FileInfo, err error) error {
-	if err == nil {
+// Add
+/**
+ *  @Description: 递归监听dir及其全部子目录，新建的子目录会自动加入监听；Start前后均可调用
+ *  dir下的事件分发给callback，dir位于其他监听目录下时以最深的监听目录为准
+ *  @receiver w
+ *  @param dir
+ *  @param callback
+ *  @return err callback.InitCallback返回的错误
+ */
+func (w *watcher) Add(dir string, callback FileWatch) (err error) {
+	select {
+	case <-w.done:
+		return ErrClosed
+	default:
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	prev, exist := w.roots[dir]
+	w.roots[dir] = callback
+	w.mu.Unlock()
+	err = w.watchDir(dir, callback)
+	if err != nil {
+		// 恢复为Add之前的状态
+		w.mu.Lock()
+		if exist {
+			w.roots[dir] = prev
+		} else {
+			delete(w.roots, dir)
+		}
+		w.mu.Unlock()
+		if !exist {
+			w.unwatch(dir)
+		}
+	}
+	return
+}
+
+// Start
+/**
+ *  @Description: 启动事件循环，ctx结束或调用Close后停止并释放资源，通过Wait等待停止
+ *  @receiver w
+ *  @param ctx
+ *  @return err 已启动或已关闭时返回错误
+ */
+func (w *watcher) Start(ctx context.Context) (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.done:
+		return ErrClosed
+	default:
+	}
+	if w.started {
+		return errors.New("fsnotify: watch already started")
+	}
+	w.started = true
+	go w.run(ctx)
+	return
+}
+
+// Close 停止事件循环并释放资源，不等待正在执行的回调，可重复调用
+func (w *watcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		if w.debounce != nil {
+			w.debounce.stop()
+		}
+		w.closeErr = w.watch.Close()
+		for _, cb := range w.callbacks() {
+			if cc, ok := cb.(CloseCallback); ok {
+				cc.CloseCallback()
+			}
+		}
+	})
+	return w.closeErr
+}
+
+// Wait 等待事件循环退出；未调用Start时等待Close
+func (w *watcher) Wait() {
+	w.mu.Lock()
+	started := w.started
+	w.mu.Unlock()
+	if started {
+		<-w.exited
+	} else {
+		<-w.done
+	}
+}
+
+func (w *watcher) walkPath(path string, info os.FileInfo, err error) error {
+	if err != nil {
 		return err
 	}
 	if info == nil {
@@ -64,89 +302,421 @@ func (w *Watch) walkPath(path string, info os.FileInfo, err error) error {
 	}
 	// 判断是否为文件夹
 	if info.IsDir() {
-		// 返回该路径的绝对路径
-		path, err = filepath.Abs(path)
-		if err != nil {
-			return err
+		if w.ignored(path, true) {
+			return filepath.SkipDir
 		}
 		// 将此路径加入监听
-		err = w.watch.Add(path)
-		if err != nil {
+		if err = w.add(path); err != nil {
 			return err
 		}
+	} else if w.ignored(path, false) {
+		return nil
 	}
+	w.remember(path, info)
 	return nil
 }
 
+// remember 记录文件信息
+func (w *watcher) remember(name string, info os.FileInfo) {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	w.infos[name] = info
+	w.mu.Unlock()
+}
+
+// forget 移除name及其下级路径的文件信息，返回name的文件信息
+func (w *watcher) forget(name string) (info os.FileInfo) {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info = w.infos[name]
+	for path := range w.infos {
+		if path == name || strings.HasPrefix(path, name+string(filepath.Separator)) {
+			delete(w.infos, path)
+		}
+	}
+	return
+}
+
+// add 监听目录
+func (w *watcher) add(dir string) (err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	err = w.watch.Add(dir)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	w.dirs[dir] = true
+	w.mu.Unlock()
+	return
+}
+
+// unwatch 移除对name及其子目录的监听，返回name是否为已监听的目录
+func (w *watcher) unwatch(name string) (isDir bool) {
+	name, err := filepath.Abs(name)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	isDir = w.dirs[name]
+	for dir := range w.dirs {
+		if dir == name || strings.HasPrefix(dir, name+string(filepath.Separator)) {
+			delete(w.dirs, dir)
+			// 目录已被删除时inotify已自动移除监听，忽略错误
+			w.watch.Remove(dir)
+		}
+	}
+	return
+}
+
+// addCreatedDir 递归监听新建的目录；监听生效前目录中已创建的文件与子目录不会产生事件，因此逐个补发Create事件
+func (w *watcher) addCreatedDir(dir string, callback FileWatch) {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 遍历期间被删除的路径
+			return nil
+		}
+		if err = w.walkPath(path, info, nil); err != nil {
+			return err
+		}
+		if !info.IsDir() && w.ignored(path, false) {
+			return nil
+		}
+		if path != dir {
+			w.dispatchFile(fsnotify.Event{Name: path, Op: fsnotify.Create}, info.IsDir(), callback)
+		}
+		return nil
+	})
+	if err != nil {
+		w.fail(callback, fmt.Errorf("fsnotify: watch %s: %w", dir, err))
+	}
+}
+
+// dispatchFile 分发Create与Write事件，启用Debounce时文件的事件等待写入完成后再分发
+func (w *watcher) dispatchFile(e fsnotify.Event, dir bool, callback FileWatch) {
+	if w.debounce != nil && !dir {
+		w.debounce.add(e, callback)
+		return
+	}
+	if e.Op == fsnotify.Create {
+		callback.CreateCallback(Event{Event: e, IsDir: dir})
+	} else {
+		callback.WriteCallback(Event{Event: e, IsDir: dir})
+	}
+}
+
+// settled 已写入完成的文件，未启用Debounce时为nil
+func (w *watcher) settled() <-chan string {
+	if w.debounce == nil {
+		return nil
+	}
+	return w.debounce.settled
+}
+
+// cancelPending 文件被删除或重命名后丢弃等待中的事件
+func (w *watcher) cancelPending(name string) {
+	if w.debounce != nil {
+		w.debounce.cancel(name)
+	}
+}
+
+// rootOf 返回name所在的最深的监听目录及其回调，不在任何监听目录下时callback为nil
+func (w *watcher) rootOf(name string) (root string, callback FileWatch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for r, cb := range w.roots {
+		if (name == r || strings.HasPrefix(name, r+string(filepath.Separator))) && len(r) > len(root) {
+			root, callback = r, cb
+		}
+	}
+	return
+}
+
+// relPath 返回name相对于所在监听目录的路径，以"/"分隔
+func (w *watcher) relPath(name string) string {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return filepath.ToSlash(name)
+	}
+	root, _ := w.rootOf(abs)
+	if root == "" {
+		return filepath.ToSlash(abs)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ignored 判断路径是否被WatchOptions忽略，监听的根目录不会被忽略
+func (w *watcher) ignored(name string, isDir bool) bool {
+	rel := w.relPath(name)
+	if rel == "." {
+		return false
+	}
+	if matchAny(w.opts.Exclude, rel) {
+		return true
+	}
+	return !isDir && len(w.opts.Include) > 0 && !matchAny(w.opts.Include, rel)
+}
+
+// matchAny 判断相对路径是否匹配任一模式
+func matchAny(patterns []string, rel string) bool {
+	base := path.Base(rel)
+	for _, pattern := range patterns {
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isDir 判断路径是否为目录，路径不存在时返回false
+func isDir(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.IsDir()
+}
+
+// fail 将错误交给callback的ErrorCallback，callback为nil时交给全部监听目录的回调
+func (w *watcher) fail(callback FileWatch, err error) {
+	callbacks := []FileWatch{callback}
+	if callback == nil {
+		callbacks = w.callbacks()
+	}
+	reported := false
+	for _, cb := range callbacks {
+		if ec, ok := cb.(ErrorCallback); ok {
+			ec.ErrorCallback(err)
+			reported = true
+		}
+	}
+	if !reported {
+		if w.opts.Logger != nil {
+			w.opts.Logger.Println("error:", err)
+		} else {
+			log.Println("error:", err)
+		}
+	}
+}
+
+// logf 设置了Logger时输出日志
+func (w *watcher) logf(format string, args ...interface{}) {
+	if w.opts.Logger != nil {
+		w.opts.Logger.Printf(format, args...)
+	}
+}
+
+// callbacks 返回全部监听目录的回调，同一回调只返回一次
+func (w *watcher) callbacks() (callbacks []FileWatch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	seen := make(map[FileWatch]bool)
+	for _, cb := range w.roots {
+		if !seen[cb] {
+			seen[cb] = true
+			callbacks = append(callbacks, cb)
+		}
+	}
+	return
+}
+
 // watchDir
 /**
- *  @Description: 监听文件夹
+ *  @Description: 递归监听文件夹
  *  @receiver w
  *  @param dir
  *  @param callback
  *  @return err
  */
-func (w *Watch) watchDir(dir string, callback FileWatch) (err error) {
-	//// 遍历指定目录下的所有文件
-	//err = filepath.Walk(dir, w.walkPath)
-	//if err != nil {
-	//	return
-	//}
-
-	err = w.watch.Add(dir)
+func (w *watcher) watchDir(dir string, callback FileWatch) (err error) {
+	// 遍历指定目录下的所有文件夹并加入监听
+	err = filepath.Walk(dir, w.walkPath)
 	if err != nil {
 		return
 	}
-	log.Println("监控服务已经启动", dir)
 	// 初始化回调
 	err = callback.InitCallback(dir)
 	if err != nil {
-		log.Panicln(err.Error())
-		return err
+		return
 	}
-	go func() {
-		for {
+	w.logf("监控服务已经启动 %s", dir)
+	return
+}
+
+// run 事件循环，按事件所在的监听目录分发给对应的回调
+func (w *watcher) run(ctx context.Context) {
+	defer close(w.exited)
+	defer w.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case e, ok := <-w.watch.Events:
+			if !ok {
+				return
+			}
+			w.handle(e)
+		case <-w.renameExpired():
+			// 计时结束时配对的Create事件可能已在队列中
 			select {
 			case e, ok := <-w.watch.Events:
 				if !ok {
-					log.Panicln("e, ok := <-w.watch.Events")
 					return
 				}
-				log.Println(fmt.Sprintf("监听到文件 %s 变化| ", e.Name))
-				switch e.Op {
-				case fsnotify.Create:
-					fi, err := os.Stat(e.Name)
-					if err == nil && fi.IsDir() {
-						w.watch.Add(e.Name)
-					}
-					log.Println("添加监控:", e.Name)
-					callback.CreateCallback(Event{e})
-				case fsnotify.Write:
-					callback.WriteCallback(Event{e})
-				case fsnotify.Remove:
-					fi, err := os.Stat(e.Name)
-					if err == nil && fi.IsDir() {
-						w.watch.Remove(e.Name)
-						log.Println("删除监控 : ", e.Name)
-					}
-					callback.RemoveCallback(Event{e})
-				case fsnotify.Rename:
-					callback.RenameCallback(Event{e})
-					w.watch.Remove(e.Name)
-					fmt.Println("重命名文件 : ", e.Name)
-				case fsnotify.Chmod:
-					callback.RenameCallback(Event{e})
-				default:
-					callback.OtherCallback(Event{e})
-				}
-			case err, ok := <-w.watch.Errors:
-				if !ok {
-					log.Panicln("err, ok := <-w.watch.Errors")
-					return
+				w.handle(e)
+			default:
+				w.finishRename("")
+			}
+		case name := <-w.settled():
+			if ev, cb, ok := w.debounce.settle(name); ok {
+				if ev.Op == fsnotify.Create {
+					cb.CreateCallback(ev)
+				} else {
+					cb.WriteCallback(ev)
 				}
-				log.Println("error:", err)
 			}
+		case err, ok := <-w.watch.Errors:
+			if !ok {
+				return
+			}
+			w.fail(nil, err)
 		}
-	}()
-	return
+	}
+}
+
+// renameExpired 等待配对的Rename事件计时结束，没有等待中的Rename事件时为nil
+func (w *watcher) renameExpired() <-chan time.Time {
+	if w.rename == nil {
+		return nil
+	}
+	return w.rename.timer.C
+}
+
+// handle 分发一个事件，事件包含的每个操作分别分发
+func (w *watcher) handle(e fsnotify.Event) {
+	if w.rename != nil {
+		if e.Has(fsnotify.Create) {
+			w.finishRename(e.Name)
+			e.Op &^= fsnotify.Create
+			if e.Op == 0 {
+				return
+			}
+		} else {
+			w.finishRename("")
+		}
+	}
+	root, callback := w.rootOf(e.Name)
+	if callback == nil {
+		return
+	}
+	w.logf("监听到文件 %s 变化: %s", e.Name, e.Op)
+	if e.Has(fsnotify.Create) {
+		w.create(e.Name, callback)
+	}
+	if e.Has(fsnotify.Write) && !w.ignored(e.Name, false) {
+		w.dispatchFile(fsnotify.Event{Name: e.Name, Op: fsnotify.Write}, false, callback)
+	}
+	if e.Has(fsnotify.Remove) {
+		w.cancelPending(e.Name)
+		w.forget(e.Name)
+		removed := w.unwatch(e.Name)
+		if removed {
+			w.logf("删除监控 %s", e.Name)
+		}
+		if !w.ignored(e.Name, removed) {
+			callback.RemoveCallback(Event{Event: fsnotify.Event{Name: e.Name, Op: fsnotify.Remove}, IsDir: removed})
+		}
+	}
+	if e.Has(fsnotify.Rename) {
+		w.cancelPending(e.Name)
+		w.rename = &pendingRename{
+			name:     e.Name,
+			info:     w.forget(e.Name),
+			isDir:    w.unwatch(e.Name),
+			root:     root,
+			callback: callback,
+			timer:    time.NewTimer(renameWindow),
+		}
+	}
+	if e.Has(fsnotify.Chmod) {
+		if chmodDir := isDir(e.Name); !w.ignored(e.Name, chmodDir) {
+			callback.ChmodCallback(Event{Event: fsnotify.Event{Name: e.Name, Op: fsnotify.Chmod}, IsDir: chmodDir})
+		}
+	}
+	if e.Op&knownOps == 0 {
+		callback.OtherCallback(Event{Event: e})
+	}
+}
+
+// create 分发Create事件，新建的目录加入监听
+func (w *watcher) create(name string, callback FileWatch) {
+	created := isDir(name)
+	if w.ignored(name, created) {
+		return
+	}
+	if info, err := os.Lstat(name); err == nil {
+		w.remember(name, info)
+	}
+	w.dispatchFile(fsnotify.Event{Name: name, Op: fsnotify.Create}, created, callback)
+	if created {
+		w.logf("添加监控 %s", name)
+		w.addCreatedDir(name, callback)
+	}
+}
+
+// finishRename
+/**
+ *  @Description: 分发等待配对的Rename事件
+ *  新路径与原路径是同一个文件（设备号与inode相同）、位于同一监听目录下且均未被忽略时，分发携带新旧路径的Rename事件；
+ *  否则拆分为原路径的Rename事件与新路径的Create事件，如文件被移出监听目录的同时新建了另一个文件
+ *  @receiver w
+ *  @param newName 随后的Create事件的路径，为空时没有配对的Create事件
+ */
+func (w *watcher) finishRename(newName string) {
+	r := w.rename
+	w.rename = nil
+	r.timer.Stop()
+	oldIgnored := w.ignored(r.name, r.isDir)
+	root, callback := "", FileWatch(nil)
+	newDir, same := false, false
+	if newName != "" {
+		root, callback = w.rootOf(newName)
+		newDir = isDir(newName)
+		if info, err := os.Lstat(newName); err == nil && r.info != nil {
+			same = os.SameFile(r.info, info)
+		}
+	}
+	if same && callback != nil && root == r.root && !oldIgnored && !w.ignored(newName, newDir) {
+		// 目录下的文件随目录一起重命名，只需重新监听并记录新路径的文件信息
+		if err := filepath.Walk(newName, w.walkPath); err != nil {
+			w.fail(callback, fmt.Errorf("fsnotify: watch %s: %w", newName, err))
+		}
+		w.logf("重命名 %s -> %s", r.name, newName)
+		callback.RenameCallback(Event{Event: fsnotify.Event{Name: newName, Op: fsnotify.Rename}, OldName: r.name, IsDir: newDir})
+		return
+	}
+	if !oldIgnored {
+		w.logf("重命名 %s", r.name)
+		r.callback.RenameCallback(Event{Event: fsnotify.Event{Name: r.name, Op: fsnotify.Rename}, IsDir: r.isDir})
+	}
+	if callback != nil {
+		w.create(newName, callback)
+	}
 }
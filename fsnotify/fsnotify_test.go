@@ -0,0 +1,329 @@
+/**
+ * @Time    :2026/10/19 01:30
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package fsnotify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordWatch 将收到的事件以"操作 相对路径"的形式发送到events
+type recordWatch struct {
+	root   string
+	events chan string
+	// closed 是否已调用CloseCallback
+	closed bool
+}
+
+func (r *recordWatch) record(op string, ev Event) {
+	rel, _ := filepath.Rel(r.root, ev.Name)
+	if ev.OldName != "" {
+		oldRel, _ := filepath.Rel(r.root, ev.OldName)
+		op += " " + filepath.ToSlash(oldRel) + " ->"
+	}
+	r.events <- op + " " + filepath.ToSlash(rel)
+}
+
+func (r *recordWatch) InitCallback(dir string) error { return nil }
+func (r *recordWatch) CreateCallback(ev Event)       { r.record("create", ev) }
+func (r *recordWatch) WriteCallback(ev Event)        { r.record("write", ev) }
+func (r *recordWatch) RemoveCallback(ev Event)       { r.record("remove", ev) }
+func (r *recordWatch) RenameCallback(ev Event)       { r.record("rename", ev) }
+func (r *recordWatch) ChmodCallback(ev Event)        { r.record("chmod", ev) }
+func (r *recordWatch) OtherCallback(ev Event)        { r.record("other", ev) }
+func (r *recordWatch) CloseCallback()                { r.closed = true }
+
+// closeWatch 测试结束时关闭w并等待事件循环退出
+func closeWatch(t *testing.T, w Watch) {
+	t.Cleanup(func() {
+		w.Close()
+		w.Wait()
+	})
+}
+
+// waitEvents 等待want中的全部事件，返回期间收到的全部事件
+func waitEvents(t *testing.T, events chan string, want ...string) map[string]bool {
+	t.Helper()
+	got := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for {
+		missing := ""
+		for _, ev := range want {
+			if !got[ev] {
+				missing = ev
+				break
+			}
+		}
+		if missing == "" {
+			return got
+		}
+		select {
+		case ev := <-events:
+			got[ev] = true
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q, got %v", missing, got)
+		}
+	}
+}
+
+func TestWatchRecursive(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "sub", "deep", "old.txt"), "old")
+	writeFile(t, filepath.Join(root, "skip", "old.txt"), "old")
+	rw := &recordWatch{root: root, events: make(chan string, 100)}
+	if _, err = NewWatchWithOptions(rw, WatchOptions{Exclude: []string{"[", "*"}}, root); err == nil {
+		t.Error("NewWatchWithOptions with an invalid pattern: want error")
+	}
+	opts := WatchOptions{Include: []string{"*.txt"}, Exclude: []string{"skip", "sub/deep/*.tmp.txt"}}
+	w, err := NewWatchWithOptions(rw, opts, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeWatch(t, w)
+
+	// 启动时已存在的子目录
+	writeFile(t, filepath.Join(root, "sub", "deep", "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "sub", "deep", "a.log"), "a")
+	writeFile(t, filepath.Join(root, "sub", "deep", "a.tmp.txt"), "a")
+	writeFile(t, filepath.Join(root, "skip", "b.txt"), "b")
+	// 新建的目录，其中的文件可能在监听生效前创建
+	writeFile(t, filepath.Join(root, "new", "deeper", "c.txt"), "c")
+	got := waitEvents(t, rw.events, "create sub/deep/a.txt", "create new", "create new/deeper/c.txt")
+	writeFile(t, filepath.Join(root, "new", "deeper", "d.txt"), "d")
+	if err = os.RemoveAll(filepath.Join(root, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	for ev := range waitEvents(t, rw.events, "create new/deeper/d.txt", "remove sub") {
+		got[ev] = true
+	}
+	time.Sleep(100 * time.Millisecond)
+	for len(rw.events) > 0 {
+		got[<-rw.events] = true
+	}
+	for ev := range got {
+		if strings.Contains(ev, ".log") || strings.Contains(ev, ".tmp.txt") || strings.Contains(ev, "skip") {
+			t.Errorf("received ignored event %q", ev)
+		}
+	}
+}
+
+// countEvents 等待want出现后再等待settle，返回期间每个事件的次数
+func countEvents(t *testing.T, events chan string, want string, settle time.Duration) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	timeout := time.After(5 * time.Second)
+	for counts[want] == 0 {
+		select {
+		case ev := <-events:
+			counts[ev]++
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q, got %v", want, counts)
+		}
+	}
+	quiet := time.After(settle)
+	for {
+		select {
+		case ev := <-events:
+			counts[ev]++
+		case <-quiet:
+			return counts
+		}
+	}
+}
+
+// writeSlowly 分多次写入文件，模拟仍在写入中的文件
+func writeSlowly(t *testing.T, path string, flag int) {
+	t.Helper()
+	file, err := os.OpenFile(path, flag|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for i := 0; i < 5; i++ {
+		if _, err = file.WriteString("chunk\n"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := &recordWatch{root: root, events: make(chan string, 100)}
+	w, err := NewWatchWithOptions(rw, WatchOptions{Debounce: 100 * time.Millisecond}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeWatch(t, w)
+	path := filepath.Join(root, "a.txt")
+	writeSlowly(t, path, os.O_CREATE)
+	if counts := countEvents(t, rw.events, "create a.txt", 300*time.Millisecond); len(counts) != 1 || counts["create a.txt"] != 1 {
+		t.Errorf("events after creating a file = %v, want a single create", counts)
+	}
+	writeSlowly(t, path, os.O_APPEND)
+	if counts := countEvents(t, rw.events, "write a.txt", 300*time.Millisecond); len(counts) != 1 || counts["write a.txt"] != 1 {
+		t.Errorf("events after appending to a file = %v, want a single write", counts)
+	}
+	// 写入期间被删除的文件只分发Remove
+	writeFile(t, filepath.Join(root, "b.txt"), "b")
+	if err = os.Remove(filepath.Join(root, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if counts := countEvents(t, rw.events, "remove b.txt", 300*time.Millisecond); len(counts) != 1 {
+		t.Errorf("events after creating and removing a file = %v, want a single remove", counts)
+	}
+}
+
+// failInitWatch InitCallback返回errInjected
+type failInitWatch struct {
+	recordWatch
+}
+
+func (f *failInitWatch) InitCallback(dir string) error { return errInjected }
+
+func TestWatchLifecycle(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootA, rootB := filepath.Join(base, "a"), filepath.Join(base, "a", "b")
+	if err = os.MkdirAll(rootB, 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(WatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeWatch(t, w)
+	rwA := &recordWatch{root: rootA, events: make(chan string, 100)}
+	rwB := &recordWatch{root: rootB, events: make(chan string, 100)}
+	if err = w.Add(rootA, rwA); err != nil {
+		t.Fatal(err)
+	}
+	// InitCallback失败时保留原有的回调
+	if err = w.Add(rootA, &failInitWatch{}); !errors.Is(err, errInjected) {
+		t.Errorf("Add with a failing InitCallback = %v, want %v", err, errInjected)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err = w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Start(ctx); err == nil {
+		t.Error("second Start: want error")
+	}
+	// 启动后添加的目录，事件按最深的监听目录分发
+	if err = w.Add(rootB, rwB); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(rootA, "x.txt"), "x")
+	writeFile(t, filepath.Join(rootB, "y.txt"), "y")
+	waitEvents(t, rwA.events, "create x.txt")
+	waitEvents(t, rwB.events, "create y.txt")
+	time.Sleep(100 * time.Millisecond)
+	for len(rwA.events) > 0 {
+		if ev := <-rwA.events; strings.Contains(ev, "y.txt") {
+			t.Errorf("event %q routed to the parent root", ev)
+		}
+	}
+
+	// ctx结束后事件循环退出
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		w.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return after the context was canceled")
+	}
+	if !rwA.closed || !rwB.closed {
+		t.Error("CloseCallback was not called on close")
+	}
+	if err = w.Add(rootA, rwA); !errors.Is(err, ErrClosed) {
+		t.Errorf("Add after close = %v, want %v", err, ErrClosed)
+	}
+	if err = w.Start(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Start after close = %v, want %v", err, ErrClosed)
+	}
+
+	// 未启动的Watch，Close后Wait立即返回
+	w2, err := New(WatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2.Close()
+	w2.Close()
+	w2.Wait()
+}
+
+func TestWatchDispatch(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(base, "root")
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "dir", "b.txt"), "b")
+	writeFile(t, filepath.Join(root, "out.txt"), "out")
+	rw := &recordWatch{root: root, events: make(chan string, 100)}
+	w, err := NewWatchWithOptions(rw, WatchOptions{Exclude: []string{"*.tmp"}}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeWatch(t, w)
+
+	if err = os.Chmod(filepath.Join(root, "a.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitEvents(t, rw.events, "chmod a.txt")
+	rename := func(oldRel, newPath string) {
+		if err := os.Rename(filepath.Join(root, oldRel), newPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 目录内的重命名携带新旧路径，不再分发新路径的Create
+	rename("a.txt", filepath.Join(root, "c.txt"))
+	rename("dir", filepath.Join(root, "moved"))
+	// 移出监听目录只携带原路径，被忽略的文件重命名后作为新文件分发
+	rename("out.txt", filepath.Join(base, "out.txt"))
+	// 紧随其后新建的另一个文件不与之配对
+	writeFile(t, filepath.Join(root, "new.txt"), "new")
+	writeFile(t, filepath.Join(root, "d.tmp"), "d")
+	rename("d.tmp", filepath.Join(root, "d.txt"))
+	got := waitEvents(t, rw.events, "rename a.txt -> c.txt", "rename dir -> moved", "rename out.txt", "create new.txt", "create d.txt")
+	// 重命名后的目录仍被监听
+	writeFile(t, filepath.Join(root, "moved", "e.txt"), "e")
+	for ev := range waitEvents(t, rw.events, "create moved/e.txt") {
+		got[ev] = true
+	}
+	time.Sleep(100 * time.Millisecond)
+	for len(rw.events) > 0 {
+		got[<-rw.events] = true
+	}
+	for _, ev := range []string{"create c.txt", "create moved", "create moved/b.txt", "rename a.txt", "rename out.txt -> new.txt"} {
+		if got[ev] {
+			t.Errorf("received unexpected event %q", ev)
+		}
+	}
+	for ev := range got {
+		if strings.HasPrefix(ev, "other") || strings.Contains(ev, ".tmp") {
+			t.Errorf("received unexpected event %q", ev)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+/**
+ * @Time    :2026/10/19 00:20
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package fsnotify
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PrefixRule 将root下的子目录映射到指定的对象名前缀
+type PrefixRule struct {
+	// Dir 相对于root的目录，使用"/"或系统的路径分隔符均可；为"."时等同于修改NewOssSyncer的prefix
+	Dir string
+	// Prefix Dir下的文件使用的对象名前缀，为空时直接使用相对于Dir的路径
+	Prefix string
+}
+
+// OssSyncer 将本地目录中的变化同步到存储桶的FileWatch实现：
+// 文件创建或写入后上传，删除后删除对应的对象；重命名的文件或目录在存储桶内移动对应的对象。
+// 新路径不在监听范围内的重命名等同于删除，从监听范围外移入的文件或目录重新上传
+type OssSyncer struct {
+	client oss.ClientI
+	root   string
+	prefix string
+	// rules 按Dir的长度降序排列，优先匹配更深的目录
+	rules []PrefixRule
+	// OnError 同步失败时调用，为nil时输出日志
+	OnError func(filePath string, err error)
+	// ctx 所有请求使用的context，Watch关闭时通过CloseCallback取消
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewOssSyncer
+/**
+ *  @Description: 创建将root同步到存储桶的FileWatch，通过NewWatch(syncer, root)启动
+ *  对象名为prefix加文件相对于root的路径，rules可将子目录映射到其他前缀
+ *  @param client
+ *  @param root 本地目录
+ *  @param prefix 对象名前缀，如"backup/"，为空时对象名即相对路径
+ *  @param rules
+ *  @return syncer
+ *  @return err
+ */
+func NewOssSyncer(client oss.ClientI, root, prefix string, rules ...PrefixRule) (syncer *OssSyncer, err error) {
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return
+	}
+	syncer = &OssSyncer{client: client, root: root, prefix: prefix}
+	syncer.ctx, syncer.cancel = context.WithCancel(context.Background())
+	for _, rule := range rules {
+		rule.Dir = strings.Trim(filepath.ToSlash(filepath.Clean(rule.Dir)), "/")
+		if rule.Dir == "." || rule.Dir == "" {
+			syncer.prefix = rule.Prefix
+			continue
+		}
+		syncer.rules = append(syncer.rules, rule)
+	}
+	sort.SliceStable(syncer.rules, func(i, j int) bool { return len(syncer.rules[i].Dir) > len(syncer.rules[j].Dir) })
+	return
+}
+
+// ObjectKey
+/**
+ *  @Description: 计算本地路径对应的对象名
+ *  @receiver s
+ *  @param filePath
+ *  @return key
+ *  @return ok filePath不在root下时为false
+ */
+func (s *OssSyncer) ObjectKey(filePath string) (key string, ok bool) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(s.root, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	prefix := s.prefix
+	for _, rule := range s.rules {
+		if strings.HasPrefix(rel, rule.Dir+"/") {
+			prefix, rel = rule.Prefix, strings.TrimPrefix(rel, rule.Dir+"/")
+			break
+		}
+	}
+	key, ok = joinKey(prefix, rel), true
+	return
+}
+
+// joinKey 拼接对象名前缀与相对路径
+func joinKey(prefix, rel string) string {
+	if prefix == "" {
+		return rel
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + rel
+}
+
+// InitCallback 校验被监听的目录位于root下
+func (s *OssSyncer) InitCallback(dir string) (err error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	if _, ok := s.ObjectKey(abs); !ok && abs != s.root {
+		err = fmt.Errorf("fsnotify: %s is not under %s", dir, s.root)
+	}
+	return
+}
+
+func (s *OssSyncer) CreateCallback(ev Event) {
+	s.upload(ev.Name)
+}
+
+func (s *OssSyncer) WriteCallback(ev Event) {
+	s.upload(ev.Name)
+}
+
+// RemoveCallback 删除对应的对象，路径原本是目录时删除目录下的对象
+func (s *OssSyncer) RemoveCallback(ev Event) {
+	s.remove(ev.Name, ev.IsDir)
+}
+
+// RenameCallback OldName不为空时将原路径同步到新路径，否则删除原路径对应的对象
+func (s *OssSyncer) RenameCallback(ev Event) {
+	if ev.OldName == "" {
+		s.remove(ev.Name, ev.IsDir)
+		return
+	}
+	s.move(ev.OldName, ev.Name)
+}
+
+func (s *OssSyncer) ChmodCallback(ev Event) {}
+
+// ErrorCallback 监听出错时调用OnError，filePath为空
+func (s *OssSyncer) ErrorCallback(err error) {
+	s.fail("", err)
+}
+
+// CloseCallback 取消进行中的上传、删除与移动，此后的同步操作均失败
+func (s *OssSyncer) CloseCallback() {
+	s.cancel()
+}
+
+func (s *OssSyncer) OtherCallback(ev Event) {}
+
+// upload 上传文件，目录与已不存在的文件不做处理
+func (s *OssSyncer) upload(filePath string) {
+	key, ok := s.ObjectKey(filePath)
+	if !ok {
+		return
+	}
+	fi, err := os.Stat(filePath)
+	if err != nil || !fi.Mode().IsRegular() {
+		return
+	}
+	// 断点文件会写入被监听的目录，因此不记录断点
+	err = oss.UploadFile(s.ctx, s.client, key, filePath, ossmod.MultipartOptions{DisableCheckpoint: true})
+	if err != nil {
+		s.fail(filePath, err)
+	}
+}
+
+// remove 删除路径对应的对象，isDir为true时删除以key+"/"开头的对象
+func (s *OssSyncer) remove(filePath string, isDir bool) {
+	key, ok := s.ObjectKey(filePath)
+	if !ok {
+		return
+	}
+	var err error
+	if isDir {
+		err = s.client.RemovePrefixCtx(s.ctx, key+"/")
+	} else {
+		err = s.client.RemoveObjectCtx(s.ctx, key)
+	}
+	if err != nil {
+		s.fail(filePath, err)
+	}
+}
+
+// move 同步重命名：原路径与新路径均在同步范围内时在存储桶内移动对象，否则上传新路径并删除原路径对应的对象
+func (s *OssSyncer) move(oldPath, newPath string) {
+	fi, err := os.Stat(newPath)
+	if err != nil {
+		// 已被删除或再次重命名，由随后的事件处理
+		return
+	}
+	oldKey, oldOK := s.ObjectKey(oldPath)
+	newKey, newOK := s.ObjectKey(newPath)
+	switch {
+	case !oldOK || !newOK:
+		if fi.IsDir() {
+			s.uploadDir(newPath)
+		} else {
+			s.upload(newPath)
+		}
+		s.remove(oldPath, fi.IsDir())
+	case fi.IsDir():
+		s.moveDir(newPath, oldKey, newKey)
+	default:
+		s.moveFile(newPath, oldKey, newKey)
+	}
+}
+
+// moveFile 原对象的ETag与本地文件的MD5相同时移动原对象；原对象不存在、重命名前的写入尚未上传或ETag不是MD5（如分片上传）时，
+// 重新上传并删除原对象
+func (s *OssSyncer) moveFile(newPath, oldKey, newKey string) {
+	info, err := s.client.StatObjectCtx(s.ctx, oldKey)
+	if sum := fileMD5(newPath); err == nil && sum != "" && strings.EqualFold(info.ETag, sum) {
+		if err = s.client.MoveObjectCtx(s.ctx, oldKey, newKey); err == nil {
+			return
+		}
+	}
+	if err != nil && !errors.Is(err, oss.ErrObjectNotFound) {
+		s.fail(newPath, err)
+		return
+	}
+	s.upload(newPath)
+	if err = s.client.RemoveObjectCtx(s.ctx, oldKey); err != nil {
+		s.fail(newPath, err)
+	}
+}
+
+// fileMD5 计算文件内容的MD5，读取失败时为空
+func fileMD5(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	h := md5.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// moveDir 在存储桶内移动目录下的全部对象
+func (s *OssSyncer) moveDir(newPath, oldKey, newKey string) {
+	// 先列举再移动，避免移动过程中影响遍历
+	var keys []string
+	it := s.client.ListObjectsIter(s.ctx, ossmod.ListOptions{Prefix: oldKey + "/"})
+	for it.Next() {
+		keys = append(keys, it.Object().Key)
+	}
+	if err := it.Err(); err != nil {
+		s.fail(newPath, err)
+		return
+	}
+	for _, key := range keys {
+		dst := newKey + "/" + strings.TrimPrefix(key, oldKey+"/")
+		if err := s.client.MoveObjectCtx(s.ctx, key, dst); err != nil {
+			s.fail(newPath, err)
+		}
+	}
+}
+
+// uploadDir 上传目录下的全部文件，用于从同步范围外移入的目录
+func (s *OssSyncer) uploadDir(dir string) {
+	err := filepath.Walk(dir, func(filePath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			s.fail(filePath, err)
+			return nil
+		}
+		if fi.Mode().IsRegular() {
+			s.upload(filePath)
+		}
+		return s.ctx.Err()
+	})
+	if err != nil {
+		s.fail(dir, err)
+	}
+}
+
+// fail 报告同步失败，CloseCallback之后因取消而失败的操作不报告
+func (s *OssSyncer) fail(filePath string, err error) {
+	if s.ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return
+	}
+	if s.OnError != nil {
+		s.OnError(filePath, err)
+		return
+	}
+	log.Println("同步失败:", filePath, err)
+}
@@ -0,0 +1,226 @@
+/**
+ * @Time    :2026/10/19 00:40
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package fsnotify
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// errInjected 测试中注入的错误
+var errInjected = errors.New("injected failure")
+
+// assertObject 检查对象内容
+func assertObject(t *testing.T, client oss.ClientI, key, want string) {
+	t.Helper()
+	body, _, err := client.GetObjectStream(key)
+	if err != nil {
+		t.Errorf("GetObjectStream(%s): %v", key, err)
+		return
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil || string(data) != want {
+		t.Errorf("GetObjectStream(%s) = %q, %v, want %q", key, data, err, want)
+	}
+}
+
+// assertKeys 检查存储桶中的全部对象名
+func assertKeys(t *testing.T, name string, client oss.ClientI, want ...string) {
+	t.Helper()
+	objects, err := client.ListObjects("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("%s = %v, want %v", name, keys, want)
+	}
+}
+
+// fileEvent 构造path上的文件事件
+func fileEvent(path string, op fsnotify.Op) Event {
+	return Event{Event: fsnotify.Event{Name: path, Op: op}}
+}
+
+// renameEvent 构造从oldPath重命名为newPath的事件
+func renameEvent(oldPath, newPath string) Event {
+	return Event{Event: fsnotify.Event{Name: newPath, Op: fsnotify.Rename}, OldName: oldPath}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOssSyncerObjectKey(t *testing.T) {
+	root := t.TempDir()
+	syncer, err := NewOssSyncer(oss.NewMemoryOss("bucket"), root, "backup/",
+		PrefixRule{Dir: "logs", Prefix: "archive/logs"},
+		PrefixRule{Dir: "logs/debug", Prefix: ""},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		key  string
+		ok   bool
+	}{
+		{filepath.Join(root, "a.txt"), "backup/a.txt", true},
+		{filepath.Join(root, "docs", "b.txt"), "backup/docs/b.txt", true},
+		{filepath.Join(root, "logs", "app.log"), "archive/logs/app.log", true},
+		{filepath.Join(root, "logs", "debug", "trace.log"), "trace.log", true},
+		{filepath.Join(root, "logsx", "c.txt"), "backup/logsx/c.txt", true},
+		{root, "", false},
+		{filepath.Join(filepath.Dir(root), "outside.txt"), "", false},
+	}
+	for _, tt := range tests {
+		if key, ok := syncer.ObjectKey(tt.path); key != tt.key || ok != tt.ok {
+			t.Errorf("ObjectKey(%s) = %q, %v, want %q, %v", tt.path, key, ok, tt.key, tt.ok)
+		}
+	}
+	if err = syncer.InitCallback(root); err != nil {
+		t.Errorf("InitCallback(root): %v", err)
+	}
+	if err = syncer.InitCallback(filepath.Dir(root)); err == nil {
+		t.Error("InitCallback outside root: want error")
+	}
+}
+
+func TestOssSyncer(t *testing.T) {
+	client := oss.NewMemoryOss("bucket")
+	if err := client.NewBucket(); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	syncer, err := NewOssSyncer(client, root, "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	syncer.OnError = func(filePath string, err error) { errs = append(errs, err) }
+
+	path := filepath.Join(root, "dir", "a.txt")
+	writeFile(t, path, "created")
+	syncer.CreateCallback(fileEvent(path, fsnotify.Create))
+	assertObject(t, client, "sync/dir/a.txt", "created")
+	writeFile(t, path, "written")
+	syncer.WriteCallback(fileEvent(path, fsnotify.Write))
+	assertObject(t, client, "sync/dir/a.txt", "written")
+	// 目录与已不存在的文件不上传
+	syncer.CreateCallback(fileEvent(filepath.Join(root, "dir"), fsnotify.Create))
+	syncer.WriteCallback(fileEvent(filepath.Join(root, "gone.txt"), fsnotify.Write))
+
+	// 重命名：在存储桶内移动对象，目录下的对象一并移动
+	var moved []string
+	client.SetHook("MoveObject", func(objectName string) error {
+		moved = append(moved, objectName)
+		return nil
+	})
+	newPath := filepath.Join(root, "dir", "b.txt")
+	if err = os.Rename(path, newPath); err != nil {
+		t.Fatal(err)
+	}
+	syncer.RenameCallback(renameEvent(path, newPath))
+	assertObject(t, client, "sync/dir/b.txt", "written")
+	if fmt.Sprint(moved) != "[sync/dir/a.txt]" {
+		t.Errorf("MoveObject called for %v, want [sync/dir/a.txt]", moved)
+	}
+	// 重命名前的写入尚未上传时重新上传
+	writeFile(t, newPath, "rewritten")
+	if err = os.Rename(newPath, path); err != nil {
+		t.Fatal(err)
+	}
+	syncer.RenameCallback(renameEvent(newPath, path))
+	assertObject(t, client, "sync/dir/a.txt", "rewritten")
+	if err = os.Rename(path, newPath); err != nil {
+		t.Fatal(err)
+	}
+	syncer.RenameCallback(renameEvent(path, newPath))
+	client.SetHook("MoveObject", nil)
+	writeFile(t, filepath.Join(root, "old", "c.txt"), "c")
+	syncer.CreateCallback(fileEvent(filepath.Join(root, "old", "c.txt"), fsnotify.Create))
+	if err = os.Rename(filepath.Join(root, "old"), filepath.Join(root, "dir", "new")); err != nil {
+		t.Fatal(err)
+	}
+	syncer.RenameCallback(renameEvent(filepath.Join(root, "old"), filepath.Join(root, "dir", "new")))
+	// 尚未上传的文件重命名后上传
+	writeFile(t, filepath.Join(root, "dir", "d.txt"), "d")
+	syncer.RenameCallback(renameEvent(filepath.Join(root, "dir", "pending.txt"), filepath.Join(root, "dir", "d.txt")))
+	assertKeys(t, "ListObjects after rename", client, "sync/dir/b.txt", "sync/dir/d.txt", "sync/dir/new/c.txt")
+	// 从同步范围外移入的目录上传其下的全部文件
+	outside := filepath.Join(t.TempDir(), "in")
+	writeFile(t, filepath.Join(outside, "e.txt"), "e")
+	writeFile(t, filepath.Join(outside, "sub", "f.txt"), "f")
+	if err = os.Rename(outside, filepath.Join(root, "in")); err != nil {
+		t.Fatal(err)
+	}
+	syncer.RenameCallback(renameEvent(outside, filepath.Join(root, "in")))
+	assertKeys(t, "ListObjects after moving a directory in", client,
+		"sync/dir/b.txt", "sync/dir/d.txt", "sync/dir/new/c.txt", "sync/in/e.txt", "sync/in/sub/f.txt")
+	if err = os.RemoveAll(filepath.Join(root, "in")); err != nil {
+		t.Fatal(err)
+	}
+	removeIn := fileEvent(filepath.Join(root, "in"), fsnotify.Remove)
+	removeIn.IsDir = true
+	syncer.RemoveCallback(removeIn)
+	// 移出监听目录等同于删除
+	if err = os.Remove(filepath.Join(root, "dir", "d.txt")); err != nil {
+		t.Fatal(err)
+	}
+	syncer.RenameCallback(fileEvent(filepath.Join(root, "dir", "d.txt"), fsnotify.Rename))
+	assertKeys(t, "ListObjects after moving a file out", client, "sync/dir/b.txt", "sync/dir/new/c.txt")
+
+	// 删除目录时一并删除目录下的对象
+	writeFile(t, filepath.Join(root, "keep.txt"), "keep")
+	syncer.CreateCallback(fileEvent(filepath.Join(root, "keep.txt"), fsnotify.Create))
+	if err = os.RemoveAll(filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	removeDir := fileEvent(filepath.Join(root, "dir"), fsnotify.Remove)
+	removeDir.IsDir = true
+	syncer.RemoveCallback(removeDir)
+	assertKeys(t, "ListObjects after removing a directory", client, "sync/keep.txt")
+
+	// 上传失败时调用OnError
+	if err = client.RemoveObject("sync/keep.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.RemoveBucket(); err != nil {
+		t.Fatal(err)
+	}
+	syncer.WriteCallback(fileEvent(filepath.Join(root, "keep.txt"), fsnotify.Write))
+	// 监听出错时同样调用OnError
+	syncer.ErrorCallback(errInjected)
+	if len(errs) != 2 || !errors.Is(errs[1], errInjected) {
+		t.Errorf("OnError called %d times, want 2: %v", len(errs), errs)
+	}
+
+	// Watch关闭后不再上传，取消导致的失败不报告
+	if err = client.NewBucket(); err != nil {
+		t.Fatal(err)
+	}
+	syncer.CloseCallback()
+	syncer.WriteCallback(fileEvent(filepath.Join(root, "keep.txt"), fsnotify.Write))
+	if exist, _ := client.ObjectExist("sync/keep.txt"); exist || len(errs) != 2 {
+		t.Errorf("WriteCallback after CloseCallback: uploaded = %v, errors = %v", exist, errs)
+	}
+}
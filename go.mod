@@ -3,6 +3,7 @@ module github.com/melf-xyzh/go-oss-client
 go 1.17
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/aliyun/aliyun-oss-go-sdk v2.2.7+incompatible
 	github.com/baidubce/bce-sdk-go v0.9.150
 	github.com/fsnotify/fsnotify v1.6.0
@@ -11,6 +12,7 @@ require (
 	github.com/qiniu/go-sdk/v7 v7.15.0
 	github.com/tencentyun/cos-go-sdk-v5 v0.7.41
 	github.com/upyun/go-sdk/v3 v3.0.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
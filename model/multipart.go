@@ -0,0 +1,42 @@
+/**
+ * @Time    :2026/10/18 17:20
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package ossmod
+
+// Part 已上传的分片
+type Part struct {
+	// PartNumber 分片编号，从1开始
+	PartNumber int `json:"part_number"`
+	// ETag 服务端返回的分片ETag，完成上传时使用
+	ETag string `json:"etag"`
+	// Size 分片长度
+	Size int64 `json:"size"`
+}
+
+// MultipartOptions 分片上传的可选参数
+type MultipartOptions struct {
+	// PartSize 分片大小，默认8MB，分片数超过10000时自动增大；不能小于服务商要求的最小分片大小
+	PartSize int64
+	// Concurrency 并发上传的分片数，默认4
+	Concurrency int
+	// CheckpointFile 断点文件路径，默认为本地文件路径加 ".upload.cp" 后缀
+	CheckpointFile string
+	// DisableCheckpoint 不记录断点，上传失败时直接终止分片上传
+	DisableCheckpoint bool
+	// PutOptions 对象的可选参数
+	PutOptions PutOptions
+}
+
+// DownloadOptions 分片下载的可选参数
+type DownloadOptions struct {
+	// PartSize 分片大小，默认8MB
+	PartSize int64
+	// Concurrency 并发下载的分片数，默认4
+	Concurrency int
+	// CheckpointFile 断点文件路径，默认为本地文件路径加 ".download.cp" 后缀
+	CheckpointFile string
+	// DisableCheckpoint 不记录断点，下载失败时删除已下载的临时文件
+	DisableCheckpoint bool
+}
@@ -15,4 +15,58 @@ type ObjectInfo struct {
 	ETag         string
 	LastModified time.Time
 	StorageClass string
+	// IsDir 是否为以"/"结尾的空目录占位对象，由列举接口填充
+	IsDir bool
+	// 以下字段由StatObject与GetObjectStream返回，ListObjects不保证填充
+
+	// ContentType 对象的MIME类型
+	ContentType string
+	// ContentLength 对象内容的长度
+	ContentLength int64
+	// ContentDisposition 下载时的展示方式，如attachment; filename="a.txt"
+	ContentDisposition string
+	// CacheControl 缓存策略
+	CacheControl string
+	// ContentEncoding 内容编码，如gzip
+	ContentEncoding string
+	// Metadata 用户自定义元数据，键统一为小写且不含服务商前缀
+	Metadata map[string]string
+	// VersionID 对象的版本号，未开启多版本时为空
+	VersionID string
+	// Expires 对象的过期时间，未设置时为零值
+	Expires time.Time
+}
+
+// CopyOptions 复制对象时的可选参数
+type CopyOptions struct {
+	// SrcBucket 源对象所在的存储桶，为空时为当前存储桶；须与当前存储桶属于同一服务商且可用相同的凭证访问
+	SrcBucket string
+}
+
+// PutOptions 上传对象时的可选参数
+type PutOptions struct {
+	// ContentType 对象的MIME类型，为空时根据对象名的扩展名推断，无法推断时根据内容的前512字节检测
+	ContentType string
+	// ContentDisposition 下载时的展示方式，如attachment; filename="a.txt"
+	ContentDisposition string
+	// CacheControl 缓存策略，如max-age=3600
+	CacheControl string
+	// ContentEncoding 内容编码，如gzip；部分服务商（百度、七牛）不支持
+	ContentEncoding string
+	// Metadata 用户自定义元数据，键不含服务商前缀
+	Metadata map[string]string
+	// StorageClass 存储类型，取值由服务商决定，为空时使用存储桶的默认存储类型
+	StorageClass string
+}
+
+// ListOptions 列举对象的可选参数
+type ListOptions struct {
+	// Prefix 只列举以Prefix开头的对象
+	Prefix string
+	// StartAfter 只列举对象名大于StartAfter的对象
+	StartAfter string
+	// MaxKeys 每次请求返回的最大对象数，默认且最大为1000
+	MaxKeys int
+	// PageToken 此前遍历时ObjectIterator.PageToken的返回值，不为空时忽略StartAfter，从上次遍历到的对象之后继续
+	PageToken string
 }
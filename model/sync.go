@@ -0,0 +1,58 @@
+/**
+ * @Time    :2026/10/18 23:40
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package ossmod
+
+// SyncOp 同步的操作类型
+type SyncOp string
+
+const (
+	// SyncCopy 将源对象复制到目标存储桶
+	SyncCopy SyncOp = "copy"
+	// SyncDelete 删除目标存储桶中源存储桶没有的对象
+	SyncDelete SyncOp = "delete"
+)
+
+// SyncOptions 同步存储桶的可选参数
+type SyncOptions struct {
+	// Prefix 只同步以Prefix开头的对象，为空时同步整个存储桶
+	Prefix string
+	// Delete 删除目标存储桶中以Prefix开头、源存储桶中不存在的对象
+	Delete bool
+	// DryRun 只比较差异并通过OnAction输出计划执行的操作，不修改目标存储桶
+	DryRun bool
+	// Concurrency 并发复制或删除的对象数，默认4
+	Concurrency int
+	// OnAction 每个操作完成后调用，DryRun时在计划操作时调用；调用是串行的
+	OnAction func(action SyncAction)
+}
+
+// SyncAction 同步中的一个操作
+type SyncAction struct {
+	// Op 操作类型
+	Op SyncOp
+	// Key 对象名
+	Key string
+	// Size 复制时为源对象的大小，删除时为目标对象的大小
+	Size int64
+	// Reason 执行该操作的原因：new、size、etag、modified、extraneous
+	Reason string
+	// Err 操作失败的原因，成功或DryRun时为nil
+	Err error
+}
+
+// SyncResult 同步的统计结果，DryRun时为计划执行的操作
+type SyncResult struct {
+	// Copied 复制成功的对象数
+	Copied int
+	// CopiedBytes 复制成功的对象的总大小
+	CopiedBytes int64
+	// Deleted 删除成功的对象数
+	Deleted int
+	// Skipped 内容一致、无需复制的对象数
+	Skipped int
+	// Failed 操作失败的对象及原因，全部成功时为nil
+	Failed map[string]error
+}
@@ -6,8 +6,14 @@
 package oss
 
 import (
+	"context"
+	"errors"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 )
 
 type ALiYunOss struct {
@@ -18,14 +24,29 @@ type ALiYunOss struct {
 	Client          *oss.Client
 }
 
+func init() {
+	register("aliyun", func(cfg Config) (ClientI, error) {
+		var options []oss.ClientOption
+		if cfg.Timeout > 0 {
+			options = append(options, oss.Timeout(int64(cfg.Timeout), int64(cfg.Timeout)))
+		}
+		return newALiYunOss(endpointURL(cfg.Endpoint, cfg.UseSSL), cfg.AccessKey, cfg.SecretKey, cfg.Bucket, options...)
+	}, "endpoint", "access_key", "secret_key", "bucket")
+}
+
 func NewALiYunOss(endpoint, accessKeyId, accessKeySecret, bucket string) (aLiYunOss *ALiYunOss, err error) {
+	return newALiYunOss(endpoint, accessKeyId, accessKeySecret, bucket)
+}
+
+// newALiYunOss 使用SDK的选项创建客户端
+func newALiYunOss(endpoint, accessKeyId, accessKeySecret, bucket string, options ...oss.ClientOption) (aLiYunOss *ALiYunOss, err error) {
 	aLiYunOss = &ALiYunOss{
 		Endpoint:        endpoint,
 		AccessKeyId:     accessKeyId,
 		AccessKeySecret: accessKeySecret,
 		Bucket:          bucket,
 	}
-	aLiYunOss.Client, err = oss.New(endpoint, accessKeyId, accessKeySecret)
+	aLiYunOss.Client, err = oss.New(endpoint, accessKeyId, accessKeySecret, options...)
 	if err != nil {
 		return
 	}
@@ -41,10 +62,31 @@ func NewALiYunOss(endpoint, accessKeyId, accessKeySecret, bucket string) (aLiYun
  *  @return err
  */
 func (client *ALiYunOss) BucketExist() (exist bool, err error) {
+	return client.BucketExistCtx(context.Background())
+}
+
+// BucketExistCtx
+/**
+ *  @Description: 判断存储桶是否存在（阿里云SDK不支持context，仅在请求前检查）
+ *  @receiver client
+ *  @param ctx
+ *  @return exist
+ *  @return err
+ */
+func (client *ALiYunOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = aliyunError("BucketExist", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	exist, err = client.Client.IsBucketExist(client.Bucket)
 	return
 }
 
+// Target 返回服务商名称与存储桶
+func (client *ALiYunOss) Target() (provider, bucket string) {
+	return "aliyun", client.Bucket
+}
+
 // NewBucket
 /**
  *  @Description: 创建存储桶
@@ -53,11 +95,27 @@ func (client *ALiYunOss) BucketExist() (exist bool, err error) {
  *  @return err
  */
 func (client *ALiYunOss) NewBucket() (err error) {
+	return client.NewBucketCtx(context.Background())
+}
+
+func (client *ALiYunOss) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = aliyunError("NewBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	err = client.Client.CreateBucket(client.Bucket)
 	return
 }
 
 func (client *ALiYunOss) RemoveBucket() (err error) {
+	return client.RemoveBucketCtx(context.Background())
+}
+
+func (client *ALiYunOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = aliyunError("RemoveBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	err = client.Client.DeleteBucket(client.Bucket)
 	return
 }
@@ -73,41 +131,97 @@ func (client *ALiYunOss) RemoveBucket() (err error) {
  *  @return err
  */
 func (client *ALiYunOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+// ListObjectsCtx
+/**
+ *  @Description: 获取对象列表，每页请求前检查ctx
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix
+ *  @param startAfter
+ *  @return objects
+ *  @return err
+ */
+func (client *ALiYunOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = aliyunError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter
+/**
+ *  @Description: 分页遍历对象
+ *  @receiver client
+ *  @param ctx
+ *  @param opts
+ *  @return ObjectIterator
+ */
+func (client *ALiYunOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+// ListDir
+/**
+ *  @Description: 按分隔符列举prefix下一级的对象与公共前缀
+ *  @receiver client
+ *  @param prefix 目录前缀，如 "a/b/"
+ *  @param delimiter 为空时使用"/"
+ *  @return objects
+ *  @return prefixes 子目录
+ *  @return err
+ */
+func (client *ALiYunOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+// ListDirCtx
+/**
+ *  @Description: 按分隔符列举prefix下一级的对象与公共前缀
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix 目录前缀，如 "a/b/"
+ *  @param delimiter 为空时使用"/"
+ *  @return objects
+ *  @return prefixes 子目录
+ *  @return err
+ */
+func (client *ALiYunOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = aliyunError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 请求一页对象，使用marker续接
+func (client *ALiYunOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = aliyunError("ListObjects", "", err) }()
 	var bucket *oss.Bucket
 	// 获取存储桶
 	bucket, err = client.Client.Bucket(client.Bucket)
 	if err != nil {
 		return
 	}
-	// 分页列举包含指定前缀的文件。每页列举100个。
-	prefixOptions := oss.Prefix(prefix)
-	markerOptions := oss.Marker(startAfter)
-	for {
-		var lsRes oss.ListObjectsResult
-		lsRes, err = bucket.ListObjects(oss.MaxKeys(100), markerOptions, prefixOptions)
-		if err != nil {
-			return
-		}
-
-		for _, object := range lsRes.Objects {
-			o := ossmod.ObjectInfo{
-				Key:          object.Key,
-				Size:         object.Size,
-				ETag:         object.ETag,
-				LastModified: object.LastModified,
-				StorageClass: object.StorageClass,
-			}
-			objects = append(objects, o)
-		}
-		//// 打印列举结果。默认情况下，一次返回100条记录。
-		//fmt.Println("Objects:", lsRes.Objects)
-		if lsRes.IsTruncated {
-			prefixOptions = oss.Prefix(lsRes.Prefix)
-			markerOptions = oss.Marker(lsRes.NextMarker)
-		} else {
-			break
+	if marker == "" {
+		marker = startAfter
+	}
+	var lsRes oss.ListObjectsResult
+	lsRes, err = bucket.ListObjects(oss.MaxKeys(maxKeys), oss.Marker(marker), oss.Prefix(prefix), oss.Delimiter(delimiter))
+	if err != nil {
+		return
+	}
+	for _, object := range lsRes.Objects {
+		o := ossmod.ObjectInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			ETag:         trimETag(object.ETag),
+			LastModified: object.LastModified,
+			StorageClass: object.StorageClass,
 		}
+		page.objects = append(page.objects, o)
 	}
+	page.prefixes = lsRes.CommonPrefixes
+	page.nextMarker, page.truncated = lsRes.NextMarker, lsRes.IsTruncated
 	return
 }
 
@@ -121,15 +235,21 @@ func (client *ALiYunOss) ListObjects(prefix, startAfter string) (objects []ossmo
  *  @return err
  */
 func (client *ALiYunOss) PutObject(objectName, filePath string) (err error) {
-	var bucket *oss.Bucket
-	// 获取存储桶
-	bucket, err = client.Client.Bucket(client.Bucket)
-	if err != nil {
-		return
-	}
-	// 上传本地文件
-	// 依次填写Object的完整路径（例如exampledir/exampleobject.txt）和本地文件的完整路径（例如D:\\localpath\\examplefile.txt）。
-	err = bucket.PutObjectFromFile(objectName, filePath)
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+// PutObjectCtx
+/**
+ *  @Description: 上传文件，ctx取消时中断传输
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param filePath 本地文件的完整路径
+ *  @return err
+ */
+func (client *ALiYunOss) PutObjectCtx(ctx context.Context, objectName, filePath string) (err error) {
+	defer func() { err = aliyunError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
@@ -143,15 +263,36 @@ func (client *ALiYunOss) PutObject(objectName, filePath string) (err error) {
  *  @return err
  */
 func (client *ALiYunOss) GetObject(objectName, filePath string) (err error) {
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+// GetObjectCtx
+/**
+ *  @Description: 下载文件，ctx取消时中断传输
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param filePath 本地文件的完整路径
+ *  @return err
+ */
+func (client *ALiYunOss) GetObjectCtx(ctx context.Context, objectName, filePath string) (err error) {
+	defer func() { err = aliyunError("GetObject", objectName, err) }()
 	var bucket *oss.Bucket
 	// 获取存储桶
 	bucket, err = client.Client.Bucket(client.Bucket)
 	if err != nil {
 		return
 	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var body io.ReadCloser
+	body, err = bucket.GetObject(objectName)
+	if err != nil {
+		return
+	}
 	// 下载到本地
-	// 依次填写Object的完整路径（例如exampledir/exampleobject.txt）和本地文件的完整路径（例如D:\\localpath\\examplefile.txt）。
-	err = bucket.GetObjectToFile(objectName, filePath)
+	err = copyToFile(ctx, body, filePath)
 	return
 }
 
@@ -164,6 +305,14 @@ func (client *ALiYunOss) GetObject(objectName, filePath string) (err error) {
  *  @return err
  */
 func (client *ALiYunOss) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+func (client *ALiYunOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = aliyunError("RemoveObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	var bucket *oss.Bucket
 	// 获取存储桶
 	bucket, err = client.Client.Bucket(client.Bucket)
@@ -175,6 +324,80 @@ func (client *ALiYunOss) RemoveObject(objectName string) (err error) {
 	return
 }
 
+// RemoveObjects
+/**
+ *  @Description: 批量删除对象
+ *  @receiver client
+ *  @param keys Object的完整路径
+ *  @return failed 删除失败的对象及原因，全部成功时为nil
+ */
+func (client *ALiYunOss) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx
+/**
+ *  @Description: 批量删除对象，SDK只返回删除成功的对象，未返回的对象视为失败
+ *  @receiver client
+ *  @param ctx
+ *  @param keys Object的完整路径
+ *  @return failed 删除失败的对象及原因，全部成功时为nil
+ */
+func (client *ALiYunOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		defer func() { err = aliyunError("RemoveObjects", "", err) }()
+		var bucket *oss.Bucket
+		// 获取存储桶
+		bucket, err = client.Client.Bucket(client.Bucket)
+		if err != nil {
+			return
+		}
+		var result oss.DeleteObjectsResult
+		result, err = bucket.DeleteObjects(batch)
+		if err != nil {
+			return
+		}
+		deleted := make(map[string]bool, len(result.DeletedObjects))
+		for _, key := range result.DeletedObjects {
+			deleted[key] = true
+		}
+		for _, key := range batch {
+			if !deleted[key] {
+				if failed == nil {
+					failed = make(map[string]error)
+				}
+				failed[key] = deleteError("aliyun", key, "", "object was not deleted")
+			}
+		}
+		return
+	})
+}
+
+// RemovePrefix
+/**
+ *  @Description: 删除以prefix开头的全部对象
+ *  @receiver client
+ *  @param prefix 不能为空
+ *  @return err
+ */
+func (client *ALiYunOss) RemovePrefix(prefix string) (err error) {
+	return client.RemovePrefixCtx(context.Background(), prefix)
+}
+
+// RemovePrefixCtx
+/**
+ *  @Description: 删除以prefix开头的全部对象
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix 不能为空
+ *  @return err
+ */
+func (client *ALiYunOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = aliyunError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
 // ObjectExist
 /**
  *  @Description: 判断文件是否存在
@@ -185,6 +408,14 @@ func (client *ALiYunOss) RemoveObject(objectName string) (err error) {
  *  @return err
  */
 func (client *ALiYunOss) ObjectExist(objectName string) (exist bool, err error) {
+	return client.ObjectExistCtx(context.Background(), objectName)
+}
+
+func (client *ALiYunOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = aliyunError("ObjectExist", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	var bucket *oss.Bucket
 	// 获取存储桶
 	bucket, err = client.Client.Bucket(client.Bucket)
@@ -195,3 +426,431 @@ func (client *ALiYunOss) ObjectExist(objectName string) (exist bool, err error)
 	exist, err = bucket.IsObjectExist(objectName)
 	return
 }
+
+// PutObjectStream
+/**
+ *  @Description: 从io.Reader上传对象
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @param r 数据来源
+ *  @param size 数据长度，未知时传-1
+ *  @param opts 可选参数
+ *  @return err
+ */
+func (client *ALiYunOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+func (client *ALiYunOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = aliyunError("PutObjectStream", objectName, err) }()
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	options := aliyunPutOptions(opts)
+	if size >= 0 {
+		options = append(options, oss.ContentLength(size))
+	}
+	err = bucket.PutObject(objectName, &ctxReader{ctx: ctx, r: r}, options...)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+// GetObjectStream
+/**
+ *  @Description: 以流的方式下载对象
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @return body 对象内容，调用方负责关闭
+ *  @return info 对象信息
+ *  @return err
+ */
+func (client *ALiYunOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+func (client *ALiYunOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = aliyunError("GetObjectStream", objectName, err) }()
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var result *oss.GetObjectResult
+	result, err = bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: objectName}, nil)
+	if err != nil {
+		return
+	}
+	info = objectInfoFromHeader(objectName, result.Response.Headers, "x-oss-")
+	info.StorageClass = result.Response.Headers.Get(oss.HTTPHeaderOssStorageClass)
+	body = newCtxReadCloser(ctx, result.Response.Body)
+	return
+}
+
+// GetObjectRange
+/**
+ *  @Description: 读取对象的指定范围，调用方负责关闭返回的io.ReadCloser
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @param offset 起始位置
+ *  @param length 读取的长度，为-1时读取到对象末尾
+ *  @return body
+ *  @return err
+ */
+func (client *ALiYunOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+// GetObjectRangeCtx
+/**
+ *  @Description: 读取对象的指定范围，ctx结束后读取将返回错误
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param offset 起始位置
+ *  @param length 读取的长度，为-1时读取到对象末尾
+ *  @return body
+ *  @return err
+ */
+func (client *ALiYunOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = aliyunError("GetObjectRange", objectName, err) }()
+	var rng string
+	rng, err = rangeHeader(offset, length)
+	if err != nil {
+		return
+	}
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var rc io.ReadCloser
+	// 默认的范围行为下，超出对象长度的范围返回200与完整的对象；standard行为下返回416，由aliyunError转换为ErrInvalidRange
+	rc, err = bucket.GetObject(objectName, oss.NormalizedRange(strings.TrimPrefix(rng, "bytes=")), oss.RangeBehavior("standard"))
+	if err != nil {
+		return
+	}
+	body = newCtxReadCloser(ctx, rc)
+	return
+}
+
+// StatObject
+/**
+ *  @Description: 获取对象信息
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @return info
+ *  @return err
+ */
+func (client *ALiYunOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+func (client *ALiYunOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = aliyunError("StatObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	var header http.Header
+	header, err = bucket.GetObjectDetailedMeta(objectName)
+	if err != nil {
+		return
+	}
+	info = objectInfoFromHeader(objectName, header, "x-oss-")
+	info.StorageClass = header.Get(oss.HTTPHeaderOssStorageClass)
+	return
+}
+
+// CopyObject
+/**
+ *  @Description: 在服务端复制对象
+ *  @receiver client
+ *  @param src 源Object的完整路径
+ *  @param dst 目标Object的完整路径
+ *  @param opts 可选参数，可指定源存储桶
+ *  @return err
+ */
+func (client *ALiYunOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx
+/**
+ *  @Description: 在服务端复制对象
+ *  @receiver client
+ *  @param ctx
+ *  @param src 源Object的完整路径
+ *  @param dst 目标Object的完整路径
+ *  @param opts 可选参数，可指定源存储桶
+ *  @return err
+ */
+func (client *ALiYunOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = aliyunError("CopyObject", src, err) }()
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	_, err = bucket.CopyObjectFrom(copySource(opts, client.Bucket), src, dst)
+	return
+}
+
+// MoveObject
+/**
+ *  @Description: 移动对象，先复制再删除源对象
+ *  @receiver client
+ *  @param src 源Object的完整路径
+ *  @param dst 目标Object的完整路径
+ *  @return err
+ */
+func (client *ALiYunOss) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+// MoveObjectCtx
+/**
+ *  @Description: 移动对象，先复制再删除源对象
+ *  @receiver client
+ *  @param ctx
+ *  @param src 源Object的完整路径
+ *  @param dst 目标Object的完整路径
+ *  @return err
+ */
+func (client *ALiYunOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = aliyunError("MoveObject", src, err) }()
+	err = moveObject(ctx, client, src, dst)
+	return
+}
+
+// PresignGet
+/**
+ *  @Description: 生成下载URL
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @param ttl 有效期
+ *  @return signedURL
+ *  @return err
+ */
+func (client *ALiYunOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = aliyunError("PresignGet", objectName, err) }()
+	signedURL, err = client.signURL(objectName, oss.HTTPGet, ttl)
+	return
+}
+
+// PresignPut
+/**
+ *  @Description: 生成上传URL
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @param ttl 有效期
+ *  @param contentType 上传请求须携带的Content-Type，为空时不限制
+ *  @return signedURL
+ *  @return err
+ */
+func (client *ALiYunOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	defer func() { err = aliyunError("PresignPut", objectName, err) }()
+	var options []oss.Option
+	if contentType != "" {
+		options = append(options, oss.ContentType(contentType))
+	}
+	signedURL, err = client.signURL(objectName, oss.HTTPPut, ttl, options...)
+	return
+}
+
+func (client *ALiYunOss) signURL(objectName string, method oss.HTTPMethod, ttl time.Duration, options ...oss.Option) (signedURL string, err error) {
+	var seconds int64
+	seconds, err = presignSeconds(ttl)
+	if err != nil {
+		return
+	}
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	signedURL, err = bucket.SignURL(objectName, method, seconds, options...)
+	return
+}
+
+// MinPartSize 阿里云要求除最后一个分片外不小于100KB
+func (client *ALiYunOss) MinPartSize() int64 {
+	return oss.MinPartSize
+}
+
+// InitMultipartUploadCtx
+/**
+ *  @Description: 初始化分片上传
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param opts 可选参数
+ *  @return uploadID
+ *  @return err
+ */
+func (client *ALiYunOss) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = aliyunError("InitMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	var imur oss.InitiateMultipartUploadResult
+	imur, err = bucket.InitiateMultipartUpload(objectName, aliyunPutOptions(opts)...)
+	if err != nil {
+		return
+	}
+	uploadID = imur.UploadID
+	return
+}
+
+// UploadPartCtx
+/**
+ *  @Description: 上传分片，ctx取消时中断传输
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param uploadID
+ *  @param partNumber 分片编号，从1开始
+ *  @param r 分片内容
+ *  @param size 分片长度
+ *  @return part
+ *  @return err
+ */
+func (client *ALiYunOss) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = aliyunError("UploadPart", objectName, err) }()
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: client.Bucket, Key: objectName, UploadID: uploadID}
+	var uploaded oss.UploadPart
+	uploaded, err = bucket.UploadPart(imur, &ctxReader{ctx: ctx, r: r}, size, partNumber)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: uploaded.PartNumber, ETag: trimETag(uploaded.ETag), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx
+/**
+ *  @Description: 完成分片上传
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param uploadID
+ *  @param parts 按分片编号升序排列的分片
+ *  @return err
+ */
+func (client *ALiYunOss) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = aliyunError("CompleteMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: client.Bucket, Key: objectName, UploadID: uploadID}
+	uploaded := make([]oss.UploadPart, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, oss.UploadPart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	_, err = bucket.CompleteMultipartUpload(imur, uploaded)
+	return
+}
+
+// AbortMultipartUploadCtx
+/**
+ *  @Description: 终止分片上传
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName Object的完整路径
+ *  @param uploadID
+ *  @return err
+ */
+func (client *ALiYunOss) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = aliyunError("AbortMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var bucket *oss.Bucket
+	// 获取存储桶
+	bucket, err = client.Client.Bucket(client.Bucket)
+	if err != nil {
+		return
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: client.Bucket, Key: objectName, UploadID: uploadID}
+	err = bucket.AbortMultipartUpload(imur)
+	return
+}
+
+// aliyunPutOptions 将上传参数转换为oss的请求选项
+func aliyunPutOptions(opts ossmod.PutOptions) (options []oss.Option) {
+	if opts.ContentType != "" {
+		options = append(options, oss.ContentType(opts.ContentType))
+	}
+	if opts.ContentDisposition != "" {
+		options = append(options, oss.ContentDisposition(opts.ContentDisposition))
+	}
+	if opts.CacheControl != "" {
+		options = append(options, oss.CacheControl(opts.CacheControl))
+	}
+	if opts.ContentEncoding != "" {
+		options = append(options, oss.ContentEncoding(opts.ContentEncoding))
+	}
+	for k, v := range opts.Metadata {
+		options = append(options, oss.Meta(k, v))
+	}
+	if opts.StorageClass != "" {
+		options = append(options, oss.ObjectStorageClass(oss.StorageClassType(opts.StorageClass)))
+	}
+	return
+}
+
+// aliyunError 将阿里云SDK的错误转换为*Error
+func aliyunError(op, key string, err error) error {
+	var se oss.ServiceError
+	if errors.As(err, &se) {
+		return newError("aliyun", op, key, se.StatusCode, se.Code, se.RequestID, err)
+	}
+	return newError("aliyun", op, key, 0, "", "", err)
+}
@@ -6,13 +6,21 @@
 package oss
 
 import (
+	"context"
+	"errors"
 	"github.com/baidubce/bce-sdk-go/bce"
 	"github.com/baidubce/bce-sdk-go/services/bos"
 	"github.com/baidubce/bce-sdk-go/services/bos/api"
 	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"net/http"
+	"sync"
 	"time"
 )
 
+// bosMu bce-sdk-go的每次请求都会写入包级http.Client的Timeout，并发请求存在数据竞争，因此所有BOS请求串行发起
+var bosMu sync.Mutex
+
 type BaiduCloudBos struct {
 	Endpoint  string
 	AccessKey string
@@ -21,6 +29,17 @@ type BaiduCloudBos struct {
 	Client    *bos.Client
 }
 
+func init() {
+	register("baidu", func(cfg Config) (ClientI, error) {
+		client, err := NewBaiduCloudBos(endpointURL(cfg.Endpoint, cfg.UseSSL), cfg.AccessKey, cfg.SecretKey, cfg.Bucket)
+		if err == nil && cfg.Timeout > 0 {
+			// 百度云SDK的超时限制整个请求
+			client.Client.Config.ConnectionTimeoutInMillis = cfg.Timeout * 1000
+		}
+		return client, err
+	}, "endpoint", "access_key", "secret_key", "bucket")
+}
+
 func NewBaiduCloudBos(endpoint, accessKey, secretKey, bucket string) (client *BaiduCloudBos, err error) {
 	client = &BaiduCloudBos{
 		Endpoint:  endpoint,
@@ -40,68 +59,480 @@ func NewBaiduCloudBos(endpoint, accessKey, secretKey, bucket string) (client *Ba
 	return
 }
 
+// Target 返回服务商名称与存储桶
+func (client *BaiduCloudBos) Target() (provider, bucket string) {
+	return "baidu", client.Bucket
+}
+
 func (client *BaiduCloudBos) NewBucket() (err error) {
+	return client.NewBucketCtx(context.Background())
+}
+
+// NewBucketCtx 创建存储桶，百度云SDK不支持context，仅在请求前检查
+func (client *BaiduCloudBos) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = baiduError("NewBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	bosMu.Lock()
 	_, err = client.Client.PutBucket(client.Bucket)
+	bosMu.Unlock()
 	return
 }
 
 func (client *BaiduCloudBos) RemoveBucket() (err error) {
+	return client.RemoveBucketCtx(context.Background())
+}
+
+// RemoveBucketCtx 删除存储桶
+func (client *BaiduCloudBos) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = baiduError("RemoveBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	bosMu.Lock()
 	err = client.Client.DeleteBucket(client.Bucket)
+	bosMu.Unlock()
 	return
 }
 
 func (client *BaiduCloudBos) BucketExist() (exist bool, err error) {
+	return client.BucketExistCtx(context.Background())
+}
+
+// BucketExistCtx 判断存储桶是否存在
+func (client *BaiduCloudBos) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = baiduError("BucketExist", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	bosMu.Lock()
 	exist, err = client.Client.DoesBucketExist(client.Bucket)
+	bosMu.Unlock()
 	return
 }
 
 func (client *BaiduCloudBos) PutObject(objectName string, filePath string) (err error) {
-	_, err = client.Client.PutObjectFromFile(client.Bucket, objectName, filePath, nil)
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+// PutObjectCtx 上传文件，ctx取消时中断传输
+func (client *BaiduCloudBos) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = baiduError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
 func (client *BaiduCloudBos) GetObject(objectName string, filePath string) (err error) {
-	err = client.Client.BasicGetObjectToFile(client.Bucket, objectName, filePath)
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+// GetObjectCtx 下载文件，ctx取消时中断传输
+func (client *BaiduCloudBos) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = baiduError("GetObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var res *api.GetObjectResult
+	bosMu.Lock()
+	res, err = client.Client.BasicGetObject(client.Bucket, objectName)
+	bosMu.Unlock()
+	if err != nil {
+		return
+	}
+	err = copyToFile(ctx, res.Body, filePath)
 	return
 }
 
 func (client *BaiduCloudBos) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+// ListObjectsCtx 获取对象列表
+func (client *BaiduCloudBos) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = baiduError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象
+func (client *BaiduCloudBos) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *BaiduCloudBos) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *BaiduCloudBos) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = baiduError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 请求一页对象，使用marker续接
+func (client *BaiduCloudBos) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = baiduError("ListObjects", "", err) }()
+	if marker == "" {
+		marker = startAfter
+	}
 	args := &api.ListObjectsArgs{
-		Marker: startAfter,
-		Prefix: prefix,
+		Delimiter: delimiter,
+		Marker:    marker,
+		MaxKeys:   maxKeys,
+		Prefix:    prefix,
 	}
 	var listObjectResult *api.ListObjectsResult
+	bosMu.Lock()
 	listObjectResult, err = client.Client.ListObjects(client.Bucket, args)
+	bosMu.Unlock()
 	if err != nil {
 		return
 	}
-	// 打印Contents字段的具体结果
 	for _, obj := range listObjectResult.Contents {
 		o := ossmod.ObjectInfo{
 			Key:          obj.Key,
 			Size:         int64(obj.Size),
-			ETag:         obj.ETag,
+			ETag:         trimETag(obj.ETag),
 			StorageClass: obj.StorageClass,
 		}
 		o.LastModified, _ = time.Parse("2006-01-02T15:04:05Z", obj.LastModified)
-		objects = append(objects, o)
+		page.objects = append(page.objects, o)
+	}
+	for _, p := range listObjectResult.CommonPrefixes {
+		page.prefixes = append(page.prefixes, p.Prefix)
 	}
+	page.nextMarker, page.truncated = listObjectResult.NextMarker, listObjectResult.IsTruncated
 	return
 }
 
 func (client *BaiduCloudBos) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+// RemoveObjectCtx 删除单个文件
+func (client *BaiduCloudBos) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = baiduError("RemoveObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	bosMu.Lock()
 	err = client.Client.DeleteObject(client.Bucket, objectName)
+	bosMu.Unlock()
 	return
 }
 
-func (client *BaiduCloudBos) ObjectExist(objectName string) (exist bool, err error) {
-	_, err = client.Client.GetObjectMeta(client.Bucket, objectName)
-	if realErr, ok := err.(*bce.BceServiceError); ok {
-		if realErr.StatusCode == 404 {
-			exist = false
+func (client *BaiduCloudBos) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx 批量删除对象
+func (client *BaiduCloudBos) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		defer func() { err = baiduError("RemoveObjects", "", err) }()
+		var result *api.DeleteMultipleObjectsResult
+		bosMu.Lock()
+		result, err = client.Client.DeleteMultipleObjectsFromKeyList(client.Bucket, batch)
+		bosMu.Unlock()
+		if err != nil || result == nil {
 			return
 		}
+		for _, e := range result.Errors {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[e.Key] = deleteError("baidu", e.Key, e.Code, e.Message)
+		}
+		return
+	})
+}
+
+func (client *BaiduCloudBos) RemovePrefix(prefix string) (err error) {
+	return client.RemovePrefixCtx(context.Background(), prefix)
+}
+
+// RemovePrefixCtx 删除以prefix开头的全部对象
+func (client *BaiduCloudBos) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = baiduError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
+func (client *BaiduCloudBos) ObjectExist(objectName string) (exist bool, err error) {
+	return client.ObjectExistCtx(context.Background(), objectName)
+}
+
+// ObjectExistCtx 判断文件是否存在
+func (client *BaiduCloudBos) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = baiduError("ObjectExist", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	_, err = client.StatObjectCtx(ctx, objectName)
+	exist, err = existResult(err, ErrObjectNotFound)
+	return
+}
+
+func (client *BaiduCloudBos) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 从io.Reader上传对象，百度云SDK会将数据读入内存计算MD5
+func (client *BaiduCloudBos) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = baiduError("PutObjectStream", objectName, err) }()
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	var body *bce.Body
+	body, err = bce.NewBodyFromSizedReader(&ctxReader{ctx: ctx, r: r}, size)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return
+	}
+	// bos上传时不支持设置ContentEncoding
+	args := &api.PutObjectArgs{
+		ContentType:        opts.ContentType,
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		UserMeta:           opts.Metadata,
+		StorageClass:       opts.StorageClass,
+	}
+	bosMu.Lock()
+	_, err = client.Client.PutObject(client.Bucket, objectName, body, args)
+	bosMu.Unlock()
+	return
+}
+
+func (client *BaiduCloudBos) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+// GetObjectStreamCtx 以流的方式下载对象
+func (client *BaiduCloudBos) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = baiduError("GetObjectStream", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var res *api.GetObjectResult
+	bosMu.Lock()
+	res, err = client.Client.BasicGetObject(client.Bucket, objectName)
+	bosMu.Unlock()
+	if err != nil {
+		return
+	}
+	info = baiduObjectInfo(objectName, res.ObjectMeta)
+	body = newCtxReadCloser(ctx, res.Body)
+	return
+}
+
+func (client *BaiduCloudBos) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+// GetObjectRangeCtx 读取对象的指定范围
+func (client *BaiduCloudBos) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = baiduError("GetObjectRange", objectName, err) }()
+	if _, err = rangeHeader(offset, length); err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	ranges := []int64{offset}
+	if length > 0 {
+		ranges = append(ranges, offset+length-1)
+	}
+	var res *api.GetObjectResult
+	// 只串行到收到响应头为止，响应体的读取可以并发
+	bosMu.Lock()
+	res, err = client.Client.GetObject(client.Bucket, objectName, nil, ranges...)
+	bosMu.Unlock()
+	if err != nil {
+		return
+	}
+	body = newCtxReadCloser(ctx, res.Body)
+	return
+}
+
+func (client *BaiduCloudBos) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+// StatObjectCtx 获取对象信息
+func (client *BaiduCloudBos) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = baiduError("StatObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var result *api.GetObjectMetaResult
+	bosMu.Lock()
+	result, err = client.Client.GetObjectMeta(client.Bucket, objectName)
+	bosMu.Unlock()
+	if err != nil {
+		return
 	}
-	exist = true
+	info = baiduObjectInfo(objectName, result.ObjectMeta)
 	return
 }
+
+// baiduObjectInfo 转换bos返回的对象元数据
+func baiduObjectInfo(key string, meta api.ObjectMeta) (info ossmod.ObjectInfo) {
+	info = ossmod.ObjectInfo{
+		Key:                key,
+		Size:               meta.ContentLength,
+		ETag:               trimETag(meta.ETag),
+		StorageClass:       meta.StorageClass,
+		ContentType:        meta.ContentType,
+		ContentLength:      meta.ContentLength,
+		ContentDisposition: meta.ContentDisposition,
+		CacheControl:       meta.CacheControl,
+		ContentEncoding:    meta.ContentEncoding,
+		Metadata:           userMeta(meta.UserMeta),
+	}
+	info.LastModified, _ = http.ParseTime(meta.LastModified)
+	info.Expires, _ = http.ParseTime(meta.Expires)
+	return
+}
+
+func (client *BaiduCloudBos) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx 在服务端复制对象
+func (client *BaiduCloudBos) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = baiduError("CopyObject", src, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	bosMu.Lock()
+	_, err = client.Client.BasicCopyObject(client.Bucket, dst, copySource(opts, client.Bucket), src)
+	bosMu.Unlock()
+	return
+}
+
+func (client *BaiduCloudBos) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+// MoveObjectCtx 移动对象，先复制再删除源对象
+func (client *BaiduCloudBos) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = baiduError("MoveObject", src, err) }()
+	err = moveObject(ctx, client, src, dst)
+	return
+}
+
+// PresignGet 生成下载URL
+func (client *BaiduCloudBos) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = baiduError("PresignGet", objectName, err) }()
+	var seconds int64
+	seconds, err = presignSeconds(ttl)
+	if err != nil {
+		return
+	}
+	signedURL = client.Client.GeneratePresignedUrl(client.Bucket, objectName, int(seconds), http.MethodGet, nil, nil)
+	return
+}
+
+// PresignPut 生成上传URL，contentType不为空时参与签名
+func (client *BaiduCloudBos) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	defer func() { err = baiduError("PresignPut", objectName, err) }()
+	var seconds int64
+	seconds, err = presignSeconds(ttl)
+	if err != nil {
+		return
+	}
+	var headers map[string]string
+	if contentType != "" {
+		headers = map[string]string{"Content-Type": contentType}
+	}
+	signedURL = client.Client.GeneratePresignedUrl(client.Bucket, objectName, int(seconds), http.MethodPut, headers, nil)
+	return
+}
+
+// MinPartSize 百度云要求除最后一个分片外不小于100KB
+func (client *BaiduCloudBos) MinPartSize() int64 {
+	return bos.MIN_MULTIPART_SIZE
+}
+
+// InitMultipartUploadCtx 初始化分片上传
+func (client *BaiduCloudBos) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = baiduError("InitMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	// bos初始化分片上传时不支持设置ContentEncoding与用户元数据
+	args := &api.InitiateMultipartUploadArgs{
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		StorageClass:       opts.StorageClass,
+	}
+	var result *api.InitiateMultipartUploadResult
+	bosMu.Lock()
+	result, err = client.Client.InitiateMultipartUpload(client.Bucket, objectName, opts.ContentType, args)
+	bosMu.Unlock()
+	if err != nil {
+		return
+	}
+	uploadID = result.UploadId
+	return
+}
+
+// UploadPartCtx 上传分片，百度云SDK会将分片读入内存
+func (client *BaiduCloudBos) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = baiduError("UploadPart", objectName, err) }()
+	var body *bce.Body
+	body, err = bce.NewBodyFromSizedReader(&ctxReader{ctx: ctx, r: r}, size)
+	if err != nil {
+		return
+	}
+	var etag string
+	bosMu.Lock()
+	etag, err = client.Client.BasicUploadPart(client.Bucket, objectName, uploadID, partNumber, body)
+	bosMu.Unlock()
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: partNumber, ETag: trimETag(etag), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx 完成分片上传
+func (client *BaiduCloudBos) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = baiduError("CompleteMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	args := &api.CompleteMultipartUploadArgs{}
+	for _, part := range parts {
+		args.Parts = append(args.Parts, api.UploadInfoType{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	bosMu.Lock()
+	_, err = client.Client.CompleteMultipartUploadFromStruct(client.Bucket, objectName, uploadID, args)
+	bosMu.Unlock()
+	return
+}
+
+// AbortMultipartUploadCtx 终止分片上传
+func (client *BaiduCloudBos) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = baiduError("AbortMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	bosMu.Lock()
+	err = client.Client.AbortMultipartUpload(client.Bucket, objectName, uploadID)
+	bosMu.Unlock()
+	return
+}
+
+// baiduError 将百度云SDK的错误转换为*Error
+func baiduError(op, key string, err error) error {
+	var se *bce.BceServiceError
+	if errors.As(err, &se) {
+		return newError("baidu", op, key, se.StatusCode, se.Code, se.RequestId, err)
+	}
+	return newError("baidu", op, key, 0, "", "", err)
+}
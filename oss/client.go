@@ -6,9 +6,11 @@
 package oss
 
 import (
+	"context"
 	"fmt"
 	"github.com/melf-xyzh/go-oss-client/model"
-	"github.com/qiniu/go-sdk/v7/storage"
+	"io"
+	"time"
 )
 
 type ClientI interface {
@@ -24,30 +26,89 @@ type ClientI interface {
 	GetObject(objectName string, filePath string) (err error)
 	// ListObjects 列出对象
 	ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error)
+	// ListDir 按分隔符列举prefix下一级的对象与子目录（公共前缀），delimiter为空时使用"/"
+	ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error)
 	// RemoveObject 删除对象
 	RemoveObject(objectName string) (err error)
 	// ObjectExist 判断对象是否存在
 	ObjectExist(objectName string) (exist bool, err error)
+	// PutObjectStream 从io.Reader上传对象，size未知时传-1
+	PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error)
+	// GetObjectStream 以流的方式下载对象，调用方负责关闭返回的io.ReadCloser
+	GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error)
+	// GetObjectRange 读取对象中自offset起length字节的内容，length为-1时读取到对象末尾
+	GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error)
+	// StatObject 获取对象信息，对象不存在时返回ErrObjectNotFound
+	StatObject(objectName string) (info ossmod.ObjectInfo, err error)
+	// CopyObject 在服务端复制对象，dst已存在时将被覆盖
+	CopyObject(src, dst string, opts ossmod.CopyOptions) (err error)
+	// MoveObject 移动（重命名）当前存储桶内的对象，dst已存在时将被覆盖
+	MoveObject(src, dst string) (err error)
+	// RemoveObjects 批量删除对象，返回删除失败的对象及原因，全部成功时为nil；对象不存在不视为失败
+	RemoveObjects(keys []string) (failed map[string]error)
+	// RemovePrefix 删除以prefix开头的全部对象，prefix不能为空
+	RemovePrefix(prefix string) (err error)
+	// PresignGet 生成有效期为ttl的下载URL
+	PresignGet(objectName string, ttl time.Duration) (signedURL string, err error)
+	// PresignPut 生成有效期为ttl的上传URL，contentType不为空时上传请求须携带相同的Content-Type
+	PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error)
+	// Target 返回服务商名称与存储桶，服务商名称与NewClientFromConfig使用的名称一致；断点文件据此判断是否属于同一目标
+	Target() (provider, bucket string)
+
+	ClientCtxI
+}
+
+// ClientCtxI 携带context的接口，调用方可通过ctx传递超时时间与取消信号
+type ClientCtxI interface {
+	// NewBucketCtx 创建存储桶
+	NewBucketCtx(ctx context.Context) (err error)
+	// RemoveBucketCtx 删除存储桶
+	RemoveBucketCtx(ctx context.Context) (err error)
+	// BucketExistCtx 判断存储桶是否存在
+	BucketExistCtx(ctx context.Context) (exist bool, err error)
+	// PutObjectCtx 上传对象
+	PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error)
+	// GetObjectCtx 下载对象
+	GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error)
+	// ListObjectsCtx 列出对象
+	ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error)
+	// RemoveObjectCtx 删除对象
+	RemoveObjectCtx(ctx context.Context, objectName string) (err error)
+	// ObjectExistCtx 判断对象是否存在
+	ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error)
+	// PutObjectStreamCtx 从io.Reader上传对象
+	PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error)
+	// GetObjectStreamCtx 以流的方式下载对象，ctx结束后读取将返回错误
+	GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error)
+	// GetObjectRangeCtx 读取对象的指定范围，offset超出对象长度时返回ErrInvalidRange
+	GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error)
+	// ListDirCtx 按分隔符列举prefix下一级的对象与子目录
+	ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error)
+	// ListObjectsIter 按页遍历对象，适用于对象数量较多的存储桶
+	ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator
+	// StatObjectCtx 获取对象信息
+	StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error)
+	// RemoveObjectsCtx 批量删除对象
+	RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error)
+	// RemovePrefixCtx 删除以prefix开头的全部对象
+	RemovePrefixCtx(ctx context.Context, prefix string) (err error)
+	// CopyObjectCtx 在服务端复制对象
+	CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error)
+	// MoveObjectCtx 移动（重命名）对象
+	MoveObjectCtx(ctx context.Context, src, dst string) (err error)
 }
 
+// NewClient 使用环境变量中的配置创建name对应的客户端
+//
+// Deprecated: 使用NewClientFromConfig，配置可通过LoadConfig从文件与环境变量加载
 func NewClient(name string) (client ClientI, err error) {
-	switch name {
-	case "aliyun":
-		client, err = NewALiYunOss("", "", "", "")
-	case "tencent":
-		client, err = NewTencentCloudOss("", "", "", 15)
-	case "minio":
-		client, err = NewMinioOss("", "", "", "", 15, false)
-	case "qiniu":
-		client = NewQiNiuCloudOss("", "", "", "", 15, false, storage.RIDHuadong)
-	case "upyun":
-		client = NewUpYunOss("", "", "")
-	case "baidu":
-		client, err = NewBaiduCloudBos("", "", "", "")
-	case "huawei":
-		client, err = NewHuaweiCloudObs("", "", "", "")
+	var cfg Config
+	cfg, err = ConfigFromEnv()
+	if err != nil {
+		return
 	}
-	return
+	cfg.Provider = name
+	return NewClientFromConfig(cfg)
 }
 
 type Template struct {
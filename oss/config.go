@@ -0,0 +1,206 @@
+/**
+ * @Time    :2026/10/18 20:30
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config 创建客户端所需的配置，可从YAML、JSON、TOML文件与环境变量加载
+type Config struct {
+	// Provider 服务商：aliyun、tencent、minio、qiniu、upyun、baidu、huawei、local、memory，或通过Register注册的名称
+	Provider string `json:"provider" yaml:"provider" toml:"provider"`
+	// Endpoint 服务地址；tencent为存储桶URL，qiniu与upyun为下载域名，local为根目录；
+	// minio只填写主机名与端口，其余服务商不含协议时按UseSSL补充http://或https://
+	Endpoint string `json:"endpoint" yaml:"endpoint" toml:"endpoint"`
+	// Region 区域，目前仅qiniu使用，为空时为华东
+	Region string `json:"region" yaml:"region" toml:"region"`
+	// Bucket 存储桶，tencent的存储桶包含在Endpoint中
+	Bucket string `json:"bucket" yaml:"bucket" toml:"bucket"`
+	// AccessKey 访问密钥ID，upyun为操作员名
+	AccessKey string `json:"access_key" yaml:"access_key" toml:"access_key"`
+	// SecretKey 访问密钥，upyun为操作员密码
+	SecretKey string `json:"secret_key" yaml:"secret_key" toml:"secret_key"`
+	// Timeout 请求超时时间，单位秒，0表示使用SDK的默认值；minio、tencent、baidu限制整个请求，
+	// aliyun、huawei、qiniu、upyun限制建立连接、等待响应与每次读写的时间；local与memory不支持
+	Timeout int `json:"timeout" yaml:"timeout" toml:"timeout"`
+	// UseSSL 是否使用https；Endpoint已包含协议时以Endpoint为准，与http://同时使用时校验失败；
+	// upyun的接口地址固定，为false时使用http访问接口；local与memory不支持
+	UseSSL bool `json:"use_ssl" yaml:"use_ssl" toml:"use_ssl"`
+}
+
+// 环境变量名，LoadConfig读取文件后使用已设置的环境变量覆盖对应字段
+const (
+	EnvProvider  = "OSS_PROVIDER"
+	EnvEndpoint  = "OSS_ENDPOINT"
+	EnvRegion    = "OSS_REGION"
+	EnvBucket    = "OSS_BUCKET"
+	EnvAccessKey = "OSS_ACCESS_KEY"
+	EnvSecretKey = "OSS_SECRET_KEY"
+	EnvTimeout   = "OSS_TIMEOUT"
+	EnvUseSSL    = "OSS_USE_SSL"
+)
+
+// LoadConfig
+/**
+ *  @Description: 加载配置，按扩展名（.yaml/.yml、.json、.toml）解析文件后使用环境变量覆盖
+ *  @param filePath 配置文件路径，为空时只读取环境变量
+ *  @return cfg 未校验的配置，可交给NewClientFromConfig校验
+ *  @return err
+ */
+func LoadConfig(filePath string) (cfg Config, err error) {
+	return LoadConfigWithEnvPrefix(filePath, "OSS_")
+}
+
+// LoadConfigWithEnvPrefix
+/**
+ *  @Description: 与LoadConfig相同，但使用envPrefix开头的环境变量覆盖，如envPrefix为"OSS_SRC_"时读取OSS_SRC_BUCKET；
+ *  用于同一进程中加载多份配置，避免OSS_开头的环境变量同时覆盖每一份配置
+ *  @param filePath 配置文件路径，为空时只读取环境变量
+ *  @param envPrefix 环境变量名前缀，替换EnvProvider等常量中的"OSS_"
+ *  @return cfg 未校验的配置
+ *  @return err
+ */
+func LoadConfigWithEnvPrefix(filePath, envPrefix string) (cfg Config, err error) {
+	if filePath != "" {
+		var data []byte
+		data, err = ioutil.ReadFile(filePath)
+		if err != nil {
+			return
+		}
+		switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &cfg)
+		case ".json":
+			err = json.Unmarshal(data, &cfg)
+		case ".toml":
+			err = toml.Unmarshal(data, &cfg)
+		default:
+			err = fmt.Errorf("oss config: unsupported file type %q", ext)
+		}
+		if err != nil {
+			err = fmt.Errorf("oss config: parse %s: %w", filePath, err)
+			return
+		}
+	}
+	err = cfg.loadEnv(envPrefix)
+	return
+}
+
+// ConfigFromEnv 只从环境变量加载配置
+func ConfigFromEnv() (cfg Config, err error) {
+	return LoadConfig("")
+}
+
+// loadEnv 使用已设置的环境变量覆盖配置，环境变量名为prefix加常量中"OSS_"之后的部分
+func (cfg *Config) loadEnv(prefix string) (err error) {
+	env := func(name string) string {
+		return prefix + strings.TrimPrefix(name, "OSS_")
+	}
+	for name, field := range map[string]*string{
+		EnvProvider:  &cfg.Provider,
+		EnvEndpoint:  &cfg.Endpoint,
+		EnvRegion:    &cfg.Region,
+		EnvBucket:    &cfg.Bucket,
+		EnvAccessKey: &cfg.AccessKey,
+		EnvSecretKey: &cfg.SecretKey,
+	} {
+		if v, ok := os.LookupEnv(env(name)); ok {
+			*field = v
+		}
+	}
+	if v, ok := os.LookupEnv(env(EnvTimeout)); ok {
+		if cfg.Timeout, err = strconv.Atoi(v); err != nil {
+			err = fmt.Errorf("oss config: %s: %w", env(EnvTimeout), err)
+			return
+		}
+	}
+	if v, ok := os.LookupEnv(env(EnvUseSSL)); ok {
+		if cfg.UseSSL, err = strconv.ParseBool(v); err != nil {
+			err = fmt.Errorf("oss config: %s: %w", env(EnvUseSSL), err)
+			return
+		}
+	}
+	return
+}
+
+// Validate 校验服务商是否已注册以及内置服务商的必填字段是否齐全，错误信息列出全部缺失的字段
+func (cfg Config) Validate() error {
+	if cfg.Provider == "" {
+		return fmt.Errorf("oss config: missing provider")
+	}
+	p, err := lookupProvider(cfg.Provider)
+	if err != nil {
+		return err
+	}
+	values := map[string]string{
+		"endpoint":   cfg.Endpoint,
+		"bucket":     cfg.Bucket,
+		"access_key": cfg.AccessKey,
+		"secret_key": cfg.SecretKey,
+	}
+	var missing []string
+	for _, field := range p.required {
+		if values[field] == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("oss config: provider %s requires %s", cfg.Provider, strings.Join(missing, ", "))
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("oss config: timeout must not be negative")
+	}
+	if p.offline && (cfg.Timeout != 0 || cfg.UseSSL) {
+		return fmt.Errorf("oss config: provider %s does not support timeout or use_ssl", cfg.Provider)
+	}
+	if cfg.UseSSL && strings.HasPrefix(strings.ToLower(cfg.Endpoint), "http://") {
+		return fmt.Errorf("oss config: use_ssl conflicts with endpoint %s", cfg.Endpoint)
+	}
+	return nil
+}
+
+// endpointURL 为不含协议的endpoint补充协议，useSSL为true时使用https
+func endpointURL(endpoint string, useSSL bool) string {
+	if endpoint == "" || strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	if useSSL {
+		return "https://" + endpoint
+	}
+	return "http://" + endpoint
+}
+
+// NewClientFromConfig
+/**
+ *  @Description: 根据配置创建客户端
+ *  @param cfg
+ *  @return client
+ *  @return err 配置不完整时返回缺失的字段
+ */
+func NewClientFromConfig(cfg Config) (client ClientI, err error) {
+	if err = cfg.Validate(); err != nil {
+		return
+	}
+	var p provider
+	if p, err = lookupProvider(cfg.Provider); err != nil {
+		return
+	}
+	client, err = p.factory(cfg)
+	if err != nil {
+		// 避免返回包含nil指针的非nil接口
+		client = nil
+	}
+	return
+}
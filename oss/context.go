@@ -0,0 +1,144 @@
+/**
+ * @Time    :2026/10/18 10:12
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// timeoutCtx
+/**
+ *  @Description: 根据超时时间（秒）创建context，超时时间小于等于0时不设置超时
+ *  @param timeOut
+ *  @return context.Context
+ *  @return context.CancelFunc
+ */
+func timeoutCtx(timeOut int) (context.Context, context.CancelFunc) {
+	if timeOut <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(timeOut)*time.Second)
+}
+
+// ctxReader 每次读取前检查context，使不支持context的SDK在传输过程中也能响应取消
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err != nil {
+		return
+	}
+	return r.r.Read(p)
+}
+
+// closeOnDone
+/**
+ *  @Description: context结束时关闭c，使阻塞中的读取立即返回
+ *  @param ctx
+ *  @param c
+ *  @return stop 传输正常结束后调用，释放监听协程
+ */
+func closeOnDone(ctx context.Context, c io.Closer) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// copyToFile
+/**
+ *  @Description: 将body写入本地文件，期间响应ctx的取消
+ *  先写入filePath+".tmp"，完整写入后再重命名，传输中断时不会在filePath留下不完整的文件
+ *  @param ctx
+ *  @param body
+ *  @param filePath
+ *  @return err
+ */
+func copyToFile(ctx context.Context, body io.ReadCloser, filePath string) (err error) {
+	stop := closeOnDone(ctx, body)
+	defer stop()
+	defer body.Close()
+	tmpPath := filePath + ".tmp"
+	var file *os.File
+	file, err = os.Create(tmpPath)
+	if err != nil {
+		return
+	}
+	_, err = io.Copy(file, &ctxReader{ctx: ctx, r: body})
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return
+}
+
+// closerFunc 将函数适配为io.Closer
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// ctxReadCloser 读取时检查ctx，ctx结束时关闭底层body
+type ctxReadCloser struct {
+	ctxReader
+	c    io.Closer
+	stop func()
+}
+
+// newCtxReadCloser
+/**
+ *  @Description: 为不支持context的SDK返回的body绑定ctx
+ *  @param ctx
+ *  @param rc
+ *  @return io.ReadCloser
+ */
+func newCtxReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	return &ctxReadCloser{
+		ctxReader: ctxReader{ctx: ctx, r: rc},
+		c:         rc,
+		stop:      closeOnDone(ctx, rc),
+	}
+}
+
+func (r *ctxReadCloser) Close() error {
+	r.stop()
+	return r.c.Close()
+}
+
+// cancelReadCloser 关闭body时释放context
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
@@ -0,0 +1,264 @@
+/**
+ * @Time    :2026/10/18 22:30
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// KeyProvider 管理用于包装数据密钥的主密钥，可对接KMS等密钥管理服务
+type KeyProvider interface {
+	// CurrentKeyID 当前用于WrapKey的主密钥ID，轮换主密钥后改变
+	CurrentKeyID() string
+	// WrapKey 使用当前主密钥加密数据密钥，返回主密钥ID与密文
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey 使用keyID对应的主密钥解密数据密钥
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) (dataKey []byte, err error)
+}
+
+// StaticKeyProvider 使用内存中的AES-256主密钥包装数据密钥
+// 轮换时加入新的主密钥并将其设为当前主密钥，旧主密钥保留用于解密尚未重新包装的对象
+type StaticKeyProvider struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewStaticKeyProvider
+/**
+ *  @Description: 创建StaticKeyProvider
+ *  @param currentID 当前主密钥的ID，须包含在keys中
+ *  @param keys 主密钥ID到32字节主密钥的映射
+ *  @return provider
+ *  @return err
+ */
+func NewStaticKeyProvider(currentID string, keys map[string][]byte) (provider *StaticKeyProvider, err error) {
+	if _, ok := keys[currentID]; !ok {
+		err = fmt.Errorf("oss: current key %q not found", currentID)
+		return
+	}
+	provider = &StaticKeyProvider{current: currentID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if len(key) != 32 {
+			provider, err = nil, fmt.Errorf("oss: key %q must be 32 bytes, got %d", id, len(key))
+			return
+		}
+		if provider.keys[id], err = newGCM(key); err != nil {
+			provider = nil
+			return
+		}
+	}
+	return
+}
+
+func (p *StaticKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+// WrapKey 密文为12字节随机nonce加AES-GCM密文，主密钥ID作为附加数据
+func (p *StaticKeyProvider) WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error) {
+	keyID = p.current
+	aead := p.keys[keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+	wrapped = aead.Seal(nonce, nonce, dataKey, []byte(keyID))
+	return
+}
+
+func (p *StaticKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) (dataKey []byte, err error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		err = fmt.Errorf("oss: unknown key %q", keyID)
+		return
+	}
+	if len(wrapped) < aead.NonceSize() {
+		err = errors.New("oss: wrapped key too short")
+		return
+	}
+	dataKey, err = aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+	return
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 对象按segmentSize分段加密，每段的密文比明文多segmentTag字节；
+// 第n段的nonce为n的大端序，最后一段以附加数据标记，截断或重排分段都会导致解密失败
+const (
+	segmentSize = 64 * 1024
+	segmentTag  = 16
+)
+
+// encryptedSize 明文长度为size时的密文长度，size未知（小于0）时返回-1
+func encryptedSize(size int64) int64 {
+	if size < 0 {
+		return -1
+	}
+	segments := (size + segmentSize - 1) / segmentSize
+	if segments == 0 {
+		segments = 1
+	}
+	return size + segments*segmentTag
+}
+
+// plaintextSize 密文长度为size时的明文长度，不是有效的密文长度时返回-1
+func plaintextSize(size int64) int64 {
+	segments := (size + segmentSize + segmentTag - 1) / (segmentSize + segmentTag)
+	if segments == 0 || size-segments*segmentTag < 0 {
+		return -1
+	}
+	return size - segments*segmentTag
+}
+
+func segmentNonce(seq uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], seq)
+	return nonce
+}
+
+func segmentAAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// encryptReader 读取时对src分段加密
+type encryptReader struct {
+	aead cipher.AEAD
+	src  io.Reader
+	seq  uint64
+	// buf 多读一个字节以判断当前段是否为最后一段，pending为上次多读的字节数
+	buf     []byte
+	pending int
+	sealed  []byte
+	out     []byte
+	done    bool
+}
+
+func newEncryptReader(aead cipher.AEAD, src io.Reader) *encryptReader {
+	return &encryptReader{
+		aead:   aead,
+		src:    src,
+		buf:    make([]byte, segmentSize+1),
+		sealed: make([]byte, 0, segmentSize+segmentTag),
+	}
+}
+
+func (r *encryptReader) Read(p []byte) (n int, err error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err = r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, r.out)
+	r.out = r.out[n:]
+	return
+}
+
+func (r *encryptReader) fill() error {
+	n, err := io.ReadFull(r.src, r.buf[r.pending:])
+	n += r.pending
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	size := n
+	if !last {
+		size = segmentSize
+	}
+	r.out = r.aead.Seal(r.sealed[:0], segmentNonce(r.seq), r.buf[:size], segmentAAD(last))
+	r.seq++
+	if last {
+		r.done = true
+	} else {
+		r.buf[0] = r.buf[segmentSize]
+		r.pending = 1
+	}
+	return nil
+}
+
+// decryptReader 读取时对src分段解密，src从第seq段的起始位置开始，size为整个对象的密文长度
+type decryptReader struct {
+	aead   cipher.AEAD
+	src    io.Reader
+	seq    uint64
+	size   int64
+	buf    []byte
+	opened []byte
+	out    []byte
+	done   bool
+}
+
+func newDecryptReader(aead cipher.AEAD, src io.Reader, size int64, seq uint64) *decryptReader {
+	return &decryptReader{
+		aead:   aead,
+		src:    src,
+		seq:    seq,
+		size:   size,
+		buf:    make([]byte, segmentSize+segmentTag),
+		opened: make([]byte, 0, segmentSize),
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (n int, err error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err = r.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, r.out)
+	r.out = r.out[n:]
+	return
+}
+
+func (r *decryptReader) fill() (err error) {
+	pos := int64(r.seq) * (segmentSize + segmentTag)
+	length := r.size - pos
+	if length > segmentSize+segmentTag {
+		length = segmentSize + segmentTag
+	}
+	if length < segmentTag {
+		return fmt.Errorf("ciphertext length %d: %w", r.size, ErrDecryptFailed)
+	}
+	if _, err = io.ReadFull(r.src, r.buf[:length]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	last := pos+length == r.size
+	r.out, err = r.aead.Open(r.opened[:0], segmentNonce(r.seq), r.buf[:length], segmentAAD(last))
+	if err != nil {
+		return fmt.Errorf("segment %d: %w", r.seq, ErrDecryptFailed)
+	}
+	r.seq++
+	r.done = last
+	return
+}
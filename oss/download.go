@@ -0,0 +1,358 @@
+/**
+ * @Time    :2026/10/18 18:10
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// downloadCheckpoint 断点文件的内容，对象变化后断点失效
+type downloadCheckpoint struct {
+	// Provider 与 Bucket 为下载的来源，与ClientI.Target一致
+	Provider   string `json:"provider"`
+	Bucket     string `json:"bucket"`
+	ObjectName string `json:"object_name"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+	PartSize   int64  `json:"part_size"`
+	// Parts 已下载的分片编号，从1开始
+	Parts []int `json:"parts"`
+}
+
+// DownloadFile
+/**
+ *  @Description: 分片并发下载对象到本地文件，支持断点续传
+ *  数据先写入filePath+".download"，全部分片完成并校验大小与ETag后再重命名为filePath；
+ *  启用断点时，下载失败会保留临时文件与断点文件，再次调用时只下载缺失的分片，对象已变化时重新下载；
+ *  断点属于其他服务商或存储桶时返回错误
+ *  @param ctx
+ *  @param client
+ *  @param objectName
+ *  @param filePath 本地文件的完整路径
+ *  @param opts
+ *  @return err
+ */
+func DownloadFile(ctx context.Context, client ClientI, objectName, filePath string, opts ossmod.DownloadOptions) (err error) {
+	var info ossmod.ObjectInfo
+	info, err = client.StatObjectCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	partSize := opts.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	provider, bucket := client.Target()
+	d := &download{
+		client:   client,
+		filePath: filePath,
+		tmpPath:  filePath + ".download",
+		opts:     opts,
+		checkpoint: downloadCheckpoint{
+			Provider:   provider,
+			Bucket:     bucket,
+			ObjectName: objectName,
+			ETag:       info.ETag,
+			Size:       info.Size,
+			PartSize:   partSize,
+		},
+	}
+	if !opts.DisableCheckpoint {
+		d.cpPath = opts.CheckpointFile
+		if d.cpPath == "" {
+			d.cpPath = filePath + ".download.cp"
+		}
+	}
+	err = d.run(ctx)
+	return
+}
+
+// download 一次分片下载的状态
+type download struct {
+	client   ClientI
+	filePath string
+	// tmpPath 下载中的临时文件
+	tmpPath string
+	opts    ossmod.DownloadOptions
+	// cpPath 断点文件路径，为空时不记录断点
+	cpPath     string
+	mu         sync.Mutex
+	checkpoint downloadCheckpoint
+}
+
+func (d *download) run(ctx context.Context) (err error) {
+	var resumed bool
+	if resumed, err = d.loadCheckpoint(); err != nil {
+		return
+	}
+	if !resumed {
+		// 没有可用的断点，丢弃残留的临时文件
+		if err = os.Remove(d.tmpPath); err != nil && !os.IsNotExist(err) {
+			return
+		}
+		err = nil
+	}
+	var file *os.File
+	file, err = os.OpenFile(d.tmpPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return
+	}
+	err = file.Truncate(d.checkpoint.Size)
+	if err == nil {
+		err = d.saveCheckpoint()
+	}
+	if err == nil {
+		err = d.downloadParts(ctx, file)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = d.verify(ctx)
+	}
+	if err == nil {
+		err = os.Rename(d.tmpPath, d.filePath)
+	}
+	if err == nil || d.cpPath == "" {
+		d.removeCheckpoint()
+	}
+	if err != nil && d.cpPath == "" {
+		os.Remove(d.tmpPath)
+	}
+	return
+}
+
+// downloadParts 并发下载尚未完成的分片，任一分片失败时取消其余分片
+func (d *download) downloadParts(ctx context.Context, file *os.File) (err error) {
+	done := make(map[int]bool, len(d.checkpoint.Parts))
+	for _, n := range d.checkpoint.Parts {
+		done[n] = true
+	}
+	size, partSize := d.checkpoint.Size, d.checkpoint.PartSize
+	count := int((size + partSize - 1) / partSize)
+	pending := make(chan int, count)
+	for n := 1; n <= count; n++ {
+		if !done[n] {
+			pending <- n
+		}
+	}
+	close(pending)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var once sync.Once
+	fail := func(e error) {
+		once.Do(func() {
+			err = e
+			cancel()
+		})
+	}
+	concurrency := d.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+				offset := int64(n-1) * partSize
+				length := partSize
+				if offset+length > size {
+					length = size - offset
+				}
+				if e := d.downloadPart(ctx, file, offset, length); e != nil {
+					fail(e)
+					return
+				}
+				if e := d.addPart(n); e != nil {
+					fail(e)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+// downloadPart 下载一个分片并写入文件的对应位置
+func (d *download) downloadPart(ctx context.Context, file *os.File, offset, length int64) (err error) {
+	var body io.ReadCloser
+	body, err = d.client.GetObjectRangeCtx(ctx, d.checkpoint.ObjectName, offset, length)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	var n int64
+	n, err = io.Copy(&offsetWriter{w: file, off: offset}, io.LimitReader(body, length))
+	if err == nil && n != length {
+		err = fmt.Errorf("download %s: range at %d expected %d bytes, read %d", d.checkpoint.ObjectName, offset, length, n)
+	}
+	return
+}
+
+// verify 校验临时文件的大小，ETag为内容的MD5时校验MD5，并确认下载期间对象未被修改
+func (d *download) verify(ctx context.Context) (err error) {
+	var fi os.FileInfo
+	fi, err = os.Stat(d.tmpPath)
+	if err != nil {
+		return
+	}
+	if fi.Size() != d.checkpoint.Size {
+		err = fmt.Errorf("download %s: expected %d bytes, got %d", d.checkpoint.ObjectName, d.checkpoint.Size, fi.Size())
+		return
+	}
+	var info ossmod.ObjectInfo
+	info, err = d.client.StatObjectCtx(ctx, d.checkpoint.ObjectName)
+	if err != nil {
+		return
+	}
+	if info.ETag != d.checkpoint.ETag || info.Size != d.checkpoint.Size {
+		d.discard()
+		err = fmt.Errorf("download %s: object changed during download", d.checkpoint.ObjectName)
+		return
+	}
+	if !isMD5ETag(d.checkpoint.ETag) {
+		return
+	}
+	var sum string
+	sum, err = fileMD5(d.tmpPath)
+	if err != nil {
+		return
+	}
+	if !strings.EqualFold(sum, d.checkpoint.ETag) {
+		d.discard()
+		err = fmt.Errorf("download %s: md5 %s does not match etag %s", d.checkpoint.ObjectName, sum, d.checkpoint.ETag)
+	}
+	return
+}
+
+// discard 删除临时文件与断点文件，下次调用时重新下载
+func (d *download) discard() {
+	os.Remove(d.tmpPath)
+	d.removeCheckpoint()
+}
+
+func (d *download) addPart(n int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checkpoint.Parts = append(d.checkpoint.Parts, n)
+	return d.writeCheckpoint()
+}
+
+// loadCheckpoint
+/**
+ *  @Description: 读取断点文件，断点与对象当前的状态不一致或临时文件丢失时丢弃断点
+ *  @receiver d
+ *  @return resumed 是否沿用断点中已下载的分片
+ *  @return err 断点属于其他服务商或存储桶时返回错误，不删除断点文件与临时文件
+ */
+func (d *download) loadCheckpoint() (resumed bool, err error) {
+	if d.cpPath == "" {
+		return
+	}
+	data, readErr := ioutil.ReadFile(d.cpPath)
+	if readErr != nil {
+		return
+	}
+	var cp downloadCheckpoint
+	if json.Unmarshal(data, &cp) != nil {
+		d.removeCheckpoint()
+		return
+	}
+	if cp.Provider != d.checkpoint.Provider || cp.Bucket != d.checkpoint.Bucket {
+		err = fmt.Errorf("download %s: checkpoint %s belongs to %s bucket %s", d.checkpoint.ObjectName, d.cpPath, cp.Provider, cp.Bucket)
+		return
+	}
+	if cp.ObjectName != d.checkpoint.ObjectName || cp.ETag != d.checkpoint.ETag ||
+		cp.Size != d.checkpoint.Size || cp.PartSize != d.checkpoint.PartSize {
+		d.removeCheckpoint()
+		return
+	}
+	fi, statErr := os.Stat(d.tmpPath)
+	if statErr != nil || fi.Size() != cp.Size {
+		d.removeCheckpoint()
+		return
+	}
+	d.checkpoint = cp
+	resumed = true
+	return
+}
+
+func (d *download) saveCheckpoint() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.writeCheckpoint()
+}
+
+// writeCheckpoint 写入断点文件，调用方需持有锁
+func (d *download) writeCheckpoint() error {
+	if d.cpPath == "" {
+		return nil
+	}
+	return writeJSONFile(d.cpPath, d.checkpoint)
+}
+
+func (d *download) removeCheckpoint() {
+	if d.cpPath != "" {
+		_ = os.Remove(d.cpPath)
+	}
+}
+
+// offsetWriter 从指定位置开始顺序写入文件
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.WriteAt(p, w.off)
+	w.off += int64(n)
+	return
+}
+
+// isMD5ETag 判断ETag是否为对象内容的MD5，分片上传等方式生成的ETag不是MD5
+func isMD5ETag(etag string) bool {
+	if len(etag) != md5.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(etag)
+	return err == nil
+}
+
+// fileMD5 计算本地文件的MD5
+func fileMD5(filePath string) (sum string, err error) {
+	var file *os.File
+	file, err = os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	hash := md5.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return
+	}
+	sum = hex.EncodeToString(hash.Sum(nil))
+	return
+}
@@ -0,0 +1,614 @@
+/**
+ * @Time    :2026/10/18 22:50
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrEnvelopeNotFound 对象没有加密信封，可能不是通过EncryptedClient上传的
+	ErrEnvelopeNotFound = errors.New("oss: encryption envelope not found")
+	// ErrDecryptFailed 解密失败，数据或加密信封已被篡改、截断，或使用了错误的密钥
+	ErrDecryptFailed = errors.New("oss: decryption failed")
+)
+
+// 加密信封保存在用户元数据中时使用的键
+const (
+	metaEnvelopeKey   = "cse-key"
+	metaEnvelopeKeyID = "cse-key-id"
+	metaEnvelopeAlg   = "cse-alg"
+	// envelopeAlg 数据的加密算法，按64KiB分段的AES-256-GCM
+	envelopeAlg = "AES256-GCM-64K"
+	// defaultSidecarSuffix 信封对象的默认后缀
+	defaultSidecarSuffix = ".cse-envelope"
+	// etagPrefix 返回的ETag是密文的ETag，加上前缀以免被当作明文的MD5校验
+	etagPrefix = "cse-"
+)
+
+// EncryptionOptions 客户端加密的可选参数
+type EncryptionOptions struct {
+	// Sidecar 为true时加密信封保存在对象名加SidecarSuffix的信封对象中，而不是对象的用户元数据中；
+	// StatObject不返回用户元数据的服务商（如七牛云）须使用此方式
+	Sidecar bool
+	// SidecarSuffix 信封对象的后缀，默认为".cse-envelope"；以此结尾的对象不会出现在列举结果中
+	SidecarSuffix string
+}
+
+// envelope 加密信封，保存被主密钥包装后的数据密钥
+type envelope struct {
+	Alg   string `json:"alg"`
+	KeyID string `json:"key_id"`
+	Key   []byte `json:"key"`
+}
+
+// EncryptedClient 客户端加密的装饰器，上传时加密、下载时解密，服务商只能看到密文
+//
+// 每个对象使用随机生成的数据密钥加密，数据密钥由KeyProvider的主密钥包装后保存在加密信封中；
+// 轮换主密钥时只需通过Rewrap重新包装数据密钥，无需重新加密数据。
+// 列举与查询返回明文的大小，ETag为密文的ETag加"cse-"前缀，不是明文的MD5；
+// 不支持预签名与分片上传，UploadFile会直接调用PutObjectStreamCtx
+type EncryptedClient struct {
+	ClientI
+	keys KeyProvider
+	opts EncryptionOptions
+}
+
+// NewEncryptedClient
+/**
+ *  @Description: 创建客户端加密装饰器
+ *  @param client 被包装的客户端
+ *  @param keys 主密钥
+ *  @param opts
+ *  @return *EncryptedClient
+ */
+func NewEncryptedClient(client ClientI, keys KeyProvider, opts EncryptionOptions) *EncryptedClient {
+	if opts.SidecarSuffix == "" {
+		opts.SidecarSuffix = defaultSidecarSuffix
+	}
+	return &EncryptedClient{ClientI: client, keys: keys, opts: opts}
+}
+
+// sidecarName 对象的信封对象名
+func (client *EncryptedClient) sidecarName(objectName string) string {
+	return objectName + client.opts.SidecarSuffix
+}
+
+// isSidecar 判断key是否为信封对象
+func (client *EncryptedClient) isSidecar(key string) bool {
+	return client.opts.Sidecar && strings.HasSuffix(key, client.opts.SidecarSuffix)
+}
+
+// newDataKey 生成数据密钥并用当前主密钥包装
+func (client *EncryptedClient) newDataKey(ctx context.Context) (aead cipher.AEAD, env envelope, err error) {
+	dataKey := make([]byte, 32)
+	if _, err = rand.Read(dataKey); err != nil {
+		return
+	}
+	env, err = client.wrap(ctx, dataKey)
+	if err != nil {
+		return
+	}
+	aead, err = newGCM(dataKey)
+	return
+}
+
+func (client *EncryptedClient) wrap(ctx context.Context, dataKey []byte) (env envelope, err error) {
+	env.Alg = envelopeAlg
+	env.KeyID, env.Key, err = client.keys.WrapKey(ctx, dataKey)
+	return
+}
+
+// readEnvelope
+/**
+ *  @Description: 读取对象的加密信封
+ *  @receiver client
+ *  @param ctx
+ *  @param op
+ *  @param objectName
+ *  @param meta 对象的用户元数据，使用信封对象时忽略
+ *  @return env
+ *  @return err 对象没有加密信封时可通过errors.Is匹配ErrEnvelopeNotFound
+ */
+func (client *EncryptedClient) readEnvelope(ctx context.Context, op, objectName string, meta map[string]string) (env envelope, err error) {
+	if client.opts.Sidecar {
+		var body io.ReadCloser
+		body, _, err = client.ClientI.GetObjectStreamCtx(ctx, client.sidecarName(objectName))
+		if errors.Is(err, ErrObjectNotFound) {
+			err = kindError("encrypted", op, objectName, ErrEnvelopeNotFound)
+		}
+		if err != nil {
+			return
+		}
+		defer body.Close()
+		if err = json.NewDecoder(body).Decode(&env); err != nil {
+			err = fmt.Errorf("oss: invalid encryption envelope of %s: %w", objectName, err)
+			return
+		}
+	} else {
+		if meta[metaEnvelopeKey] == "" {
+			err = kindError("encrypted", op, objectName, ErrEnvelopeNotFound)
+			return
+		}
+		env.Alg = meta[metaEnvelopeAlg]
+		env.KeyID = meta[metaEnvelopeKeyID]
+		if env.Key, err = base64.StdEncoding.DecodeString(meta[metaEnvelopeKey]); err != nil {
+			err = fmt.Errorf("oss: invalid encryption envelope of %s: %w", objectName, err)
+			return
+		}
+	}
+	if env.Alg != envelopeAlg {
+		err = fmt.Errorf("oss: unsupported encryption algorithm %q of %s", env.Alg, objectName)
+	}
+	return
+}
+
+// openEnvelope 读取加密信封并解开数据密钥
+func (client *EncryptedClient) openEnvelope(ctx context.Context, op, objectName string, meta map[string]string) (aead cipher.AEAD, err error) {
+	var env envelope
+	env, err = client.readEnvelope(ctx, op, objectName, meta)
+	if err != nil {
+		return
+	}
+	var dataKey []byte
+	dataKey, err = client.keys.UnwrapKey(ctx, env.KeyID, env.Key)
+	if err != nil {
+		return
+	}
+	aead, err = newGCM(dataKey)
+	return
+}
+
+func (client *EncryptedClient) putSidecar(ctx context.Context, objectName string, env envelope) (err error) {
+	var data []byte
+	data, err = json.Marshal(env)
+	if err != nil {
+		return
+	}
+	err = client.ClientI.PutObjectStreamCtx(ctx, client.sidecarName(objectName), bytes.NewReader(data), int64(len(data)),
+		ossmod.PutOptions{ContentType: "application/json"})
+	return
+}
+
+// withEnvelope 返回加入了加密信封的用户元数据，不修改meta
+func withEnvelope(meta map[string]string, env envelope) map[string]string {
+	m := make(map[string]string, len(meta)+3)
+	for k, v := range meta {
+		m[k] = v
+	}
+	m[metaEnvelopeAlg] = env.Alg
+	m[metaEnvelopeKeyID] = env.KeyID
+	m[metaEnvelopeKey] = base64.StdEncoding.EncodeToString(env.Key)
+	return m
+}
+
+// withoutEnvelope 返回去除了加密信封的用户元数据，没有其他元数据时返回nil
+func withoutEnvelope(meta map[string]string) map[string]string {
+	m := make(map[string]string, len(meta))
+	for k, v := range meta {
+		if k != metaEnvelopeKey && k != metaEnvelopeKeyID && k != metaEnvelopeAlg {
+			m[k] = v
+		}
+	}
+	return userMeta(m)
+}
+
+// plainInfo 将被包装客户端返回的对象信息转换为明文的大小与用户元数据
+func plainInfo(info ossmod.ObjectInfo) ossmod.ObjectInfo {
+	if !info.IsDir {
+		if n := plaintextSize(info.Size); n >= 0 {
+			info.Size = n
+			info.ContentLength = n
+		}
+	}
+	if info.ETag != "" {
+		info.ETag = etagPrefix + info.ETag
+	}
+	info.Metadata = withoutEnvelope(info.Metadata)
+	return info
+}
+
+// plainListed 转换列举结果中的对象信息，目录占位对象加密后不再为空，需按明文的大小重新判断
+func plainListed(info ossmod.ObjectInfo) ossmod.ObjectInfo {
+	info = plainInfo(info)
+	info.IsDir = info.IsDir || isDirPlaceholder(info)
+	return info
+}
+
+// plainObjects 去除信封对象，并转换为明文的大小
+func (client *EncryptedClient) plainObjects(objects []ossmod.ObjectInfo) []ossmod.ObjectInfo {
+	plain := objects[:0]
+	for _, o := range objects {
+		if !client.isSidecar(o.Key) {
+			plain = append(plain, plainListed(o))
+		}
+	}
+	return plain
+}
+
+func (client *EncryptedClient) PutObject(objectName string, filePath string) (err error) {
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+func (client *EncryptedClient) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	// 本地源文件的错误不做归类，避免与对象不存在混淆
+	defer func() { err = newError("encrypted", "PutObject", objectName, 0, "", "", err) }()
+	err = putFile(ctx, client, objectName, filePath)
+	return
+}
+
+func (client *EncryptedClient) GetObject(objectName string, filePath string) (err error) {
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+func (client *EncryptedClient) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	var body io.ReadCloser
+	body, _, err = client.GetObjectStreamCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	err = copyToFile(ctx, body, filePath)
+	return
+}
+
+func (client *EncryptedClient) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 使用新的数据密钥加密后上传，ContentType根据明文检测；
+// 使用信封对象时先上传对象再上传信封对象，两者之间失败会使对象无法解密
+func (client *EncryptedClient) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	var aead cipher.AEAD
+	var env envelope
+	aead, env, err = client.newDataKey(ctx)
+	if err != nil {
+		return
+	}
+	if !client.opts.Sidecar {
+		opts.Metadata = withEnvelope(opts.Metadata, env)
+	}
+	err = client.ClientI.PutObjectStreamCtx(ctx, objectName, newEncryptReader(aead, r), encryptedSize(size), opts)
+	if err == nil && client.opts.Sidecar {
+		err = client.putSidecar(ctx, objectName, env)
+	}
+	return
+}
+
+func (client *EncryptedClient) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+// GetObjectStreamCtx 边下载边解密，数据被篡改时读取返回ErrDecryptFailed
+func (client *EncryptedClient) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	var cipherBody io.ReadCloser
+	cipherBody, info, err = client.ClientI.GetObjectStreamCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	var aead cipher.AEAD
+	aead, err = client.openEnvelope(ctx, "GetObjectStream", objectName, info.Metadata)
+	if err != nil {
+		cipherBody.Close()
+		info = ossmod.ObjectInfo{}
+		return
+	}
+	body = struct {
+		io.Reader
+		io.Closer
+	}{newDecryptReader(aead, cipherBody, info.Size, 0), cipherBody}
+	info = plainInfo(info)
+	return
+}
+
+func (client *EncryptedClient) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+// GetObjectRangeCtx 只下载并解密覆盖该范围的分段
+func (client *EncryptedClient) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	if _, err = rangeHeader(offset, length); err != nil {
+		err = newError("encrypted", "GetObjectRange", objectName, 0, "", "", err)
+		return
+	}
+	var info ossmod.ObjectInfo
+	info, err = client.ClientI.StatObjectCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	size := plaintextSize(info.Size)
+	if offset >= size {
+		err = kindError("encrypted", "GetObjectRange", objectName, ErrInvalidRange)
+		return
+	}
+	var aead cipher.AEAD
+	aead, err = client.openEnvelope(ctx, "GetObjectRange", objectName, info.Metadata)
+	if err != nil {
+		return
+	}
+	end := size
+	if length > 0 && offset+length < size {
+		end = offset + length
+	}
+	first := offset / segmentSize
+	start := first * (segmentSize + segmentTag)
+	stop := ((end-1)/segmentSize + 1) * (segmentSize + segmentTag)
+	if stop > info.Size {
+		stop = info.Size
+	}
+	var cipherBody io.ReadCloser
+	cipherBody, err = client.ClientI.GetObjectRangeCtx(ctx, objectName, start, stop-start)
+	if err != nil {
+		return
+	}
+	r := newDecryptReader(aead, cipherBody, info.Size, uint64(first))
+	if _, err = io.CopyN(ioutil.Discard, r, offset-first*segmentSize); err != nil {
+		cipherBody.Close()
+		return
+	}
+	body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(r, end-offset), cipherBody}
+	return
+}
+
+func (client *EncryptedClient) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+func (client *EncryptedClient) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	info, err = client.ClientI.StatObjectCtx(ctx, objectName)
+	if err == nil {
+		info = plainInfo(info)
+	}
+	return
+}
+
+func (client *EncryptedClient) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+func (client *EncryptedClient) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	objects, err = client.ClientI.ListObjectsCtx(ctx, prefix, startAfter)
+	objects = client.plainObjects(objects)
+	return
+}
+
+func (client *EncryptedClient) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+func (client *EncryptedClient) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	objects, prefixes, err = client.ClientI.ListDirCtx(ctx, prefix, delimiter)
+	objects = client.plainObjects(objects)
+	return
+}
+
+func (client *EncryptedClient) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return &encryptedIterator{ObjectIterator: client.ClientI.ListObjectsIter(ctx, opts), client: client}
+}
+
+// encryptedIterator 跳过信封对象，并返回明文的大小
+type encryptedIterator struct {
+	ObjectIterator
+	client *EncryptedClient
+}
+
+func (it *encryptedIterator) Next() bool {
+	for it.ObjectIterator.Next() {
+		if !it.client.isSidecar(it.ObjectIterator.Object().Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *encryptedIterator) Object() ossmod.ObjectInfo {
+	return plainListed(it.ObjectIterator.Object())
+}
+
+func (client *EncryptedClient) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+// RemoveObjectCtx 使用信封对象时一并删除信封对象
+func (client *EncryptedClient) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	err = client.ClientI.RemoveObjectCtx(ctx, objectName)
+	if err == nil && client.opts.Sidecar {
+		if err = client.ClientI.RemoveObjectCtx(ctx, client.sidecarName(objectName)); errors.Is(err, ErrObjectNotFound) {
+			err = nil
+		}
+	}
+	return
+}
+
+func (client *EncryptedClient) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx 使用信封对象时一并删除信封对象，信封对象删除失败时记为对应对象删除失败
+func (client *EncryptedClient) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	if !client.opts.Sidecar {
+		return client.ClientI.RemoveObjectsCtx(ctx, keys)
+	}
+	all := make([]string, 0, 2*len(keys))
+	sidecars := make(map[string]string, len(keys))
+	for _, key := range keys {
+		all = append(all, key, client.sidecarName(key))
+		sidecars[client.sidecarName(key)] = key
+	}
+	for key, err := range client.ClientI.RemoveObjectsCtx(ctx, all) {
+		if objectName, ok := sidecars[key]; ok {
+			key = objectName
+		}
+		if failed == nil {
+			failed = make(map[string]error)
+		}
+		if _, ok := failed[key]; !ok {
+			failed[key] = err
+		}
+	}
+	return
+}
+
+func (client *EncryptedClient) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx 在服务端复制密文，数据密钥不变；使用信封对象时一并复制信封对象
+func (client *EncryptedClient) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	err = client.ClientI.CopyObjectCtx(ctx, src, dst, opts)
+	if err == nil && client.opts.Sidecar {
+		err = client.ClientI.CopyObjectCtx(ctx, client.sidecarName(src), client.sidecarName(dst), opts)
+	}
+	return
+}
+
+func (client *EncryptedClient) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+// MoveObjectCtx 使用信封对象时一并移动信封对象
+func (client *EncryptedClient) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	if src == dst {
+		return
+	}
+	if client.opts.Sidecar {
+		err = client.ClientI.CopyObjectCtx(ctx, client.sidecarName(src), client.sidecarName(dst), ossmod.CopyOptions{})
+		if err != nil {
+			return
+		}
+	}
+	err = client.ClientI.MoveObjectCtx(ctx, src, dst)
+	if err == nil && client.opts.Sidecar {
+		if err = client.ClientI.RemoveObjectCtx(ctx, client.sidecarName(src)); errors.Is(err, ErrObjectNotFound) {
+			err = nil
+		}
+	}
+	return
+}
+
+// PresignGet 预签名URL下载的是密文，不支持
+func (client *EncryptedClient) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	err = kindError("encrypted", "PresignGet", objectName, ErrNotSupported)
+	return
+}
+
+// PresignPut 预签名URL上传的数据不会被加密，不支持
+func (client *EncryptedClient) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	err = kindError("encrypted", "PresignPut", objectName, ErrNotSupported)
+	return
+}
+
+// RewrapObject
+/**
+ *  @Description: 使用当前主密钥重新包装对象的数据密钥，数据本身不重新加密
+ *  使用信封对象时只重写信封对象；否则需要重新上传密文以更新用户元数据，存储类型恢复为存储桶的默认值
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @return rewrapped 数据密钥已由当前主密钥包装时为false
+ *  @return err
+ */
+func (client *EncryptedClient) RewrapObject(ctx context.Context, objectName string) (rewrapped bool, err error) {
+	var meta map[string]string
+	if !client.opts.Sidecar {
+		var info ossmod.ObjectInfo
+		info, err = client.ClientI.StatObjectCtx(ctx, objectName)
+		if err != nil {
+			return
+		}
+		meta = info.Metadata
+	}
+	var env envelope
+	env, err = client.readEnvelope(ctx, "RewrapObject", objectName, meta)
+	if err != nil || env.KeyID == client.keys.CurrentKeyID() {
+		return
+	}
+	if client.opts.Sidecar {
+		env, err = client.rewrap(ctx, env)
+		if err == nil {
+			err = client.putSidecar(ctx, objectName, env)
+		}
+		rewrapped = err == nil
+		return
+	}
+	var body io.ReadCloser
+	var info ossmod.ObjectInfo
+	body, info, err = client.ClientI.GetObjectStreamCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	// 以下载时的信封为准，避免与StatObject之间对象被覆盖
+	env, err = client.readEnvelope(ctx, "RewrapObject", objectName, info.Metadata)
+	if err == nil {
+		env, err = client.rewrap(ctx, env)
+	}
+	if err != nil {
+		return
+	}
+	opts := copyPutOptions(info)
+	opts.Metadata = withEnvelope(withoutEnvelope(info.Metadata), env)
+	err = client.ClientI.PutObjectStreamCtx(ctx, objectName, body, info.Size, opts)
+	rewrapped = err == nil
+	return
+}
+
+// rewrap 解开数据密钥后使用当前主密钥重新包装
+func (client *EncryptedClient) rewrap(ctx context.Context, env envelope) (envelope, error) {
+	dataKey, err := client.keys.UnwrapKey(ctx, env.KeyID, env.Key)
+	if err != nil {
+		return env, err
+	}
+	return client.wrap(ctx, dataKey)
+}
+
+// Rewrap
+/**
+ *  @Description: 使用当前主密钥重新包装以prefix开头的全部对象的数据密钥，用于轮换主密钥；
+ *  完成后旧的主密钥不再被这些对象使用。出错时停止，已重新包装的对象不受影响，可再次调用继续
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix 为空时处理整个存储桶
+ *  @return count 重新包装的对象数
+ *  @return err
+ */
+func (client *EncryptedClient) Rewrap(ctx context.Context, prefix string) (count int, err error) {
+	it := client.ClientI.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix})
+	for it.Next() {
+		o := it.Object()
+		if client.isSidecar(o.Key) || isDirPlaceholder(o) {
+			continue
+		}
+		var rewrapped bool
+		rewrapped, err = client.RewrapObject(ctx, o.Key)
+		if err != nil {
+			return
+		}
+		if rewrapped {
+			count++
+		}
+	}
+	err = it.Err()
+	return
+}
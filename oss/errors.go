@@ -0,0 +1,197 @@
+/**
+ * @Time    :2026/10/18 16:05
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 各服务商的错误会被转换为*Error，可通过errors.Is与以下错误比较
+var (
+	// ErrObjectNotFound 对象不存在
+	ErrObjectNotFound = errors.New("oss: object not found")
+	// ErrBucketNotFound 存储桶不存在
+	ErrBucketNotFound = errors.New("oss: bucket not found")
+	// ErrAccessDenied 无访问权限
+	ErrAccessDenied = errors.New("oss: access denied")
+	// ErrBucketNotEmpty 存储桶非空
+	ErrBucketNotEmpty = errors.New("oss: bucket not empty")
+	// ErrPreconditionFailed 条件请求不满足
+	ErrPreconditionFailed = errors.New("oss: precondition failed")
+	// ErrInvalidRange 请求的范围无效或超出对象长度
+	ErrInvalidRange = errors.New("oss: invalid range")
+	// ErrUploadNotFound 分片上传不存在，可能已完成、终止或过期
+	ErrUploadNotFound = errors.New("oss: multipart upload not found")
+	// ErrNotSupported 服务商或实现不支持该操作
+	ErrNotSupported = errors.New("oss: operation not supported")
+)
+
+// Error 统一的错误类型，包装服务商SDK返回的原始错误
+type Error struct {
+	// Provider 服务商，与NewClient的名称一致
+	Provider string
+	// Op 出错的操作，如 PutObject
+	Op string
+	// Key 操作的对象名，存储桶操作时为空
+	Key string
+	// StatusCode HTTP状态码，无法获取时为0
+	StatusCode int
+	// Code 服务端返回的错误码
+	Code string
+	// RequestID 服务端返回的请求ID
+	RequestID string
+	// Err SDK返回的原始错误
+	Err error
+
+	kind error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Provider)
+	b.WriteString(" ")
+	b.WriteString(e.Op)
+	if e.Key != "" {
+		b.WriteString(" ")
+		b.WriteString(e.Key)
+	}
+	b.WriteString(": ")
+	// kindError创建的错误Err即为kind，不重复输出
+	if e.kind != nil && e.kind != e.Err {
+		b.WriteString(strings.TrimPrefix(e.kind.Error(), "oss: "))
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id: %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 判断是否为指定的哨兵错误
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// bucketOps 存储桶级别的操作，404表示存储桶不存在
+var bucketOps = map[string]bool{
+	"NewBucket":    true,
+	"RemoveBucket": true,
+	"BucketExist":  true,
+	"ListObjects":  true,
+}
+
+// classify
+/**
+ *  @Description: 根据HTTP状态码与服务端错误码判断错误类别
+ *  @param op
+ *  @param status
+ *  @param code
+ *  @return error 对应的哨兵错误，无法归类时为nil
+ */
+func classify(op string, status int, code string) error {
+	switch {
+	case strings.Contains(code, "NoSuchBucket"):
+		return ErrBucketNotFound
+	case strings.Contains(code, "NoSuchUpload"):
+		return ErrUploadNotFound
+	case strings.Contains(code, "NoSuchKey"):
+		return ErrObjectNotFound
+	case strings.Contains(code, "BucketNotEmpty"):
+		return ErrBucketNotEmpty
+	case code == "AccessDenied":
+		return ErrAccessDenied
+	case code == "InvalidRange":
+		return ErrInvalidRange
+	}
+	switch status {
+	case http.StatusNotFound:
+		if bucketOps[op] {
+			return ErrBucketNotFound
+		}
+		return ErrObjectNotFound
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return ErrAccessDenied
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusRequestedRangeNotSatisfiable:
+		return ErrInvalidRange
+	case http.StatusConflict:
+		if op == "RemoveBucket" {
+			return ErrBucketNotEmpty
+		}
+	}
+	return nil
+}
+
+// newError
+/**
+ *  @Description: 构造*Error，err为nil时返回nil，err已是*Error时原样返回
+ *  @param provider
+ *  @param op
+ *  @param key
+ *  @param status
+ *  @param code
+ *  @param requestID
+ *  @param err
+ *  @return error
+ */
+func newError(provider, op, key string, status int, code, requestID string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{
+		Provider:   provider,
+		Op:         op,
+		Key:        key,
+		StatusCode: status,
+		Code:       code,
+		RequestID:  requestID,
+		Err:        err,
+		kind:       classify(op, status, code),
+	}
+}
+
+// kindError 构造不依赖SDK的错误，用于本地与内存实现
+func kindError(provider, op, key string, kind error) error {
+	return &Error{
+		Provider: provider,
+		Op:       op,
+		Key:      key,
+		Err:      kind,
+		kind:     kind,
+	}
+}
+
+// existResult
+/**
+ *  @Description: 将判断存在性的请求结果转换为exist，kind类错误视为不存在
+ *  @param err 已转换的错误
+ *  @param kind ErrObjectNotFound或ErrBucketNotFound
+ *  @return exist
+ *  @return rerr
+ */
+func existResult(err, kind error) (exist bool, rerr error) {
+	if err == nil {
+		exist = true
+		return
+	}
+	if !errors.Is(err, kind) {
+		rerr = err
+	}
+	return
+}
@@ -6,10 +6,13 @@
 package oss
 
 import (
+	"context"
+	"errors"
 	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 	"github.com/melf-xyzh/go-oss-client/model"
 	"io"
-	"os"
+	"net/http"
+	"time"
 )
 
 type HuaweiCloudObs struct {
@@ -20,7 +23,18 @@ type HuaweiCloudObs struct {
 	Client    *obs.ObsClient
 }
 
+func init() {
+	register("huawei", func(cfg Config) (ClientI, error) {
+		return newHuaweiCloudObs(endpointURL(cfg.Endpoint, cfg.UseSSL), cfg.AccessKey, cfg.SecretKey, cfg.Bucket, cfg.Timeout)
+	}, "endpoint", "access_key", "secret_key", "bucket")
+}
+
 func NewHuaweiCloudObs(endpoint, accessKey, secretKey, bucket string) (client *HuaweiCloudObs, err error) {
+	return newHuaweiCloudObs(endpoint, accessKey, secretKey, bucket, 0)
+}
+
+// newHuaweiCloudObs timeOut大于0时限制建立连接、等待响应头与每次读写的时间，单位秒
+func newHuaweiCloudObs(endpoint, accessKey, secretKey, bucket string, timeOut int) (client *HuaweiCloudObs, err error) {
 	client = &HuaweiCloudObs{
 		Endpoint:  endpoint,
 		AccessKey: accessKey,
@@ -28,11 +42,30 @@ func NewHuaweiCloudObs(endpoint, accessKey, secretKey, bucket string) (client *H
 		Bucket:    bucket,
 	}
 	// 创建ObsClient结构体
+	if timeOut > 0 {
+		client.Client, err = obs.New(client.AccessKey, client.SecretKey, client.Endpoint,
+			obs.WithConnectTimeout(timeOut), obs.WithHeaderTimeout(timeOut), obs.WithSocketTimeout(timeOut))
+		return
+	}
 	client.Client, err = obs.New(client.AccessKey, client.SecretKey, client.Endpoint)
 	return
 }
 
+// Target 返回服务商名称与存储桶
+func (client *HuaweiCloudObs) Target() (provider, bucket string) {
+	return "huawei", client.Bucket
+}
+
 func (client *HuaweiCloudObs) NewBucket() (err error) {
+	return client.NewBucketCtx(context.Background())
+}
+
+// NewBucketCtx 创建存储桶，华为云SDK仅支持在创建客户端时设置context，此处在请求前检查
+func (client *HuaweiCloudObs) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = huaweiError("NewBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	input := &obs.CreateBucketInput{}
 	input.Bucket = client.Bucket
 	input.Location = "bucketlocation"
@@ -44,54 +77,106 @@ func (client *HuaweiCloudObs) NewBucket() (err error) {
 }
 
 func (client *HuaweiCloudObs) RemoveBucket() (err error) {
+	return client.RemoveBucketCtx(context.Background())
+}
+
+// RemoveBucketCtx 删除存储桶
+func (client *HuaweiCloudObs) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = huaweiError("RemoveBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	_, err = client.Client.DeleteBucket(client.Bucket)
 	return
 }
 
 func (client *HuaweiCloudObs) BucketExist() (exist bool, err error) {
-	_, err = client.Client.HeadBucket(client.Bucket)
-	if err == nil {
-		exist = true
-	} else {
-		exist = false
+	return client.BucketExistCtx(context.Background())
+}
+
+// BucketExistCtx 判断存储桶是否存在
+func (client *HuaweiCloudObs) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = huaweiError("BucketExist", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
 	}
+	_, err = client.Client.HeadBucket(client.Bucket)
+	exist, err = existResult(huaweiError("BucketExist", "", err), ErrBucketNotFound)
 	return
 }
 
 func (client *HuaweiCloudObs) PutObject(objectName string, filePath string) (err error) {
-	input := &obs.PutFileInput{}
-	input.Bucket = client.Bucket
-	input.Key = objectName
-	input.SourceFile = filePath
-	_, err = client.Client.PutFile(input)
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+// PutObjectCtx 上传文件，ctx取消时中断传输
+func (client *HuaweiCloudObs) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = huaweiError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
 func (client *HuaweiCloudObs) GetObject(objectName string, filePath string) (err error) {
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+// GetObjectCtx 下载文件，ctx取消时中断传输
+func (client *HuaweiCloudObs) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = huaweiError("GetObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	input := &obs.GetObjectInput{}
 	input.Bucket = client.Bucket
 	input.Key = objectName
 	var output *obs.GetObjectOutput
 	output, err = client.Client.GetObject(input)
 	if err == nil {
-		defer output.Body.Close()
-		var file *os.File
-		file, err = os.Create(filePath)
-		if err != nil {
-			return
-		}
-		defer file.Close()
 		// 拷贝文件
-		_, err = io.Copy(file, output.Body)
+		err = copyToFile(ctx, output.Body, filePath)
 	}
 	return
 }
 
 func (client *HuaweiCloudObs) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+// ListObjectsCtx 获取对象列表
+func (client *HuaweiCloudObs) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = huaweiError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象
+func (client *HuaweiCloudObs) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *HuaweiCloudObs) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *HuaweiCloudObs) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = huaweiError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 请求一页对象，使用marker续接
+func (client *HuaweiCloudObs) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = huaweiError("ListObjects", "", err) }()
+	if marker == "" {
+		marker = startAfter
+	}
 	input := &obs.ListObjectsInput{}
 	input.Bucket = client.Bucket
 	input.Prefix = prefix
-	input.Marker = startAfter
+	input.Delimiter = delimiter
+	input.Marker = marker
+	input.MaxKeys = maxKeys
 	var output *obs.ListObjectsOutput
 	output, err = client.Client.ListObjects(input)
 	if err != nil {
@@ -101,16 +186,27 @@ func (client *HuaweiCloudObs) ListObjects(prefix, startAfter string) (objects []
 		o := ossmod.ObjectInfo{
 			Key:          val.Key,
 			Size:         val.Size,
-			ETag:         val.ETag,
+			ETag:         trimETag(val.ETag),
 			LastModified: val.LastModified,
 			StorageClass: string(val.StorageClass),
 		}
-		objects = append(objects, o)
+		page.objects = append(page.objects, o)
 	}
+	page.prefixes = output.CommonPrefixes
+	page.nextMarker, page.truncated = output.NextMarker, output.IsTruncated
 	return
 }
 
 func (client *HuaweiCloudObs) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+// RemoveObjectCtx 删除单个文件
+func (client *HuaweiCloudObs) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = huaweiError("RemoveObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	input := &obs.DeleteObjectInput{}
 	input.Bucket = client.Bucket
 	input.Key = objectName
@@ -118,15 +214,346 @@ func (client *HuaweiCloudObs) RemoveObject(objectName string) (err error) {
 	return
 }
 
+func (client *HuaweiCloudObs) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx 批量删除对象，使用静默模式只返回删除失败的对象
+func (client *HuaweiCloudObs) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		defer func() { err = huaweiError("RemoveObjects", "", err) }()
+		input := &obs.DeleteObjectsInput{Bucket: client.Bucket, Quiet: true}
+		for _, key := range batch {
+			input.Objects = append(input.Objects, obs.ObjectToDelete{Key: key})
+		}
+		var output *obs.DeleteObjectsOutput
+		output, err = client.Client.DeleteObjects(input)
+		if err != nil {
+			return
+		}
+		for _, e := range output.Errors {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[e.Key] = deleteError("huawei", e.Key, e.Code, e.Message)
+		}
+		return
+	})
+}
+
+func (client *HuaweiCloudObs) RemovePrefix(prefix string) (err error) {
+	return client.RemovePrefixCtx(context.Background(), prefix)
+}
+
+// RemovePrefixCtx 删除以prefix开头的全部对象
+func (client *HuaweiCloudObs) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = huaweiError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
 func (client *HuaweiCloudObs) ObjectExist(objectName string) (exist bool, err error) {
+	return client.ObjectExistCtx(context.Background(), objectName)
+}
+
+// ObjectExistCtx 判断文件是否存在
+func (client *HuaweiCloudObs) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = huaweiError("ObjectExist", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	_, err = client.StatObjectCtx(ctx, objectName)
+	exist, err = existResult(err, ErrObjectNotFound)
+	return
+}
+
+func (client *HuaweiCloudObs) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 从io.Reader上传对象
+func (client *HuaweiCloudObs) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = huaweiError("PutObjectStream", objectName, err) }()
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	r, size, err = sizedReader(&ctxReader{ctx: ctx, r: r}, size)
+	if err != nil {
+		return
+	}
+	input := &obs.PutObjectInput{}
+	input.ObjectOperationInput = huaweiObjectInput(client.Bucket, objectName, opts)
+	input.ContentLength = size
+	input.HttpHeader = obs.HttpHeader{
+		ContentType:        opts.ContentType,
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		ContentEncoding:    opts.ContentEncoding,
+	}
+	input.Body = r
+	_, err = client.Client.PutObject(input)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+func (client *HuaweiCloudObs) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+// GetObjectStreamCtx 以流的方式下载对象
+func (client *HuaweiCloudObs) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = huaweiError("GetObjectStream", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	input := &obs.GetObjectInput{}
+	input.Bucket = client.Bucket
+	input.Key = objectName
+	var output *obs.GetObjectOutput
+	output, err = client.Client.GetObject(input)
+	if err != nil {
+		return
+	}
+	info = huaweiObjectInfo(objectName, &output.GetObjectMetadataOutput)
+	body = newCtxReadCloser(ctx, output.Body)
+	return
+}
+
+func (client *HuaweiCloudObs) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+// GetObjectRangeCtx 读取对象的指定范围，SDK的RangeStart/RangeEnd无法表示单字节与开放区间，因此直接设置Range请求头
+func (client *HuaweiCloudObs) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = huaweiError("GetObjectRange", objectName, err) }()
+	var rng string
+	rng, err = rangeHeader(offset, length)
+	if err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	input := &obs.GetObjectInput{}
+	input.Bucket = client.Bucket
+	input.Key = objectName
+	var output *obs.GetObjectOutput
+	output, err = client.Client.GetObject(input, obs.WithCustomHeader("Range", rng))
+	if err != nil {
+		return
+	}
+	body = newCtxReadCloser(ctx, output.Body)
+	return
+}
+
+func (client *HuaweiCloudObs) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+// StatObjectCtx 获取对象信息
+func (client *HuaweiCloudObs) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = huaweiError("StatObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	input := &obs.GetObjectMetadataInput{}
 	input.Bucket = client.Bucket
 	input.Key = objectName
-	_, err = client.Client.GetObjectMetadata(input)
+	var output *obs.GetObjectMetadataOutput
+	output, err = client.Client.GetObjectMetadata(input)
 	if err != nil {
-		exist = false
-	} else {
-		exist = true
+		return
+	}
+	info = huaweiObjectInfo(objectName, output)
+	return
+}
+
+// huaweiObjectInfo 转换obs返回的对象元数据
+func huaweiObjectInfo(key string, output *obs.GetObjectMetadataOutput) (info ossmod.ObjectInfo) {
+	info = ossmod.ObjectInfo{
+		Key:                key,
+		Size:               output.ContentLength,
+		ETag:               trimETag(output.ETag),
+		LastModified:       output.LastModified,
+		StorageClass:       string(output.StorageClass),
+		ContentType:        output.ContentType,
+		ContentLength:      output.ContentLength,
+		ContentDisposition: output.ContentDisposition,
+		CacheControl:       output.CacheControl,
+		ContentEncoding:    output.ContentEncoding,
+		Metadata:           userMeta(output.Metadata),
+		VersionID:          output.VersionId,
+	}
+	info.Expires, _ = http.ParseTime(output.HttpExpires)
+	return
+}
+
+// huaweiObjectInput 将上传参数中的存储类型与元数据转换为obs的请求参数
+func huaweiObjectInput(bucket, key string, opts ossmod.PutOptions) obs.ObjectOperationInput {
+	return obs.ObjectOperationInput{
+		Bucket:       bucket,
+		Key:          key,
+		StorageClass: obs.StorageClassType(opts.StorageClass),
+		Metadata:     opts.Metadata,
+	}
+}
+
+func (client *HuaweiCloudObs) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx 在服务端复制对象
+func (client *HuaweiCloudObs) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = huaweiError("CopyObject", src, err) }()
+	if err = ctx.Err(); err != nil {
+		return
 	}
+	input := &obs.CopyObjectInput{}
+	input.Bucket = client.Bucket
+	input.Key = dst
+	input.CopySourceBucket = copySource(opts, client.Bucket)
+	input.CopySourceKey = src
+	_, err = client.Client.CopyObject(input)
+	return
+}
+
+func (client *HuaweiCloudObs) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+// MoveObjectCtx 移动对象，先复制再删除源对象
+func (client *HuaweiCloudObs) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = huaweiError("MoveObject", src, err) }()
+	err = moveObject(ctx, client, src, dst)
 	return
 }
+
+// PresignGet 生成下载URL
+func (client *HuaweiCloudObs) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = huaweiError("PresignGet", objectName, err) }()
+	signedURL, err = client.signURL(objectName, obs.HttpMethodGet, ttl, nil)
+	return
+}
+
+// PresignPut 生成上传URL，contentType不为空时参与签名
+func (client *HuaweiCloudObs) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	defer func() { err = huaweiError("PresignPut", objectName, err) }()
+	var headers map[string]string
+	if contentType != "" {
+		headers = map[string]string{"Content-Type": contentType}
+	}
+	signedURL, err = client.signURL(objectName, obs.HttpMethodPut, ttl, headers)
+	return
+}
+
+func (client *HuaweiCloudObs) signURL(objectName string, method obs.HttpMethodType, ttl time.Duration, headers map[string]string) (signedURL string, err error) {
+	var seconds int64
+	seconds, err = presignSeconds(ttl)
+	if err != nil {
+		return
+	}
+	var output *obs.CreateSignedUrlOutput
+	output, err = client.Client.CreateSignedUrl(&obs.CreateSignedUrlInput{
+		Method:  method,
+		Bucket:  client.Bucket,
+		Key:     objectName,
+		Expires: int(seconds),
+		Headers: headers,
+	})
+	if err != nil {
+		return
+	}
+	signedURL = output.SignedUrl
+	return
+}
+
+// MinPartSize 华为云要求除最后一个分片外不小于100KB
+func (client *HuaweiCloudObs) MinPartSize() int64 {
+	return obs.MIN_PART_SIZE
+}
+
+// InitMultipartUploadCtx 初始化分片上传
+func (client *HuaweiCloudObs) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = huaweiError("InitMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	input := &obs.InitiateMultipartUploadInput{}
+	input.ObjectOperationInput = huaweiObjectInput(client.Bucket, objectName, opts)
+	// SDK初始化分片上传时只支持ContentType，ContentDisposition、CacheControl、ContentEncoding不生效
+	input.ContentType = opts.ContentType
+	var output *obs.InitiateMultipartUploadOutput
+	output, err = client.Client.InitiateMultipartUpload(input)
+	if err != nil {
+		return
+	}
+	uploadID = output.UploadId
+	return
+}
+
+// UploadPartCtx 上传分片，ctx取消时中断传输
+func (client *HuaweiCloudObs) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = huaweiError("UploadPart", objectName, err) }()
+	input := &obs.UploadPartInput{
+		Bucket:     client.Bucket,
+		Key:        objectName,
+		PartNumber: partNumber,
+		UploadId:   uploadID,
+		Body:       &ctxReader{ctx: ctx, r: r},
+		PartSize:   size,
+	}
+	var output *obs.UploadPartOutput
+	output, err = client.Client.UploadPart(input)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: partNumber, ETag: trimETag(output.ETag), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx 完成分片上传
+func (client *HuaweiCloudObs) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = huaweiError("CompleteMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	input := &obs.CompleteMultipartUploadInput{
+		Bucket:   client.Bucket,
+		Key:      objectName,
+		UploadId: uploadID,
+	}
+	for _, part := range parts {
+		input.Parts = append(input.Parts, obs.Part{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	_, err = client.Client.CompleteMultipartUpload(input)
+	return
+}
+
+// AbortMultipartUploadCtx 终止分片上传
+func (client *HuaweiCloudObs) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = huaweiError("AbortMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	_, err = client.Client.AbortMultipartUpload(&obs.AbortMultipartUploadInput{
+		Bucket:   client.Bucket,
+		Key:      objectName,
+		UploadId: uploadID,
+	})
+	return
+}
+
+// huaweiError 将华为云SDK的错误转换为*Error
+func huaweiError(op, key string, err error) error {
+	var oe obs.ObsError
+	if errors.As(err, &oe) {
+		return newError("huawei", op, key, oe.StatusCode, oe.Code, oe.RequestId, err)
+	}
+	return newError("huawei", op, key, 0, "", "", err)
+}
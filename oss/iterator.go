@@ -0,0 +1,261 @@
+/**
+ * @Time    :2026/10/18 19:40
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"sort"
+	"strings"
+)
+
+// maxListKeys 单次列举请求的最大对象数，各服务商均不超过1000
+const maxListKeys = 1000
+
+// ObjectIterator 按对象名升序逐个遍历对象，内部按页请求，内存占用只与每页大小有关
+//
+//	it := client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: "logs/"})
+//	for it.Next() {
+//		fmt.Println(it.Object().Key)
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type ObjectIterator interface {
+	// Next 前进到下一个对象，遍历结束或出错时返回false
+	Next() bool
+	// Object 返回当前对象，须在Next返回true后调用
+	Object() ossmod.ObjectInfo
+	// Err 返回遍历中遇到的错误，正常结束时为nil
+	Err() error
+	// PageToken 返回续接令牌，传入ListOptions.PageToken后从当前对象之后继续遍历
+	PageToken() string
+}
+
+// objectPage 一次列举请求的结果
+type objectPage struct {
+	// objects 本页的对象，按对象名升序
+	objects []ossmod.ObjectInfo
+	// prefixes 指定分隔符时本页的公共前缀
+	prefixes []string
+	// nextMarker 下一页的续接标记，服务商未返回时为空，此时从本页最后一个对象之后继续
+	nextMarker string
+	// truncated 是否还有下一页
+	truncated bool
+}
+
+// listPageFunc
+/**
+ *  @Description: 请求一页对象，由各服务商实现
+ *  @param ctx
+ *  @param prefix
+ *  @param delimiter 为空时递归列举
+ *  @param startAfter 从该对象名之后开始列举，marker不为空时为空
+ *  @param marker 上一页返回的服务商续接标记
+ *  @param maxKeys 本页最大条目数
+ *  @return page
+ *  @return err
+ */
+type listPageFunc func(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error)
+
+// objectIterator 基于listPageFunc的ObjectIterator实现
+type objectIterator struct {
+	ctx     context.Context
+	prefix  string
+	maxKeys int
+	list    listPageFunc
+	page    []ossmod.ObjectInfo
+	object  ossmod.ObjectInfo
+	// last 已返回的最后一个对象名
+	last string
+	// startAfter 与 marker 为下一次请求的起始位置
+	startAfter string
+	marker     string
+	done       bool
+	err        error
+}
+
+func newObjectIterator(ctx context.Context, opts ossmod.ListOptions, list listPageFunc) *objectIterator {
+	maxKeys := opts.MaxKeys
+	if maxKeys <= 0 || maxKeys > maxListKeys {
+		maxKeys = maxListKeys
+	}
+	last := opts.StartAfter
+	if opts.PageToken != "" {
+		last = opts.PageToken
+	}
+	return &objectIterator{
+		ctx:        ctx,
+		prefix:     opts.Prefix,
+		maxKeys:    maxKeys,
+		list:       list,
+		last:       last,
+		startAfter: last,
+	}
+}
+
+func (it *objectIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	it.object = it.page[0]
+	it.page = it.page[1:]
+	it.last = it.object.Key
+	return true
+}
+
+// fetch 请求下一页，过滤服务商可能返回的重复对象与不匹配前缀的对象
+func (it *objectIterator) fetch() {
+	if it.err = it.ctx.Err(); it.err != nil {
+		return
+	}
+	page, err := it.list(it.ctx, it.prefix, "", it.startAfter, it.marker, it.maxKeys)
+	if err != nil {
+		it.err = err
+		return
+	}
+	for _, o := range page.objects {
+		if o.Key > it.last && strings.HasPrefix(o.Key, it.prefix) {
+			o.IsDir = isDirPlaceholder(o)
+			it.page = append(it.page, o)
+		}
+	}
+	switch {
+	case !page.truncated:
+		it.done = true
+	case page.nextMarker != "":
+		it.startAfter, it.marker = "", page.nextMarker
+	case len(page.objects) > 0:
+		it.startAfter, it.marker = page.objects[len(page.objects)-1].Key, ""
+	default:
+		// 服务商声明还有数据却未返回任何续接位置，避免死循环
+		it.done = true
+	}
+}
+
+func (it *objectIterator) Object() ossmod.ObjectInfo {
+	return it.object
+}
+
+func (it *objectIterator) Err() error {
+	return it.err
+}
+
+func (it *objectIterator) PageToken() string {
+	return it.last
+}
+
+// collectObjects 遍历迭代器中的全部对象
+func collectObjects(it ObjectIterator) (objects []ossmod.ObjectInfo, err error) {
+	for it.Next() {
+		objects = append(objects, it.Object())
+	}
+	err = it.Err()
+	return
+}
+
+// listDir
+/**
+ *  @Description: 按分隔符列举prefix下一级的对象与公共前缀，自动翻页
+ *  @param ctx
+ *  @param list
+ *  @param prefix
+ *  @param delimiter 为空时使用"/"
+ *  @return objects 按对象名升序
+ *  @return prefixes 按字典序升序
+ *  @return err
+ */
+func listDir(ctx context.Context, list listPageFunc, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	if delimiter == "" {
+		delimiter = "/"
+	}
+	var startAfter, marker string
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		var page objectPage
+		page, err = list(ctx, prefix, delimiter, startAfter, marker, maxListKeys)
+		if err != nil {
+			return
+		}
+		for _, o := range page.objects {
+			o.IsDir = isDirPlaceholder(o)
+			objects = append(objects, o)
+		}
+		for _, p := range page.prefixes {
+			// 客户端分组的实现跨页时可能返回相同的前缀
+			if len(prefixes) == 0 || prefixes[len(prefixes)-1] != p {
+				prefixes = append(prefixes, p)
+			}
+		}
+		if !page.truncated {
+			return
+		}
+		// 未返回续接标记时从本页最后一个对象或公共前缀之后继续，公共前缀之后不会再返回其下的对象
+		last := ""
+		if len(page.objects) > 0 {
+			last = page.objects[len(page.objects)-1].Key
+		}
+		if len(page.prefixes) > 0 && page.prefixes[len(page.prefixes)-1] > last {
+			last = page.prefixes[len(page.prefixes)-1]
+		}
+		switch {
+		case page.nextMarker != "":
+			startAfter, marker = "", page.nextMarker
+		case last > startAfter:
+			startAfter, marker = last, ""
+		default:
+			// 服务商声明还有数据却未返回新的续接位置，返回错误而不是截断结果
+			err = fmt.Errorf("truncated listing of %q has no continuation position after %q", prefix, startAfter)
+			return
+		}
+	}
+}
+
+// isDirPlaceholder 判断对象是否为以"/"结尾的空目录占位对象
+func isDirPlaceholder(o ossmod.ObjectInfo) bool {
+	return o.Size == 0 && strings.HasSuffix(o.Key, "/")
+}
+
+// pageObjects
+/**
+ *  @Description: 从已按对象名升序排列的全部对象中截取一页并按分隔符分组，用于没有分页接口的实现
+ *  @param all
+ *  @param prefix
+ *  @param delimiter
+ *  @param startAfter
+ *  @param maxKeys
+ *  @return page 未分组前本页最后一个对象名作为nextMarker
+ */
+func pageObjects(all []ossmod.ObjectInfo, prefix, delimiter, startAfter string, maxKeys int) (page objectPage) {
+	i := sort.Search(len(all), func(i int) bool { return all[i].Key > startAfter })
+	objects := all[i:]
+	if len(objects) > maxKeys {
+		objects, page.truncated = objects[:maxKeys], true
+		page.nextMarker = objects[len(objects)-1].Key
+	}
+	for _, o := range objects {
+		if !strings.HasPrefix(o.Key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if j := strings.Index(o.Key[len(prefix):], delimiter); j >= 0 {
+				p := o.Key[:len(prefix)+j+len(delimiter)]
+				if len(page.prefixes) == 0 || page.prefixes[len(page.prefixes)-1] != p {
+					page.prefixes = append(page.prefixes, p)
+				}
+				continue
+			}
+		}
+		page.objects = append(page.objects, o)
+	}
+	return
+}
@@ -0,0 +1,927 @@
+/**
+ * @Time    :2026/10/18 11:40
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// localMetaDir 存放元数据与临时文件的目录，位于Root下；存储桶名不能以"."开头，因此不会与存储桶的目录冲突
+	localMetaDir = ".meta"
+	// localDirObject 以"/"结尾的对象保存为对应目录下的该文件，对象名中不能包含这一段
+	localDirObject = ".oss-dir"
+	// localStorageClass 本地存储统一使用的存储类型
+	localStorageClass = "STANDARD"
+)
+
+// LocalFsOss 本地文件系统实现，存储桶对应Root下的目录，对象对应其中的文件
+// 对象的ETag、ContentType等元数据以json格式保存在Root/.meta/<Bucket>下的同名文件中
+type LocalFsOss struct {
+	Root   string
+	Bucket string
+}
+
+// localMeta 对象的元数据
+type localMeta struct {
+	ETag               string            `json:"etag"`
+	ContentType        string            `json:"content_type"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	CacheControl       string            `json:"cache_control,omitempty"`
+	ContentEncoding    string            `json:"content_encoding,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	StorageClass       string            `json:"storage_class"`
+}
+
+func init() {
+	registerOffline("local", func(cfg Config) (ClientI, error) {
+		return NewLocalFsOss(cfg.Endpoint, cfg.Bucket), nil
+	}, "endpoint", "bucket")
+}
+
+func NewLocalFsOss(root, bucket string) (client *LocalFsOss) {
+	client = &LocalFsOss{
+		Root:   root,
+		Bucket: bucket,
+	}
+	return
+}
+
+// bucketDir 存储桶对应的目录
+func (client *LocalFsOss) bucketDir() string {
+	return filepath.Join(client.Root, client.Bucket)
+}
+
+// metaDir 存储桶元数据对应的目录
+func (client *LocalFsOss) metaDir() string {
+	return filepath.Join(client.Root, localMetaDir, client.Bucket)
+}
+
+// checkBucket 校验存储桶名，以"."开头的存储桶名会与元数据目录冲突，包含路径分隔符的存储桶名会越出Root
+func (client *LocalFsOss) checkBucket() error {
+	if client.Bucket == "" || strings.HasPrefix(client.Bucket, ".") || strings.ContainsAny(client.Bucket, `/\`) {
+		return fmt.Errorf("invalid bucket name %q", client.Bucket)
+	}
+	return nil
+}
+
+// objectPath
+/**
+ *  @Description: 获取对象的文件路径与元数据路径
+ *  对象名须为规范形式，包含空段、"."或".."段、以"/"开头的对象名会被拒绝，而不是规范化后映射到其他对象；
+ *  以"/"结尾的对象保存为对应目录下的localDirObject文件
+ *  @receiver client
+ *  @param objectName
+ *  @return filePath
+ *  @return metaPath 与文件路径同名的元数据文件
+ *  @return err
+ */
+func (client *LocalFsOss) objectPath(objectName string) (filePath, metaPath string, err error) {
+	if err = client.checkBucket(); err != nil {
+		return
+	}
+	for _, seg := range strings.Split(strings.TrimSuffix(objectName, "/"), "/") {
+		if seg == "" || seg == "." || seg == ".." || seg == localDirObject || strings.ContainsRune(seg, filepath.Separator) {
+			err = fmt.Errorf("invalid object name %q", objectName)
+			return
+		}
+	}
+	name := filepath.FromSlash(objectName)
+	if strings.HasSuffix(objectName, "/") {
+		name += localDirObject
+	}
+	filePath = filepath.Join(client.bucketDir(), name)
+	metaPath = filepath.Join(client.metaDir(), name)
+	return
+}
+
+// Target 返回服务商名称与存储桶，本地实现返回存储桶对应的目录
+func (client *LocalFsOss) Target() (provider, bucket string) {
+	return "local", client.bucketDir()
+}
+
+func (client *LocalFsOss) NewBucket() (err error) {
+	return client.NewBucketCtx(context.Background())
+}
+
+func (client *LocalFsOss) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = localError("NewBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if err = client.checkBucket(); err != nil {
+		return
+	}
+	err = os.MkdirAll(client.bucketDir(), 0755)
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(client.metaDir(), 0755)
+	return
+}
+
+func (client *LocalFsOss) RemoveBucket() (err error) {
+	return client.RemoveBucketCtx(context.Background())
+}
+
+// RemoveBucketCtx 删除存储桶，存储桶非空时返回错误
+func (client *LocalFsOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = localError("RemoveBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if err = client.checkBucket(); err != nil {
+		return
+	}
+	var entries []os.DirEntry
+	entries, err = os.ReadDir(client.bucketDir())
+	if err != nil {
+		return
+	}
+	if len(entries) > 0 {
+		err = kindError("local", "RemoveBucket", "", ErrBucketNotEmpty)
+		return
+	}
+	err = os.Remove(client.bucketDir())
+	if err != nil {
+		return
+	}
+	err = os.RemoveAll(client.metaDir())
+	return
+}
+
+func (client *LocalFsOss) BucketExist() (exist bool, err error) {
+	return client.BucketExistCtx(context.Background())
+}
+
+func (client *LocalFsOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = localError("BucketExist", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if err = client.checkBucket(); err != nil {
+		return
+	}
+	var fi os.FileInfo
+	fi, err = os.Stat(client.bucketDir())
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	exist = fi.IsDir()
+	return
+}
+
+func (client *LocalFsOss) PutObject(objectName string, filePath string) (err error) {
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+func (client *LocalFsOss) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	// 本地源文件的错误不做归类，避免与对象不存在混淆
+	defer func() { err = newError("local", "PutObject", objectName, 0, "", "", err) }()
+	err = putFile(ctx, client, objectName, filePath)
+	return
+}
+
+func (client *LocalFsOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx
+/**
+ *  @Description: 写入对象，先写入临时文件再重命名，避免读到写了一半的对象
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @param r
+ *  @param size 数据长度，未知时传-1
+ *  @param opts
+ *  @return err
+ */
+func (client *LocalFsOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = localError("PutObjectStream", objectName, err) }()
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	var filePath, metaPath string
+	filePath, metaPath, err = client.objectPath(objectName)
+	if err != nil {
+		return
+	}
+	var exist bool
+	exist, err = client.BucketExistCtx(ctx)
+	if err != nil {
+		return
+	}
+	if !exist {
+		err = kindError("local", "PutObjectStream", objectName, ErrBucketNotFound)
+		return
+	}
+	tmpDir := filepath.Join(client.Root, localMetaDir, ".tmp")
+	err = os.MkdirAll(tmpDir, 0755)
+	if err != nil {
+		return
+	}
+	var tmp *os.File
+	tmp, err = ioutil.TempFile(tmpDir, "put-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	var n int64
+	n, err = io.Copy(io.MultiWriter(tmp, hash), &ctxReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+	if size >= 0 && n != size {
+		err = fmt.Errorf("put %s: expected %d bytes, read %d", objectName, size, n)
+		return
+	}
+	meta := localMeta{
+		ETag:               hex.EncodeToString(hash.Sum(nil)),
+		ContentType:        opts.ContentType,
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		ContentEncoding:    opts.ContentEncoding,
+		Metadata:           userMeta(opts.Metadata),
+		StorageClass:       opts.StorageClass,
+	}
+	if meta.StorageClass == "" {
+		meta.StorageClass = localStorageClass
+	}
+	err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), filePath)
+	if err != nil {
+		return
+	}
+	err = writeLocalMeta(metaPath, meta)
+	return
+}
+
+func (client *LocalFsOss) GetObject(objectName string, filePath string) (err error) {
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+func (client *LocalFsOss) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	// 本地目标文件的错误不做归类，避免与对象不存在混淆
+	defer func() { err = newError("local", "GetObject", objectName, 0, "", "", err) }()
+	var body io.ReadCloser
+	body, _, err = client.GetObjectStreamCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	err = copyToFile(ctx, body, filePath)
+	return
+}
+
+func (client *LocalFsOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+func (client *LocalFsOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = localError("GetObjectStream", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var file *os.File
+	file, info, err = client.openObject(objectName)
+	if err != nil {
+		return
+	}
+	body = newCtxReadCloser(ctx, file)
+	return
+}
+
+// openObject 打开对象对应的文件，调用方负责关闭
+func (client *LocalFsOss) openObject(objectName string) (file *os.File, info ossmod.ObjectInfo, err error) {
+	var filePath, metaPath string
+	filePath, metaPath, err = client.objectPath(objectName)
+	if err != nil {
+		return
+	}
+	file, err = os.Open(filePath)
+	if err != nil {
+		return
+	}
+	var fi os.FileInfo
+	fi, err = file.Stat()
+	if err != nil {
+		file.Close()
+		file = nil
+		return
+	}
+	info = localObjectInfo(objectName, fi, metaPath)
+	return
+}
+
+func (client *LocalFsOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+// ListObjectsCtx 按对象名的字典序列出对象
+func (client *LocalFsOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = localError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象
+func (client *LocalFsOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *LocalFsOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *LocalFsOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = localError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 从startAfter之后按对象名顺序遍历，多取一个对象以判断是否还有下一页
+func (client *LocalFsOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = localError("ListObjects", "", err) }()
+	if marker != "" {
+		startAfter = marker
+	}
+	if err = client.checkBucket(); err != nil {
+		return
+	}
+	root := client.bucketDir()
+	if _, err = os.Stat(root); err != nil {
+		return
+	}
+	w := &localWalk{client: client, prefix: prefix, startAfter: startAfter, limit: maxKeys + 1}
+	if err = w.walk(ctx, root, ""); err != nil {
+		return
+	}
+	page = pageObjects(w.objects, prefix, delimiter, startAfter, maxKeys)
+	return
+}
+
+// localWalk 按对象名升序遍历存储桶目录，跳过对象名全部不大于startAfter或不以prefix开头的子目录，
+// 收集到limit个对象后停止，因此续接列举时不会重新遍历之前的对象
+type localWalk struct {
+	client     *LocalFsOss
+	prefix     string
+	startAfter string
+	limit      int
+	objects    []ossmod.ObjectInfo
+}
+
+// localEntry 目录项及其对应的对象名，子目录的对象名以"/"结尾
+type localEntry struct {
+	key   string
+	entry os.DirEntry
+}
+
+// walk 遍历dir，dirKey为dir对应的对象名前缀
+func (w *localWalk) walk(ctx context.Context, dir, dirKey string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var dirEntries []os.DirEntry
+	dirEntries, err = os.ReadDir(dir)
+	if err != nil {
+		// 子目录在遍历期间被删除
+		if dirKey != "" && errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	// 目录项按名称排序，与对象名的顺序不同（如"a.txt"应在"a/b"之前），需按对象名重新排序
+	entries := make([]localEntry, 0, len(dirEntries))
+	for _, e := range dirEntries {
+		key := dirKey + e.Name()
+		switch {
+		case e.Name() == localDirObject:
+			// 以"/"结尾的对象，存储桶根目录下不存在这样的对象
+			if dirKey == "" || e.IsDir() {
+				continue
+			}
+			key = dirKey
+		case e.IsDir():
+			key += "/"
+		}
+		entries = append(entries, localEntry{key: key, entry: e})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	for _, e := range entries {
+		if len(w.objects) >= w.limit {
+			return
+		}
+		if e.entry.IsDir() {
+			if !strings.HasPrefix(e.key, w.prefix) && !strings.HasPrefix(w.prefix, e.key) {
+				continue
+			}
+			if e.key <= w.startAfter && !strings.HasPrefix(w.startAfter, e.key) {
+				continue
+			}
+			if err = w.walk(ctx, filepath.Join(dir, e.entry.Name()), e.key); err != nil {
+				return
+			}
+			continue
+		}
+		if !strings.HasPrefix(e.key, w.prefix) || e.key <= w.startAfter {
+			continue
+		}
+		var fi os.FileInfo
+		fi, err = e.entry.Info()
+		// 文件在遍历期间被删除
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		var metaPath string
+		if _, metaPath, err = w.client.objectPath(e.key); err != nil {
+			return
+		}
+		w.objects = append(w.objects, localObjectInfo(e.key, fi, metaPath))
+	}
+	return
+}
+
+func (client *LocalFsOss) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+// RemoveObjectCtx 删除对象及其元数据，并清理因此变空的目录；对象不存在时不返回错误
+func (client *LocalFsOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = localError("RemoveObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var filePath, metaPath string
+	filePath, metaPath, err = client.objectPath(objectName)
+	if err != nil {
+		return
+	}
+	err = os.Remove(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	err = os.Remove(metaPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	err = nil
+	removeEmptyDirs(filepath.Dir(filePath), client.bucketDir())
+	removeEmptyDirs(filepath.Dir(metaPath), client.metaDir())
+	return
+}
+
+func (client *LocalFsOss) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx 逐个删除对象
+func (client *LocalFsOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeEach(ctx, client, keys)
+}
+
+func (client *LocalFsOss) RemovePrefix(prefix string) (err error) {
+	return client.RemovePrefixCtx(context.Background(), prefix)
+}
+
+func (client *LocalFsOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
+func (client *LocalFsOss) ObjectExist(objectName string) (exist bool, err error) {
+	return client.ObjectExistCtx(context.Background(), objectName)
+}
+
+func (client *LocalFsOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = localError("ObjectExist", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var filePath string
+	filePath, _, err = client.objectPath(objectName)
+	if err != nil {
+		return
+	}
+	var fi os.FileInfo
+	fi, err = os.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	exist = fi.Mode().IsRegular()
+	return
+}
+
+func (client *LocalFsOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+func (client *LocalFsOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = localError("GetObjectRange", objectName, err) }()
+	if _, err = rangeHeader(offset, length); err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var file *os.File
+	var info ossmod.ObjectInfo
+	file, info, err = client.openObject(objectName)
+	if err != nil {
+		return
+	}
+	if offset >= info.Size {
+		file.Close()
+		err = kindError("local", "GetObjectRange", objectName, ErrInvalidRange)
+		return
+	}
+	if length < 0 || offset+length > info.Size {
+		length = info.Size - offset
+	}
+	rc := struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, offset, length), file}
+	body = newCtxReadCloser(ctx, rc)
+	return
+}
+
+func (client *LocalFsOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+func (client *LocalFsOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = localError("StatObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var filePath, metaPath string
+	filePath, metaPath, err = client.objectPath(objectName)
+	if err != nil {
+		return
+	}
+	var fi os.FileInfo
+	fi, err = os.Stat(filePath)
+	if err != nil {
+		return
+	}
+	if !fi.Mode().IsRegular() {
+		err = kindError("local", "StatObject", objectName, ErrObjectNotFound)
+		return
+	}
+	info = localObjectInfo(objectName, fi, metaPath)
+	return
+}
+
+func (client *LocalFsOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx 复制对象，源存储桶为Root下的其他目录
+func (client *LocalFsOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = localError("CopyObject", src, err) }()
+	from := client
+	if bucket := copySource(opts, client.Bucket); bucket != client.Bucket {
+		from = NewLocalFsOss(client.Root, bucket)
+	}
+	var file *os.File
+	var info ossmod.ObjectInfo
+	file, info, err = from.openObject(src)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	putOpts := copyPutOptions(info)
+	putOpts.StorageClass = info.StorageClass
+	err = client.PutObjectStreamCtx(ctx, dst, file, info.Size, putOpts)
+	return
+}
+
+func (client *LocalFsOss) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+// MoveObjectCtx 移动对象，通过重命名文件与元数据实现
+func (client *LocalFsOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = localError("MoveObject", src, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var srcPath, srcMeta, dstPath, dstMeta string
+	if srcPath, srcMeta, err = client.objectPath(src); err != nil {
+		return
+	}
+	if dstPath, dstMeta, err = client.objectPath(dst); err != nil {
+		return
+	}
+	var fi os.FileInfo
+	fi, err = os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	if !fi.Mode().IsRegular() {
+		err = kindError("local", "MoveObject", src, ErrObjectNotFound)
+		return
+	}
+	if src == dst {
+		return
+	}
+	meta, _ := readLocalMeta(srcMeta)
+	err = os.MkdirAll(filepath.Dir(dstPath), 0755)
+	if err != nil {
+		return
+	}
+	err = os.Rename(srcPath, dstPath)
+	if err != nil {
+		return
+	}
+	err = writeLocalMeta(dstMeta, meta)
+	if err != nil {
+		return
+	}
+	err = os.Remove(srcMeta)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	err = nil
+	removeEmptyDirs(filepath.Dir(srcPath), client.bucketDir())
+	removeEmptyDirs(filepath.Dir(srcMeta), client.metaDir())
+	return
+}
+
+// PresignGet 本地存储不支持预签名URL
+func (client *LocalFsOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	err = kindError("local", "PresignGet", objectName, ErrNotSupported)
+	return
+}
+
+// PresignPut 本地存储不支持预签名URL
+func (client *LocalFsOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	err = kindError("local", "PresignPut", objectName, ErrNotSupported)
+	return
+}
+
+// localUpload 分片上传的信息，保存在分片目录下的upload.json中
+type localUpload struct {
+	ObjectName string            `json:"object_name"`
+	PutOptions ossmod.PutOptions `json:"put_options"`
+}
+
+// uploadDir 分片上传对应的目录，分片以编号命名
+func (client *LocalFsOss) uploadDir(uploadID string) string {
+	return filepath.Join(client.Root, localMetaDir, ".uploads", uploadID)
+}
+
+// loadUpload 读取分片上传的信息，上传不存在或与对象名不一致时返回ErrUploadNotFound
+func (client *LocalFsOss) loadUpload(op, objectName, uploadID string) (dir string, upload localUpload, err error) {
+	if uploadID == "" || strings.ContainsAny(uploadID, `/\.`) {
+		err = kindError("local", op, objectName, ErrUploadNotFound)
+		return
+	}
+	dir = client.uploadDir(uploadID)
+	var data []byte
+	data, err = os.ReadFile(filepath.Join(dir, "upload.json"))
+	if err == nil {
+		err = json.Unmarshal(data, &upload)
+	}
+	if err != nil || upload.ObjectName != objectName {
+		err = kindError("local", op, objectName, ErrUploadNotFound)
+	}
+	return
+}
+
+// MinPartSize 本地实现不限制分片大小
+func (client *LocalFsOss) MinPartSize() int64 {
+	return 0
+}
+
+// InitMultipartUploadCtx 初始化分片上传，分片暂存于Root/.meta/.uploads/<uploadID>下
+func (client *LocalFsOss) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = localError("InitMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if _, _, err = client.objectPath(objectName); err != nil {
+		return
+	}
+	var exist bool
+	exist, err = client.BucketExistCtx(ctx)
+	if err != nil {
+		return
+	}
+	if !exist {
+		err = kindError("local", "InitMultipartUpload", objectName, ErrBucketNotFound)
+		return
+	}
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return
+	}
+	uploadID = hex.EncodeToString(id)
+	dir := client.uploadDir(uploadID)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return
+	}
+	var data []byte
+	data, err = json.Marshal(localUpload{ObjectName: objectName, PutOptions: opts})
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(filepath.Join(dir, "upload.json"), data, 0644)
+	return
+}
+
+// UploadPartCtx 上传分片，ETag为分片内容的MD5
+func (client *LocalFsOss) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = localError("UploadPart", objectName, err) }()
+	var dir string
+	dir, _, err = client.loadUpload("UploadPart", objectName, uploadID)
+	if err != nil {
+		return
+	}
+	var tmp *os.File
+	tmp, err = ioutil.TempFile(dir, "part-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	var n int64
+	n, err = io.Copy(io.MultiWriter(tmp, hash), &ctxReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+	if n != size {
+		err = fmt.Errorf("upload part %d of %s: expected %d bytes, read %d", partNumber, objectName, size, n)
+		return
+	}
+	err = os.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(partNumber)))
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: partNumber, ETag: hex.EncodeToString(hash.Sum(nil)), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx 按parts的顺序合并分片，完成后删除分片目录
+func (client *LocalFsOss) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = localError("CompleteMultipartUpload", objectName, err) }()
+	var dir string
+	var upload localUpload
+	dir, upload, err = client.loadUpload("CompleteMultipartUpload", objectName, uploadID)
+	if err != nil {
+		return
+	}
+	readers := make([]io.Reader, 0, len(parts))
+	var size int64
+	for _, part := range parts {
+		var file *os.File
+		file, err = os.Open(filepath.Join(dir, strconv.Itoa(part.PartNumber)))
+		if err != nil {
+			err = fmt.Errorf("complete %s: invalid part %d: %w", objectName, part.PartNumber, err)
+			return
+		}
+		defer file.Close()
+		var fi os.FileInfo
+		fi, err = file.Stat()
+		if err != nil {
+			return
+		}
+		size += fi.Size()
+		readers = append(readers, file)
+	}
+	err = client.PutObjectStreamCtx(ctx, objectName, io.MultiReader(readers...), size, upload.PutOptions)
+	if err != nil {
+		return
+	}
+	err = os.RemoveAll(dir)
+	return
+}
+
+// AbortMultipartUploadCtx 终止分片上传并删除分片目录
+func (client *LocalFsOss) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = localError("AbortMultipartUpload", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var dir string
+	dir, _, err = client.loadUpload("AbortMultipartUpload", objectName, uploadID)
+	if err != nil {
+		return
+	}
+	err = os.RemoveAll(dir)
+	return
+}
+
+// localObjectInfo 根据文件信息与元数据文件组装对象信息
+func localObjectInfo(key string, fi os.FileInfo, metaPath string) (info ossmod.ObjectInfo) {
+	meta, _ := readLocalMeta(metaPath)
+	info = ossmod.ObjectInfo{
+		Key:                key,
+		Size:               fi.Size(),
+		ETag:               meta.ETag,
+		LastModified:       fi.ModTime(),
+		StorageClass:       meta.StorageClass,
+		ContentType:        meta.ContentType,
+		ContentLength:      fi.Size(),
+		ContentDisposition: meta.ContentDisposition,
+		CacheControl:       meta.CacheControl,
+		ContentEncoding:    meta.ContentEncoding,
+		Metadata:           meta.Metadata,
+	}
+	return
+}
+
+func readLocalMeta(metaPath string) (meta localMeta, err error) {
+	var data []byte
+	data, err = os.ReadFile(metaPath)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &meta)
+	return
+}
+
+func writeLocalMeta(metaPath string, meta localMeta) (err error) {
+	err = os.MkdirAll(filepath.Dir(metaPath), 0755)
+	if err != nil {
+		return
+	}
+	var data []byte
+	data, err = json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(metaPath, data, 0644)
+	return
+}
+
+// removeEmptyDirs 自dir向上删除空目录，直到stop为止（不删除stop）
+func removeEmptyDirs(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// localError 将文件系统错误转换为*Error
+func localError(op, key string, err error) error {
+	status := 0
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, os.ErrPermission):
+		status = http.StatusForbidden
+	}
+	return newError("local", op, key, status, "", "", err)
+}
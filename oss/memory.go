@@ -0,0 +1,641 @@
+/**
+ * @Time    :2026/10/18 13:15
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryEpoch 内存存储逻辑时钟的起点，每次写入对象时钟前进一秒，保证LastModified可预期
+var memoryEpoch = time.Date(2023, 5, 23, 0, 0, 0, 0, time.UTC)
+
+// MemoryOss 内存实现，并发安全，主要用于单元测试
+// 可通过SetHook在指定方法上注入错误，用于测试失败与回滚逻辑
+type MemoryOss struct {
+	Bucket  string
+	mu      sync.RWMutex
+	exist   bool
+	objects map[string]memoryObject
+	clock   int64
+	hooks   map[string]func(objectName string) error
+	uploads map[string]*memoryUpload
+	// uploadSeq 分片上传ID的序号
+	uploadSeq int
+}
+
+type memoryObject struct {
+	data []byte
+	info ossmod.ObjectInfo
+}
+
+// objectInfo 返回对象信息的副本，Metadata同样拷贝，调用方修改返回值不会影响存储的对象
+func (object memoryObject) objectInfo() ossmod.ObjectInfo {
+	info := object.info
+	if info.Metadata != nil {
+		info.Metadata = make(map[string]string, len(object.info.Metadata))
+		for k, v := range object.info.Metadata {
+			info.Metadata[k] = v
+		}
+	}
+	return info
+}
+
+// memoryUpload 进行中的分片上传
+type memoryUpload struct {
+	objectName string
+	opts       ossmod.PutOptions
+	parts      map[int][]byte
+}
+
+func init() {
+	registerOffline("memory", func(cfg Config) (ClientI, error) {
+		return NewMemoryOss(cfg.Bucket), nil
+	})
+}
+
+func NewMemoryOss(bucket string) (client *MemoryOss) {
+	client = &MemoryOss{
+		Bucket:  bucket,
+		objects: make(map[string]memoryObject),
+		hooks:   make(map[string]func(objectName string) error),
+		uploads: make(map[string]*memoryUpload),
+	}
+	return
+}
+
+// SetHook
+/**
+ *  @Description: 设置方法调用前的钩子，钩子返回的错误将作为该方法的返回值；hook为nil时移除钩子
+ *  @receiver client
+ *  @param op 方法名，如 "PutObject"、"RemoveObject"，对应的Ctx方法共用同一钩子
+ *  @param hook 参数为本次调用的对象名，存储桶操作时为空
+ */
+func (client *MemoryOss) SetHook(op string, hook func(objectName string) error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if hook == nil {
+		delete(client.hooks, op)
+		return
+	}
+	client.hooks[op] = hook
+}
+
+// ClearHooks 移除全部钩子
+func (client *MemoryOss) ClearHooks() {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.hooks = make(map[string]func(objectName string) error)
+}
+
+// before 检查ctx并执行钩子
+func (client *MemoryOss) before(ctx context.Context, op, objectName string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	client.mu.RLock()
+	hook := client.hooks[op]
+	client.mu.RUnlock()
+	if hook != nil {
+		err = hook(objectName)
+	}
+	return
+}
+
+// checkBucket 检查存储桶是否存在，调用方需持有锁
+func (client *MemoryOss) checkBucket(op string) error {
+	if client.exist {
+		return nil
+	}
+	return kindError("memory", op, "", ErrBucketNotFound)
+}
+
+// Target 返回服务商名称与存储桶
+func (client *MemoryOss) Target() (provider, bucket string) {
+	return "memory", client.Bucket
+}
+
+func (client *MemoryOss) NewBucket() (err error) {
+	return client.NewBucketCtx(context.Background())
+}
+
+func (client *MemoryOss) NewBucketCtx(ctx context.Context) (err error) {
+	if err = client.before(ctx, "NewBucket", ""); err != nil {
+		return
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.exist = true
+	return
+}
+
+func (client *MemoryOss) RemoveBucket() (err error) {
+	return client.RemoveBucketCtx(context.Background())
+}
+
+// RemoveBucketCtx 删除存储桶，存储桶非空时返回错误
+func (client *MemoryOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	if err = client.before(ctx, "RemoveBucket", ""); err != nil {
+		return
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if err = client.checkBucket("RemoveBucket"); err != nil {
+		return
+	}
+	if len(client.objects) > 0 {
+		err = kindError("memory", "RemoveBucket", "", ErrBucketNotEmpty)
+		return
+	}
+	client.exist = false
+	return
+}
+
+func (client *MemoryOss) BucketExist() (exist bool, err error) {
+	return client.BucketExistCtx(context.Background())
+}
+
+func (client *MemoryOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	if err = client.before(ctx, "BucketExist", ""); err != nil {
+		return
+	}
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	exist = client.exist
+	return
+}
+
+func (client *MemoryOss) PutObject(objectName string, filePath string) (err error) {
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+func (client *MemoryOss) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	if err = client.before(ctx, "PutObject", objectName); err != nil {
+		return
+	}
+	var file *os.File
+	file, err = os.Open(filePath)
+	if err != nil {
+		// 本地源文件的错误不做归类，避免与对象不存在混淆
+		err = newError("memory", "PutObject", objectName, 0, "", "", err)
+		return
+	}
+	defer file.Close()
+	err = client.put(ctx, "PutObject", objectName, file, -1, ossmod.PutOptions{})
+	return
+}
+
+func (client *MemoryOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+func (client *MemoryOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	if err = client.before(ctx, "PutObjectStream", objectName); err != nil {
+		return
+	}
+	err = client.put(ctx, "PutObjectStream", objectName, r, size, opts)
+	return
+}
+
+// put 读取全部数据后写入，ETag为内容的MD5
+func (client *MemoryOss) put(ctx context.Context, op, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	var data []byte
+	data, err = ioutil.ReadAll(&ctxReader{ctx: ctx, r: r})
+	if err != nil {
+		err = newError("memory", op, objectName, 0, "", "", err)
+		return
+	}
+	if size >= 0 && int64(len(data)) != size {
+		err = newError("memory", op, objectName, 0, "", "", fmt.Errorf("expected %d bytes, read %d", size, len(data)))
+		return
+	}
+	sum := md5.Sum(data)
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if err = client.checkBucket(op); err != nil {
+		return
+	}
+	client.clock++
+	info := ossmod.ObjectInfo{
+		Key:                objectName,
+		Size:               int64(len(data)),
+		ETag:               hex.EncodeToString(sum[:]),
+		LastModified:       memoryEpoch.Add(time.Duration(client.clock) * time.Second),
+		StorageClass:       opts.StorageClass,
+		ContentType:        opts.ContentType,
+		ContentLength:      int64(len(data)),
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		ContentEncoding:    opts.ContentEncoding,
+		Metadata:           userMeta(opts.Metadata),
+	}
+	if info.StorageClass == "" {
+		info.StorageClass = localStorageClass
+	}
+	client.objects[objectName] = memoryObject{data: data, info: info}
+	return
+}
+
+// get 获取对象，内部加锁
+func (client *MemoryOss) get(op, objectName string) (object memoryObject, err error) {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if err = client.checkBucket(op); err != nil {
+		return
+	}
+	var ok bool
+	object, ok = client.objects[objectName]
+	if !ok {
+		err = kindError("memory", op, objectName, ErrObjectNotFound)
+	}
+	return
+}
+
+func (client *MemoryOss) GetObject(objectName string, filePath string) (err error) {
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+func (client *MemoryOss) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	if err = client.before(ctx, "GetObject", objectName); err != nil {
+		return
+	}
+	var object memoryObject
+	object, err = client.get("GetObject", objectName)
+	if err != nil {
+		return
+	}
+	if err = os.WriteFile(filePath, object.data, 0644); err != nil {
+		err = newError("memory", "GetObject", objectName, 0, "", "", err)
+	}
+	return
+}
+
+func (client *MemoryOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+func (client *MemoryOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	if err = client.before(ctx, "GetObjectStream", objectName); err != nil {
+		return
+	}
+	var object memoryObject
+	object, err = client.get("GetObjectStream", objectName)
+	if err != nil {
+		return
+	}
+	// 写入时总是替换整个切片，此处无需拷贝
+	body = ioutil.NopCloser(&ctxReader{ctx: ctx, r: bytes.NewReader(object.data)})
+	info = object.objectInfo()
+	return
+}
+
+func (client *MemoryOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+// ListObjectsCtx 按对象名的字典序列出对象
+func (client *MemoryOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象
+func (client *MemoryOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *MemoryOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *MemoryOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 在加锁期间筛选一页对象，遍历过程中可以修改对象
+func (client *MemoryOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	if err = client.before(ctx, "ListObjects", prefix); err != nil {
+		return
+	}
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if err = client.checkBucket("ListObjects"); err != nil {
+		return
+	}
+	if marker != "" {
+		startAfter = marker
+	}
+	var all []ossmod.ObjectInfo
+	for key, object := range client.objects {
+		if strings.HasPrefix(key, prefix) && key > startAfter {
+			all = append(all, object.objectInfo())
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Key < all[j].Key
+	})
+	page = pageObjects(all, prefix, delimiter, startAfter, maxKeys)
+	return
+}
+
+func (client *MemoryOss) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+// RemoveObjectCtx 删除对象，对象不存在时不返回错误
+func (client *MemoryOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	if err = client.before(ctx, "RemoveObject", objectName); err != nil {
+		return
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if err = client.checkBucket("RemoveObject"); err != nil {
+		return
+	}
+	delete(client.objects, objectName)
+	return
+}
+
+func (client *MemoryOss) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx 逐个删除对象
+func (client *MemoryOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeEach(ctx, client, keys)
+}
+
+func (client *MemoryOss) RemovePrefix(prefix string) (err error) {
+	return client.RemovePrefixCtx(context.Background(), prefix)
+}
+
+func (client *MemoryOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
+func (client *MemoryOss) ObjectExist(objectName string) (exist bool, err error) {
+	return client.ObjectExistCtx(context.Background(), objectName)
+}
+
+func (client *MemoryOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	if err = client.before(ctx, "ObjectExist", objectName); err != nil {
+		return
+	}
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if err = client.checkBucket("ObjectExist"); err != nil {
+		return
+	}
+	_, exist = client.objects[objectName]
+	return
+}
+
+func (client *MemoryOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+func (client *MemoryOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	if err = client.before(ctx, "GetObjectRange", objectName); err != nil {
+		return
+	}
+	if _, err = rangeHeader(offset, length); err != nil {
+		err = newError("memory", "GetObjectRange", objectName, 0, "", "", err)
+		return
+	}
+	var object memoryObject
+	object, err = client.get("GetObjectRange", objectName)
+	if err != nil {
+		return
+	}
+	size := int64(len(object.data))
+	if offset >= size {
+		err = kindError("memory", "GetObjectRange", objectName, ErrInvalidRange)
+		return
+	}
+	end := size
+	if length > 0 && offset+length < size {
+		end = offset + length
+	}
+	body = ioutil.NopCloser(&ctxReader{ctx: ctx, r: bytes.NewReader(object.data[offset:end])})
+	return
+}
+
+func (client *MemoryOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+func (client *MemoryOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	if err = client.before(ctx, "StatObject", objectName); err != nil {
+		return
+	}
+	var object memoryObject
+	object, err = client.get("StatObject", objectName)
+	if err != nil {
+		return
+	}
+	info = object.objectInfo()
+	return
+}
+
+func (client *MemoryOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx 复制对象，内存存储之间相互独立，不支持从其他存储桶复制
+func (client *MemoryOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	if err = client.before(ctx, "CopyObject", src); err != nil {
+		return
+	}
+	if copySource(opts, client.Bucket) != client.Bucket {
+		err = kindError("memory", "CopyObject", src, ErrNotSupported)
+		return
+	}
+	err = client.copy("CopyObject", src, dst, false)
+	return
+}
+
+func (client *MemoryOss) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+func (client *MemoryOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	if err = client.before(ctx, "MoveObject", src); err != nil {
+		return
+	}
+	err = client.copy("MoveObject", src, dst, true)
+	return
+}
+
+// copy 复制对象，move为true时删除源对象；与写入一样推进逻辑时钟
+func (client *MemoryOss) copy(op, src, dst string, move bool) (err error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if err = client.checkBucket(op); err != nil {
+		return
+	}
+	object, ok := client.objects[src]
+	if !ok {
+		err = kindError("memory", op, src, ErrObjectNotFound)
+		return
+	}
+	if src == dst && move {
+		return
+	}
+	client.clock++
+	object.info = object.objectInfo()
+	object.info.Key = dst
+	object.info.LastModified = memoryEpoch.Add(time.Duration(client.clock) * time.Second)
+	client.objects[dst] = object
+	if move {
+		delete(client.objects, src)
+	}
+	return
+}
+
+// PresignGet 内存存储不支持预签名URL
+func (client *MemoryOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	if err = client.before(context.Background(), "PresignGet", objectName); err != nil {
+		return
+	}
+	err = kindError("memory", "PresignGet", objectName, ErrNotSupported)
+	return
+}
+
+// PresignPut 内存存储不支持预签名URL
+func (client *MemoryOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	if err = client.before(context.Background(), "PresignPut", objectName); err != nil {
+		return
+	}
+	err = kindError("memory", "PresignPut", objectName, ErrNotSupported)
+	return
+}
+
+// MinPartSize 内存实现不限制分片大小
+func (client *MemoryOss) MinPartSize() int64 {
+	return 0
+}
+
+// InitMultipartUploadCtx 初始化分片上传
+func (client *MemoryOss) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	if err = client.before(ctx, "InitMultipartUpload", objectName); err != nil {
+		return
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if err = client.checkBucket("InitMultipartUpload"); err != nil {
+		return
+	}
+	client.uploadSeq++
+	uploadID = fmt.Sprintf("memory-upload-%d", client.uploadSeq)
+	client.uploads[uploadID] = &memoryUpload{
+		objectName: objectName,
+		opts:       opts,
+		parts:      make(map[int][]byte),
+	}
+	return
+}
+
+// upload 获取进行中的分片上传，调用方需持有锁
+func (client *MemoryOss) upload(op, objectName, uploadID string) (upload *memoryUpload, err error) {
+	if err = client.checkBucket(op); err != nil {
+		return
+	}
+	upload = client.uploads[uploadID]
+	if upload == nil || upload.objectName != objectName {
+		upload = nil
+		err = kindError("memory", op, objectName, ErrUploadNotFound)
+	}
+	return
+}
+
+// UploadPartCtx 上传分片，ETag为分片内容的MD5
+func (client *MemoryOss) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	if err = client.before(ctx, "UploadPart", objectName); err != nil {
+		return
+	}
+	var data []byte
+	data, err = ioutil.ReadAll(&ctxReader{ctx: ctx, r: r})
+	if err != nil {
+		err = newError("memory", "UploadPart", objectName, 0, "", "", err)
+		return
+	}
+	if int64(len(data)) != size {
+		err = newError("memory", "UploadPart", objectName, 0, "", "", fmt.Errorf("part %d: expected %d bytes, read %d", partNumber, size, len(data)))
+		return
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	var upload *memoryUpload
+	upload, err = client.upload("UploadPart", objectName, uploadID)
+	if err != nil {
+		return
+	}
+	upload.parts[partNumber] = data
+	sum := md5.Sum(data)
+	part = ossmod.Part{PartNumber: partNumber, ETag: hex.EncodeToString(sum[:]), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx 按parts的顺序合并分片，ETag不一致时返回错误
+func (client *MemoryOss) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	if err = client.before(ctx, "CompleteMultipartUpload", objectName); err != nil {
+		return
+	}
+	client.mu.Lock()
+	var upload *memoryUpload
+	upload, err = client.upload("CompleteMultipartUpload", objectName, uploadID)
+	if err != nil {
+		client.mu.Unlock()
+		return
+	}
+	var buf bytes.Buffer
+	for _, part := range parts {
+		data, ok := upload.parts[part.PartNumber]
+		sum := md5.Sum(data)
+		if !ok || hex.EncodeToString(sum[:]) != part.ETag {
+			client.mu.Unlock()
+			err = fmt.Errorf("complete %s: invalid part %d", objectName, part.PartNumber)
+			return
+		}
+		buf.Write(data)
+	}
+	delete(client.uploads, uploadID)
+	client.mu.Unlock()
+	err = client.put(ctx, "CompleteMultipartUpload", objectName, &buf, int64(buf.Len()), upload.opts)
+	return
+}
+
+// AbortMultipartUploadCtx 终止分片上传
+func (client *MemoryOss) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	if err = client.before(ctx, "AbortMultipartUpload", objectName); err != nil {
+		return
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if _, err = client.upload("AbortMultipartUpload", objectName, uploadID); err != nil {
+		return
+	}
+	delete(client.uploads, uploadID)
+	return
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/melf-xyzh/go-oss-client/model"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
-	"log"
+	"io"
+	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -23,6 +25,12 @@ type MinioOss struct {
 	Client          *minio.Client
 }
 
+func init() {
+	register("minio", func(cfg Config) (ClientI, error) {
+		return NewMinioOss(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.Bucket, cfg.Timeout, cfg.UseSSL)
+	}, "endpoint", "access_key", "secret_key", "bucket")
+}
+
 func NewMinioOss(endpoint, accessKeyId, accessKeySecret, bucket string, timeOut int, useSSL bool) (minioOss *MinioOss, err error) {
 	minioOss = &MinioOss{
 		Endpoint:        endpoint,
@@ -49,12 +57,23 @@ func NewMinioOss(endpoint, accessKeyId, accessKeySecret, bucket string, timeOut
  */
 
 func (client *MinioOss) BucketExist() (exist bool, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.BucketExistCtx(ctx)
+}
+
+// BucketExistCtx 判断存储桶是否存在，超时与取消由ctx控制
+func (client *MinioOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = minioError("BucketExist", "", err) }()
 	exist, err = client.Client.BucketExists(ctx, client.Bucket)
 	return
 }
 
+// Target 返回服务商名称与存储桶
+func (client *MinioOss) Target() (provider, bucket string) {
+	return "minio", client.Bucket
+}
+
 // NewBucket
 /**
  *  @Description: 创建存储桶
@@ -63,8 +82,14 @@ func (client *MinioOss) BucketExist() (exist bool, err error) {
  *  @return err
  */
 func (client *MinioOss) NewBucket() (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.NewBucketCtx(ctx)
+}
+
+// NewBucketCtx 创建存储桶，超时与取消由ctx控制
+func (client *MinioOss) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = minioError("NewBucket", "", err) }()
 	location := "us-east-1"
 	err = client.Client.MakeBucket(ctx, client.Bucket, minio.MakeBucketOptions{Region: location})
 	return
@@ -78,8 +103,14 @@ func (client *MinioOss) NewBucket() (err error) {
  *  @return err
  */
 func (client *MinioOss) RemoveBucket() (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.RemoveBucketCtx(ctx)
+}
+
+// RemoveBucketCtx 删除存储桶，超时与取消由ctx控制
+func (client *MinioOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = minioError("RemoveBucket", "", err) }()
 	err = client.Client.RemoveBucket(ctx, client.Bucket)
 	return
 }
@@ -95,39 +126,135 @@ func (client *MinioOss) RemoveBucket() (err error) {
  *  @return err
  */
 func (client *MinioOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
-	opts := minio.ListObjectsOptions{}
-	// 设置指定前缀
-	if prefix != "" {
-		opts.Prefix = prefix
-		opts.Recursive = true
+	return client.ListObjectsCtx(ctx, prefix, startAfter)
+}
+
+// ListObjectsCtx 获取对象列表，超时与取消由ctx控制
+func (client *MinioOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = minioError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter
+/**
+ *  @Description: 分页遍历对象
+ *  @receiver client
+ *  @param ctx 每页请求前检查
+ *  @param opts
+ *  @return ObjectIterator
+ */
+func (client *MinioOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+// ListDir
+/**
+ *  @Description: 按分隔符列举prefix下一级的对象与公共前缀
+ *  @receiver client
+ *  @param prefix 目录前缀，如 "a/b/"
+ *  @param delimiter 为空时使用"/"
+ *  @return objects
+ *  @return prefixes 子目录
+ *  @return err
+ */
+func (client *MinioOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ListDirCtx(ctx, prefix, delimiter)
+}
+
+// ListDirCtx
+/**
+ *  @Description: 按分隔符列举prefix下一级的对象与公共前缀
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix 目录前缀，如 "a/b/"
+ *  @param delimiter 为空时使用"/"
+ *  @return objects
+ *  @return prefixes 子目录
+ *  @return err
+ */
+func (client *MinioOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = minioError("ListDir", "", err) }()
+	// SDK只支持以"/"分组，其他分隔符由listPage在客户端分组
+	if delimiter != "" && delimiter != "/" {
+		objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+		return
 	}
-	// 设置指定起始位置
-	if startAfter != "" {
-		opts.StartAfter = startAfter
-		opts.UseV1 = true
+	for object := range client.Client.ListObjects(ctx, client.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if err = object.Err; err != nil {
+			return
+		}
+		// 公共前缀只有Key，目录占位对象带有ETag与修改时间
+		if object.ETag == "" && object.LastModified.IsZero() {
+			prefixes = append(prefixes, object.Key)
+			continue
+		}
+		o := minioListInfo(object)
+		o.IsDir = isDirPlaceholder(o)
+		objects = append(objects, o)
 	}
+	err = ctx.Err()
+	return
+}
 
-	objectCh := client.Client.ListObjects(ctx, client.Bucket, opts)
-	// 遍历Object
-	for object := range objectCh {
-		if object.Err != nil {
-			err = object.Err
+// listPage
+/**
+ *  @Description: 请求一页对象，SDK的ListObjects在后台逐页请求并预取下一页，读取一页后取消ctx停止请求
+ *  SDK只支持以"/"分组，这里递归列举后在客户端按delimiter分组
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix
+ *  @param delimiter
+ *  @param startAfter
+ *  @param marker
+ *  @param maxKeys
+ *  @return page
+ *  @return err
+ */
+func (client *MinioOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = minioError("ListObjects", "", err) }()
+	if marker == "" {
+		marker = startAfter
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts := minio.ListObjectsOptions{Prefix: prefix, StartAfter: marker, MaxKeys: maxKeys, Recursive: true}
+	var objects []ossmod.ObjectInfo
+	for object := range client.Client.ListObjects(ctx, client.Bucket, opts) {
+		if err = object.Err; err != nil {
 			return
 		}
-		o := ossmod.ObjectInfo{
-			Key:          object.Key,
-			Size:         object.Size,
-			ETag:         object.ETag,
-			LastModified: object.LastModified,
-			StorageClass: object.StorageClass,
+		objects = append(objects, minioListInfo(object))
+		if len(objects) == maxKeys {
+			break
 		}
-		objects = append(objects, o)
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	page = pageObjects(objects, prefix, delimiter, marker, maxKeys)
+	// 读满一页时视为还有下一页，从本页最后一个对象之后继续
+	if len(objects) == maxKeys {
+		page.truncated, page.nextMarker = true, objects[len(objects)-1].Key
 	}
 	return
 }
 
+// minioListInfo 将列举结果转换为ObjectInfo
+func minioListInfo(object minio.ObjectInfo) ossmod.ObjectInfo {
+	return ossmod.ObjectInfo{
+		Key:          object.Key,
+		Size:         object.Size,
+		ETag:         trimETag(object.ETag),
+		LastModified: object.LastModified,
+		StorageClass: object.StorageClass,
+	}
+}
+
 // PutObject
 /**
  *  @Description: 上传文件
@@ -138,10 +265,15 @@ func (client *MinioOss) ListObjects(prefix, startAfter string) (objects []ossmod
  *  @return err
  */
 func (client *MinioOss) PutObject(objectName, filePath string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
-	opts := minio.PutObjectOptions{ContentType: "application/octet-stream"}
-	_, err = client.Client.FPutObject(ctx, client.Bucket, objectName, filePath, opts)
+	return client.PutObjectCtx(ctx, objectName, filePath)
+}
+
+// PutObjectCtx 上传文件，超时与取消由ctx控制
+func (client *MinioOss) PutObjectCtx(ctx context.Context, objectName, filePath string) (err error) {
+	defer func() { err = minioError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
@@ -155,8 +287,14 @@ func (client *MinioOss) PutObject(objectName, filePath string) (err error) {
  *  @return err
  */
 func (client *MinioOss) GetObject(objectName, filePath string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.GetObjectCtx(ctx, objectName, filePath)
+}
+
+// GetObjectCtx 下载文件，超时与取消由ctx控制
+func (client *MinioOss) GetObjectCtx(ctx context.Context, objectName, filePath string) (err error) {
+	defer func() { err = minioError("GetObject", objectName, err) }()
 	err = client.Client.FGetObject(ctx, client.Bucket, objectName, filePath, minio.GetObjectOptions{})
 	return
 }
@@ -170,16 +308,85 @@ func (client *MinioOss) GetObject(objectName, filePath string) (err error) {
  *  @return err
  */
 func (client *MinioOss) RemoveObject(objectName string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.RemoveObjectCtx(ctx, objectName)
+}
+
+// RemoveObjectCtx 删除单个文件，超时与取消由ctx控制
+func (client *MinioOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = minioError("RemoveObject", objectName, err) }()
 	opts := minio.RemoveObjectOptions{
 		GovernanceBypass: true, // 使用该参数会忽略所有 Bucket 的生命周期配置、对象锁定配置以及任何其他的数据保留规则，直接删除对象。
 	}
 	// 删除单个文件
 	err = client.Client.RemoveObject(ctx, client.Bucket, objectName, opts)
-	if err != nil {
-		log.Fatalln(err)
-	}
+	return
+}
+
+// RemoveObjects
+/**
+ *  @Description: 批量删除对象
+ *  @receiver client
+ *  @param keys
+ *  @return failed 删除失败的对象及原因，全部成功时为nil
+ */
+func (client *MinioOss) RemoveObjects(keys []string) (failed map[string]error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemoveObjectsCtx(ctx, keys)
+}
+
+// RemoveObjectsCtx
+/**
+ *  @Description: 批量删除对象，使用S3的Multi-Object Delete接口
+ *  @receiver client
+ *  @param ctx
+ *  @param keys
+ *  @return failed 删除失败的对象及原因，全部成功时为nil
+ */
+func (client *MinioOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		objectsCh := make(chan minio.ObjectInfo, len(batch))
+		for _, key := range batch {
+			objectsCh <- minio.ObjectInfo{Key: key}
+		}
+		close(objectsCh)
+		opts := minio.RemoveObjectsOptions{GovernanceBypass: true}
+		for e := range client.Client.RemoveObjects(ctx, client.Bucket, objectsCh, opts) {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[e.ObjectName] = minioError("RemoveObjects", e.ObjectName, e.Err)
+		}
+		return
+	})
+}
+
+// RemovePrefix
+/**
+ *  @Description: 删除以prefix开头的全部对象
+ *  @receiver client
+ *  @param prefix 不能为空
+ *  @return err
+ */
+func (client *MinioOss) RemovePrefix(prefix string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemovePrefixCtx(ctx, prefix)
+}
+
+// RemovePrefixCtx
+/**
+ *  @Description: 删除以prefix开头的全部对象
+ *  @receiver client
+ *  @param ctx
+ *  @param prefix 不能为空
+ *  @return err
+ */
+func (client *MinioOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = minioError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
 	return
 }
 
@@ -193,13 +400,393 @@ func (client *MinioOss) RemoveObject(objectName string) (err error) {
  *  @return err
  */
 func (client *MinioOss) ObjectExist(objectName string) (exist bool, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ObjectExistCtx(ctx, objectName)
+}
+
+// ObjectExistCtx 判断文件是否存在，超时与取消由ctx控制
+func (client *MinioOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = minioError("ObjectExist", objectName, err) }()
+	_, err = client.StatObjectCtx(ctx, objectName)
+	exist, err = existResult(err, ErrObjectNotFound)
+	return
+}
+
+// PutObjectStream
+/**
+ *  @Description: 从io.Reader上传对象
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @param r 数据来源
+ *  @param size 数据长度，未知时传-1
+ *  @param opts 可选参数
+ *  @return err
+ */
+func (client *MinioOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
-	_, err = client.Client.StatObject(ctx, client.Bucket, objectName, minio.GetObjectOptions{})
+	return client.PutObjectStreamCtx(ctx, objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 从io.Reader上传对象，超时与取消由ctx控制
+func (client *MinioOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = minioError("PutObjectStream", objectName, err) }()
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	_, err = client.Client.PutObject(ctx, client.Bucket, objectName, r, size, minioPutOptions(opts))
+	return
+}
+
+// GetObjectStream
+/**
+ *  @Description: 以流的方式下载对象，超时时间覆盖整个读取过程
+ *  @receiver client
+ *  @param objectName Object的完整路径
+ *  @return body 对象内容，调用方负责关闭
+ *  @return info 对象信息
+ *  @return err
+ */
+func (client *MinioOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	body, info, err = client.GetObjectStreamCtx(ctx, objectName)
 	if err != nil {
-		exist = false
+		cancel()
 		return
 	}
-	exist = true
+	body = &cancelReadCloser{ReadCloser: body, cancel: cancel}
 	return
 }
+
+// GetObjectStreamCtx 以流的方式下载对象，超时与取消由ctx控制
+func (client *MinioOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = minioError("GetObjectStream", objectName, err) }()
+	var object *minio.Object
+	object, err = client.Client.GetObject(ctx, client.Bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return
+	}
+	var stat minio.ObjectInfo
+	stat, err = object.Stat()
+	if err != nil {
+		object.Close()
+		return
+	}
+	info = minioObjectInfo(objectName, stat)
+	body = object
+	return
+}
+
+// GetObjectRange
+/**
+ *  @Description: 读取对象的指定范围，调用方负责关闭返回的io.ReadCloser
+ *  @receiver client
+ *  @param objectName
+ *  @param offset 起始位置
+ *  @param length 读取的长度，为-1时读取到对象末尾
+ *  @return body
+ *  @return err
+ */
+func (client *MinioOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	body, err = client.GetObjectRangeCtx(ctx, objectName, offset, length)
+	if err != nil {
+		cancel()
+		return
+	}
+	body = &cancelReadCloser{ReadCloser: body, cancel: cancel}
+	return
+}
+
+// GetObjectRangeCtx
+/**
+ *  @Description: 读取对象的指定范围，ctx结束后读取将返回错误
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @param offset 起始位置
+ *  @param length 读取的长度，为-1时读取到对象末尾
+ *  @return body
+ *  @return err
+ */
+func (client *MinioOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = minioError("GetObjectRange", objectName, err) }()
+	if _, err = rangeHeader(offset, length); err != nil {
+		return
+	}
+	end := int64(0)
+	if length > 0 {
+		end = offset + length - 1
+	}
+	opts := minio.GetObjectOptions{}
+	if err = opts.SetRange(offset, end); err != nil {
+		return
+	}
+	// minio.Object会自行管理读取位置并覆盖Range，因此直接使用Core发起请求
+	core := minio.Core{Client: client.Client}
+	body, _, _, err = core.GetObject(ctx, client.Bucket, objectName, opts)
+	return
+}
+
+// StatObject
+/**
+ *  @Description: 获取对象信息
+ *  @receiver client
+ *  @param objectName
+ *  @return info
+ *  @return err
+ */
+func (client *MinioOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.StatObjectCtx(ctx, objectName)
+}
+
+func (client *MinioOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = minioError("StatObject", objectName, err) }()
+	var oi minio.ObjectInfo
+	oi, err = client.Client.StatObject(ctx, client.Bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return
+	}
+	info = minioObjectInfo(objectName, oi)
+	return
+}
+
+// minioObjectInfo 转换minio返回的对象信息
+func minioObjectInfo(key string, oi minio.ObjectInfo) ossmod.ObjectInfo {
+	return ossmod.ObjectInfo{
+		Key:                key,
+		Size:               oi.Size,
+		ETag:               trimETag(oi.ETag),
+		LastModified:       oi.LastModified,
+		StorageClass:       oi.StorageClass,
+		ContentType:        oi.ContentType,
+		ContentLength:      oi.Size,
+		ContentDisposition: oi.Metadata.Get("Content-Disposition"),
+		CacheControl:       oi.Metadata.Get("Cache-Control"),
+		ContentEncoding:    oi.Metadata.Get("Content-Encoding"),
+		Metadata:           userMeta(oi.UserMetadata),
+		VersionID:          oi.VersionID,
+		Expires:            oi.Expires,
+	}
+}
+
+// minioPutOptions 将上传参数转换为minio的请求参数
+func minioPutOptions(opts ossmod.PutOptions) minio.PutObjectOptions {
+	return minio.PutObjectOptions{
+		ContentType:        opts.ContentType,
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		ContentEncoding:    opts.ContentEncoding,
+		UserMetadata:       opts.Metadata,
+		StorageClass:       opts.StorageClass,
+	}
+}
+
+// CopyObject
+/**
+ *  @Description: 在服务端复制对象
+ *  @receiver client
+ *  @param src 源对象
+ *  @param dst 目标对象
+ *  @param opts 可选参数，可指定源存储桶
+ *  @return err
+ */
+func (client *MinioOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.CopyObjectCtx(ctx, src, dst, opts)
+}
+
+// CopyObjectCtx
+/**
+ *  @Description: 在服务端复制对象
+ *  @receiver client
+ *  @param ctx
+ *  @param src 源对象
+ *  @param dst 目标对象
+ *  @param opts 可选参数，可指定源存储桶
+ *  @return err
+ */
+func (client *MinioOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = minioError("CopyObject", src, err) }()
+	_, err = client.Client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: client.Bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: copySource(opts, client.Bucket), Object: src},
+	)
+	return
+}
+
+// MoveObject
+/**
+ *  @Description: 移动对象，先复制再删除源对象
+ *  @receiver client
+ *  @param src 源对象
+ *  @param dst 目标对象
+ *  @return err
+ */
+func (client *MinioOss) MoveObject(src, dst string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.MoveObjectCtx(ctx, src, dst)
+}
+
+// MoveObjectCtx
+/**
+ *  @Description: 移动对象，先复制再删除源对象
+ *  @receiver client
+ *  @param ctx
+ *  @param src 源对象
+ *  @param dst 目标对象
+ *  @return err
+ */
+func (client *MinioOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = minioError("MoveObject", src, err) }()
+	err = moveObject(ctx, client, src, dst)
+	return
+}
+
+// PresignGet
+/**
+ *  @Description: 生成下载URL，需要时会请求存储桶所在区域，受TimeOut限制
+ *  @receiver client
+ *  @param objectName
+ *  @param ttl 有效期，不能超过7天
+ *  @return signedURL
+ *  @return err
+ */
+func (client *MinioOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = minioError("PresignGet", objectName, err) }()
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	var u *url.URL
+	u, err = client.Client.PresignedGetObject(ctx, client.Bucket, objectName, ttl, nil)
+	if err != nil {
+		return
+	}
+	signedURL = u.String()
+	return
+}
+
+// PresignPut
+/**
+ *  @Description: 生成上传URL，contentType不为空时参与签名
+ *  @receiver client
+ *  @param objectName
+ *  @param ttl 有效期，不能超过7天
+ *  @param contentType
+ *  @return signedURL
+ *  @return err
+ */
+func (client *MinioOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	defer func() { err = minioError("PresignPut", objectName, err) }()
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	var header http.Header
+	if contentType != "" {
+		header = http.Header{"Content-Type": []string{contentType}}
+	}
+	var u *url.URL
+	u, err = client.Client.PresignHeader(ctx, http.MethodPut, client.Bucket, objectName, ttl, nil, header)
+	if err != nil {
+		return
+	}
+	signedURL = u.String()
+	return
+}
+
+// MinPartSize S3协议要求除最后一个分片外不小于5MB
+func (client *MinioOss) MinPartSize() int64 {
+	return 5 << 20
+}
+
+// InitMultipartUploadCtx
+/**
+ *  @Description: 初始化分片上传
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @param opts 可选参数
+ *  @return uploadID
+ *  @return err
+ */
+func (client *MinioOss) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = minioError("InitMultipartUpload", objectName, err) }()
+	core := minio.Core{Client: client.Client}
+	uploadID, err = core.NewMultipartUpload(ctx, client.Bucket, objectName, minioPutOptions(opts))
+	return
+}
+
+// UploadPartCtx
+/**
+ *  @Description: 上传分片
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @param uploadID
+ *  @param partNumber 分片编号，从1开始
+ *  @param r 分片内容
+ *  @param size 分片长度
+ *  @return part
+ *  @return err
+ */
+func (client *MinioOss) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = minioError("UploadPart", objectName, err) }()
+	core := minio.Core{Client: client.Client}
+	var uploaded minio.ObjectPart
+	uploaded, err = core.PutObjectPart(ctx, client.Bucket, objectName, uploadID, partNumber, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: partNumber, ETag: trimETag(uploaded.ETag), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx
+/**
+ *  @Description: 完成分片上传
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @param uploadID
+ *  @param parts 按分片编号升序排列的分片
+ *  @return err
+ */
+func (client *MinioOss) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = minioError("CompleteMultipartUpload", objectName, err) }()
+	core := minio.Core{Client: client.Client}
+	completed := make([]minio.CompletePart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	_, err = core.CompleteMultipartUpload(ctx, client.Bucket, objectName, uploadID, completed, minio.PutObjectOptions{})
+	return
+}
+
+// AbortMultipartUploadCtx
+/**
+ *  @Description: 终止分片上传
+ *  @receiver client
+ *  @param ctx
+ *  @param objectName
+ *  @param uploadID
+ *  @return err
+ */
+func (client *MinioOss) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = minioError("AbortMultipartUpload", objectName, err) }()
+	core := minio.Core{Client: client.Client}
+	err = core.AbortMultipartUpload(ctx, client.Bucket, objectName, uploadID)
+	return
+}
+
+// minioError 将MinIO SDK的错误转换为*Error
+func minioError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	resp := minio.ToErrorResponse(err)
+	return newError("minio", op, key, resp.StatusCode, resp.Code, resp.RequestID, err)
+}
@@ -0,0 +1,327 @@
+/**
+ * @Time    :2026/10/18 17:25
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPartSize 默认分片大小
+	defaultPartSize = 8 << 20
+	// defaultConcurrency 默认并发上传的分片数
+	defaultConcurrency = 4
+	// maxParts 单次分片上传允许的最大分片数
+	maxParts = 10000
+)
+
+// MultipartI 分片上传接口，由支持原生分片上传的客户端实现，一般通过UploadFile使用
+type MultipartI interface {
+	// InitMultipartUploadCtx 初始化分片上传
+	InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error)
+	// UploadPartCtx 上传分片，partNumber从1开始
+	UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error)
+	// CompleteMultipartUploadCtx 按分片编号合并已上传的分片
+	CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error)
+	// AbortMultipartUploadCtx 终止分片上传并清理已上传的分片
+	AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error)
+	// MinPartSize 除最后一个分片外每个分片的最小字节数，为0时不限制
+	MinPartSize() int64
+}
+
+// uploadCheckpoint 断点文件的内容，本地文件变化后断点失效
+type uploadCheckpoint struct {
+	// Provider 与 Bucket 为上传的目标，与ClientI.Target一致
+	Provider   string        `json:"provider"`
+	Bucket     string        `json:"bucket"`
+	ObjectName string        `json:"object_name"`
+	UploadID   string        `json:"upload_id"`
+	FileSize   int64         `json:"file_size"`
+	ModTime    time.Time     `json:"mod_time"`
+	PartSize   int64         `json:"part_size"`
+	Parts      []ossmod.Part `json:"parts"`
+}
+
+// UploadFile
+/**
+ *  @Description: 分片并发上传本地文件，支持断点续传
+ *  不大于一个分片的文件或未实现MultipartI的客户端（如又拍云）直接调用PutObjectStreamCtx上传
+ *  启用断点时，上传失败会保留分片与断点文件，再次调用时跳过已完成的分片；
+ *  断点失效或未启用断点时，会终止对应的分片上传，避免残留分片；断点属于其他服务商或存储桶时返回错误
+ *  opts.PartSize小于服务商要求的最小分片大小（MultipartI.MinPartSize）时直接返回错误
+ *  @param ctx
+ *  @param client
+ *  @param objectName
+ *  @param filePath 本地文件的完整路径
+ *  @param opts
+ *  @return err
+ */
+func UploadFile(ctx context.Context, client ClientI, objectName, filePath string, opts ossmod.MultipartOptions) (err error) {
+	var file *os.File
+	file, err = os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	var fi os.FileInfo
+	fi, err = file.Stat()
+	if err != nil {
+		return
+	}
+	mp, ok := client.(MultipartI)
+	// 过小的分片在完成上传时才会被服务端拒绝，此时分片已上传，因此提前校验
+	if ok && opts.PartSize > 0 && opts.PartSize < mp.MinPartSize() {
+		err = fmt.Errorf("upload %s: part size %d is smaller than the minimum %d", objectName, opts.PartSize, mp.MinPartSize())
+		return
+	}
+	partSize := opts.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	if fi.Size() > partSize*maxParts {
+		partSize = (fi.Size() + maxParts - 1) / maxParts
+	}
+	if !ok || fi.Size() <= partSize {
+		err = client.PutObjectStreamCtx(ctx, objectName, file, fi.Size(), opts.PutOptions)
+		return
+	}
+	// 分片上传在初始化时确定ContentType，需提前检测
+	_, opts.PutOptions, err = detectContentType(objectName, io.NewSectionReader(file, 0, fi.Size()), opts.PutOptions)
+	if err != nil {
+		return
+	}
+	provider, bucket := client.Target()
+	u := &multipartUpload{
+		client:     mp,
+		objectName: objectName,
+		file:       file,
+		opts:       opts,
+		checkpoint: uploadCheckpoint{
+			Provider:   provider,
+			Bucket:     bucket,
+			ObjectName: objectName,
+			FileSize:   fi.Size(),
+			ModTime:    fi.ModTime(),
+			PartSize:   partSize,
+		},
+	}
+	if !opts.DisableCheckpoint {
+		u.cpPath = opts.CheckpointFile
+		if u.cpPath == "" {
+			u.cpPath = filePath + ".upload.cp"
+		}
+	}
+	err = u.run(ctx)
+	return
+}
+
+// multipartUpload 一次分片上传的状态
+type multipartUpload struct {
+	client     MultipartI
+	objectName string
+	file       *os.File
+	opts       ossmod.MultipartOptions
+	// cpPath 断点文件路径，为空时不记录断点
+	cpPath     string
+	mu         sync.Mutex
+	checkpoint uploadCheckpoint
+}
+
+func (u *multipartUpload) run(ctx context.Context) (err error) {
+	var resumed bool
+	if resumed, err = u.loadCheckpoint(); err != nil {
+		return
+	}
+	if !resumed {
+		u.checkpoint.UploadID, err = u.client.InitMultipartUploadCtx(ctx, u.objectName, u.opts.PutOptions)
+		if err != nil {
+			return
+		}
+		if err = u.saveCheckpoint(); err != nil {
+			u.abort()
+			return
+		}
+	}
+	err = u.uploadParts(ctx)
+	if err == nil {
+		parts := append([]ossmod.Part(nil), u.checkpoint.Parts...)
+		sort.Slice(parts, func(i, j int) bool {
+			return parts[i].PartNumber < parts[j].PartNumber
+		})
+		err = u.client.CompleteMultipartUploadCtx(ctx, u.objectName, u.checkpoint.UploadID, parts)
+	}
+	switch {
+	case err == nil:
+		u.removeCheckpoint()
+	case resumed && errors.Is(err, ErrUploadNotFound):
+		// 断点记录的分片上传已过期或被清理，重新上传
+		u.removeCheckpoint()
+		u.checkpoint.UploadID = ""
+		u.checkpoint.Parts = nil
+		err = u.run(ctx)
+	case u.cpPath == "":
+		u.abort()
+	}
+	return
+}
+
+// uploadParts 并发上传尚未完成的分片，任一分片失败时取消其余分片
+func (u *multipartUpload) uploadParts(ctx context.Context) (err error) {
+	done := make(map[int]bool, len(u.checkpoint.Parts))
+	for _, part := range u.checkpoint.Parts {
+		done[part.PartNumber] = true
+	}
+	size, partSize := u.checkpoint.FileSize, u.checkpoint.PartSize
+	count := int((size + partSize - 1) / partSize)
+	pending := make(chan int, count)
+	for n := 1; n <= count; n++ {
+		if !done[n] {
+			pending <- n
+		}
+	}
+	close(pending)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var once sync.Once
+	fail := func(e error) {
+		once.Do(func() {
+			err = e
+			cancel()
+		})
+	}
+	concurrency := u.opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range pending {
+				if ctx.Err() != nil {
+					return
+				}
+				offset := int64(n-1) * partSize
+				length := partSize
+				if offset+length > size {
+					length = size - offset
+				}
+				r := io.NewSectionReader(u.file, offset, length)
+				part, e := u.client.UploadPartCtx(ctx, u.objectName, u.checkpoint.UploadID, n, r, length)
+				if e != nil {
+					fail(e)
+					return
+				}
+				part.PartNumber, part.Size = n, length
+				if e = u.addPart(part); e != nil {
+					fail(e)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err == nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+// addPart 记录已完成的分片并更新断点文件
+func (u *multipartUpload) addPart(part ossmod.Part) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.checkpoint.Parts = append(u.checkpoint.Parts, part)
+	return u.writeCheckpoint()
+}
+
+// loadCheckpoint
+/**
+ *  @Description: 读取断点文件，断点与本次上传不一致时终止其中记录的分片上传
+ *  @receiver u
+ *  @return resumed 是否沿用断点中的分片上传
+ *  @return err 断点属于其他服务商或存储桶时返回错误，不终止也不删除其中记录的分片上传
+ */
+func (u *multipartUpload) loadCheckpoint() (resumed bool, err error) {
+	if u.cpPath == "" {
+		return
+	}
+	data, readErr := ioutil.ReadFile(u.cpPath)
+	if readErr != nil {
+		return
+	}
+	var cp uploadCheckpoint
+	if json.Unmarshal(data, &cp) != nil || cp.UploadID == "" {
+		u.removeCheckpoint()
+		return
+	}
+	if cp.Provider != u.checkpoint.Provider || cp.Bucket != u.checkpoint.Bucket {
+		err = fmt.Errorf("upload %s: checkpoint %s belongs to %s bucket %s", u.objectName, u.cpPath, cp.Provider, cp.Bucket)
+		return
+	}
+	if cp.ObjectName != u.checkpoint.ObjectName || cp.FileSize != u.checkpoint.FileSize ||
+		!cp.ModTime.Equal(u.checkpoint.ModTime) || cp.PartSize != u.checkpoint.PartSize {
+		_ = u.client.AbortMultipartUploadCtx(context.Background(), cp.ObjectName, cp.UploadID)
+		u.removeCheckpoint()
+		return
+	}
+	u.checkpoint = cp
+	resumed = true
+	return
+}
+
+func (u *multipartUpload) saveCheckpoint() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.writeCheckpoint()
+}
+
+// writeCheckpoint 写入断点文件，调用方需持有锁
+func (u *multipartUpload) writeCheckpoint() error {
+	if u.cpPath == "" {
+		return nil
+	}
+	return writeJSONFile(u.cpPath, u.checkpoint)
+}
+
+// writeJSONFile 先写临时文件再重命名，避免中断时留下不完整的文件
+func writeJSONFile(filePath string, v interface{}) (err error) {
+	var data []byte
+	data, err = json.Marshal(v)
+	if err != nil {
+		return
+	}
+	tmp := filePath + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return
+	}
+	err = os.Rename(tmp, filePath)
+	return
+}
+
+func (u *multipartUpload) removeCheckpoint() {
+	if u.cpPath != "" {
+		_ = os.Remove(u.cpPath)
+	}
+}
+
+// abort 终止分片上传，ctx可能已被取消，因此使用新的context
+func (u *multipartUpload) abort() {
+	_ = u.client.AbortMultipartUploadCtx(context.Background(), u.objectName, u.checkpoint.UploadID)
+}
@@ -7,12 +7,18 @@ package oss
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/melf-xyzh/go-oss-client/model"
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
+	"github.com/qiniu/go-sdk/v7/client"
 	"github.com/qiniu/go-sdk/v7/storage"
 	"io"
+	"net"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 )
 
@@ -26,6 +32,18 @@ type QiNiuCloudOss struct {
 	putPolicy     storage.PutPolicy
 	bucketManager *storage.BucketManager
 	RegionID      storage.RegionID
+	// httpClient 下载使用的客户端，TimeOut只限制建立连接与等待响应头的时间
+	httpClient *http.Client
+}
+
+func init() {
+	register("qiniu", func(cfg Config) (ClientI, error) {
+		regionID := storage.RIDHuadong
+		if cfg.Region != "" {
+			regionID = storage.RegionID(cfg.Region)
+		}
+		return NewQiNiuCloudOss(endpointURL(cfg.Endpoint, cfg.UseSSL), cfg.AccessKey, cfg.SecretKey, cfg.Bucket, cfg.Timeout, cfg.UseSSL, regionID), nil
+	}, "endpoint", "access_key", "secret_key", "bucket")
 }
 
 func NewQiNiuCloudOss(endpoint, accessKey, secretKey, bucket string, timeOut int, useSSL bool, regionID storage.RegionID) (qiNiuCloudOss *QiNiuCloudOss) {
@@ -33,6 +51,7 @@ func NewQiNiuCloudOss(endpoint, accessKey, secretKey, bucket string, timeOut int
 		Endpoint:  endpoint,
 		AccessKey: accessKey,
 		SecretKey: secretKey,
+		Bucket:    bucket,
 		TimeOut:   timeOut,
 		RegionID:  regionID,
 	}
@@ -49,103 +68,605 @@ func NewQiNiuCloudOss(endpoint, accessKey, secretKey, bucket string, timeOut int
 	// 如果没有特殊需求，默认不需要指定
 	//cfg.Region=&storage.ZoneHuabei
 	qiNiuCloudOss.bucketManager = storage.NewBucketManager(qiNiuCloudOss.mac, &cfg)
+	// 下载时间与对象大小有关，不设置整体超时，读取响应体只受ctx控制
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if timeOut > 0 {
+		d := time.Duration(timeOut) * time.Second
+		transport.DialContext = (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = d
+		transport.ResponseHeaderTimeout = d
+	}
+	qiNiuCloudOss.httpClient = &http.Client{Transport: transport}
 	return
 }
 
+// Target 返回服务商名称与存储桶
+func (client *QiNiuCloudOss) Target() (provider, bucket string) {
+	return "qiniu", client.Bucket
+}
+
 func (client *QiNiuCloudOss) NewBucket() (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.NewBucketCtx(ctx)
+}
+
+// NewBucketCtx 创建存储桶，七牛云管理接口不支持context，仅在请求前检查
+func (client *QiNiuCloudOss) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = qiniuError("NewBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	err = client.bucketManager.CreateBucket(client.Bucket, client.RegionID)
 	return
 }
 
 func (client *QiNiuCloudOss) RemoveBucket() (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemoveBucketCtx(ctx)
+}
+
+// RemoveBucketCtx 删除存储桶
+func (client *QiNiuCloudOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = qiniuError("RemoveBucket", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	err = client.bucketManager.DropBucket(client.Bucket)
 	return
 }
 
 func (client *QiNiuCloudOss) BucketExist() (exist bool, err error) {
-	_, err = client.bucketManager.GetBucketInfo(client.Bucket)
-	if err != nil {
-		exist = false
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.BucketExistCtx(ctx)
+}
+
+// BucketExistCtx 判断存储桶是否存在
+func (client *QiNiuCloudOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = qiniuError("BucketExist", "", err) }()
+	if err = ctx.Err(); err != nil {
 		return
 	}
-	exist = true
+	_, err = client.bucketManager.GetBucketInfo(client.Bucket)
+	exist, err = existResult(qiniuError("BucketExist", "", err), ErrBucketNotFound)
 	return
 }
 
 func (client *QiNiuCloudOss) PutObject(objectName string, filePath string) (err error) {
-	// 进行上传凭证的生成
-	upToken := client.putPolicy.UploadToken(client.mac)
-	cfg := storage.Config{}
-	// 用来构建一个表单上传的对象
-	formUploader := storage.NewFormUploader(&cfg)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
-	err = formUploader.PutFile(ctx, nil, upToken, objectName, filePath, nil)
+	return client.PutObjectCtx(ctx, objectName, filePath)
+}
+
+// PutObjectCtx 上传文件，超时与取消由ctx控制
+func (client *QiNiuCloudOss) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = qiniuError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
+// GetObject 下载文件，TimeOut只限制建立连接与等待响应头的时间，不限制传输时间
 func (client *QiNiuCloudOss) GetObject(objectName string, filePath string) (err error) {
-	deadline := time.Now().Add(time.Second * 3600).Unix() //1小时有效期
-	privateAccessURL := storage.MakePrivateURL(client.mac, client.Endpoint, objectName, deadline)
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+// GetObjectCtx 下载文件，超时与取消由ctx控制
+func (client *QiNiuCloudOss) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = qiniuError("GetObject", objectName, err) }()
 	// 使用http下载对象
-	var resp *http.Response
-	resp, err = http.Get(privateAccessURL)
+	var body io.ReadCloser
+	body, _, err = client.GetObjectStreamCtx(ctx, objectName)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-	var file *os.File
-	file, err = os.Create(filePath)
+	// 拷贝文件
+	err = copyToFile(ctx, body, filePath)
+	return
+}
+
+func (client *QiNiuCloudOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ListObjectsCtx(ctx, prefix, startAfter)
+}
+
+// ListObjectsCtx 获取对象列表，每页请求前检查ctx
+func (client *QiNiuCloudOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = qiniuError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象
+func (client *QiNiuCloudOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *QiNiuCloudOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ListDirCtx(ctx, prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *QiNiuCloudOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = qiniuError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 请求一页对象，七牛云的marker不是对象名，从指定对象之后开始列举时按其格式构造marker
+func (client *QiNiuCloudOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = qiniuError("ListObjects", "", err) }()
+	if marker == "" && startAfter != "" {
+		marker = qiniuMarker(startAfter)
+	}
+	var ret *storage.ListFilesRet
+	ret, page.truncated, err = client.bucketManager.ListFilesWithContext(ctx, client.Bucket,
+		storage.ListInputOptionsPrefix(prefix),
+		storage.ListInputOptionsDelimiter(delimiter),
+		storage.ListInputOptionsMarker(marker),
+		storage.ListInputOptionsLimit(maxKeys))
 	if err != nil {
 		return
 	}
-	defer file.Close()
-	// 拷贝文件
-	_, err = io.Copy(file, resp.Body)
+	for _, entry := range ret.Items {
+		o := ossmod.ObjectInfo{
+			Key:          entry.Key,
+			Size:         entry.Fsize,
+			ETag:         entry.Hash,
+			LastModified: qiniuPutTime(entry.PutTime),
+			StorageClass: qiniuStorageClass(entry.Type),
+		}
+		page.objects = append(page.objects, o)
+	}
+	page.prefixes = ret.CommonPrefixes
+	page.nextMarker = ret.Marker
 	return
 }
 
-func (client *QiNiuCloudOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
-	limit := 1000
-	delimiter := ""
-	//初始列举marker为空
-	marker := startAfter
-	for {
-		var entries []storage.ListItem
-		var nextMarker string
-		var hasNext bool
-		entries, _, nextMarker, hasNext, err = client.bucketManager.ListFiles(client.Bucket, prefix, delimiter, marker, limit)
+// qiniuMarker 构造从objectName之后开始列举的marker，格式为 base64({"c":0,"k":"<objectName>"})
+func qiniuMarker(objectName string) string {
+	data, _ := json.Marshal(struct {
+		C int    `json:"c"`
+		K string `json:"k"`
+	}{K: objectName})
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+func (client *QiNiuCloudOss) RemoveObject(objectName string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemoveObjectCtx(ctx, objectName)
+}
+
+// RemoveObjectCtx 删除单个文件
+func (client *QiNiuCloudOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = qiniuError("RemoveObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	// 删除不存在的对象不视为错误
+	if err = qiniuError("RemoveObject", objectName, client.bucketManager.Delete(client.Bucket, objectName)); errors.Is(err, ErrObjectNotFound) {
+		err = nil
+	}
+	return
+}
+
+func (client *QiNiuCloudOss) RemoveObjects(keys []string) (failed map[string]error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemoveObjectsCtx(ctx, keys)
+}
+
+// RemoveObjectsCtx 使用batch接口批量删除对象
+func (client *QiNiuCloudOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		defer func() { err = qiniuError("RemoveObjects", "", err) }()
+		ops := make([]string, 0, len(batch))
+		for _, key := range batch {
+			ops = append(ops, storage.URIDelete(client.Bucket, key))
+		}
+		var rets []storage.BatchOpRet
+		rets, err = client.bucketManager.BatchWithContext(ctx, client.Bucket, ops)
+		// 部分操作失败时SDK同时返回各操作的结果与错误，此时按结果逐个判断
+		if len(rets) == len(batch) {
+			err = nil
+		}
 		if err != nil {
-			break
+			return
 		}
-		for _, entry := range entries {
-			o := ossmod.ObjectInfo{
-				Key:  entry.Key,
-				Size: entry.Fsize,
+		for i, ret := range rets {
+			if ret.Code == http.StatusOK {
+				continue
 			}
-			o.LastModified = time.Unix(entry.PutTime, 0)
-			objects = append(objects, o)
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[batch[i]] = qiniuBatchError("RemoveObjects", batch[i], ret)
 		}
-		if hasNext {
-			marker = nextMarker
-		} else {
-			break
+		return
+	})
+}
+
+func (client *QiNiuCloudOss) RemovePrefix(prefix string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemovePrefixCtx(ctx, prefix)
+}
+
+// RemovePrefixCtx 删除以prefix开头的全部对象
+func (client *QiNiuCloudOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = qiniuError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
+func (client *QiNiuCloudOss) ObjectExist(objectName string) (exist bool, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ObjectExistCtx(ctx, objectName)
+}
+
+// ObjectExistCtx 判断文件是否存在
+func (client *QiNiuCloudOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = qiniuError("ObjectExist", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	_, err = client.StatObjectCtx(ctx, objectName)
+	exist, err = existResult(err, ErrObjectNotFound)
+	return
+}
+
+func (client *QiNiuCloudOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.PutObjectStreamCtx(ctx, objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 从io.Reader上传对象，表单上传需要指定长度；七牛云不支持设置ContentDisposition、CacheControl与ContentEncoding
+func (client *QiNiuCloudOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = qiniuError("PutObjectStream", objectName, err) }()
+	// 只指定空间的上传凭证不允许覆盖同名对象，需要指定对象名
+	putPolicy := client.putPolicy
+	putPolicy.Scope = client.Bucket + ":" + objectName
+	if opts.StorageClass != "" {
+		if putPolicy.FileType, err = qiniuFileType(opts.StorageClass); err != nil {
+			return
+		}
+	}
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	r, size, err = sizedReader(&ctxReader{ctx: ctx, r: r}, size)
+	if err != nil {
+		return
+	}
+	// 进行上传凭证的生成，存储类型由上传策略指定
+	upToken := putPolicy.UploadToken(client.mac)
+	cfg := storage.Config{}
+	formUploader := storage.NewFormUploader(&cfg)
+	extra := &storage.PutExtra{MimeType: opts.ContentType}
+	if len(opts.Metadata) > 0 {
+		extra.Params = make(map[string]string, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			extra.Params["x-qn-meta-"+k] = v
 		}
 	}
+	err = formUploader.Put(ctx, nil, upToken, objectName, r, size, extra)
 	return
 }
 
-func (client *QiNiuCloudOss) RemoveObject(objectName string) (err error) {
-	err = client.bucketManager.Delete(client.Bucket, objectName)
+// GetObjectStream 以流的方式下载对象，TimeOut只限制建立连接与等待响应头的时间，不限制读取时间
+func (client *QiNiuCloudOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+// GetObjectStreamCtx 以流的方式下载对象，超时与取消由ctx控制
+func (client *QiNiuCloudOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = qiniuError("GetObjectStream", objectName, err) }()
+	var resp *http.Response
+	resp, err = client.get(ctx, "GetObjectStream", objectName, "")
+	if err != nil {
+		return
+	}
+	info = objectInfoFromHeader(objectName, resp.Header, "x-qn-")
+	body = resp.Body
 	return
 }
 
-func (client *QiNiuCloudOss) ObjectExist(objectName string) (exist bool, err error) {
-	_, err = client.bucketManager.Stat(client.Bucket, objectName)
+// GetObjectRange 读取对象的指定范围，超时规则与GetObjectStream相同
+func (client *QiNiuCloudOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+// GetObjectRangeCtx 读取对象的指定范围
+func (client *QiNiuCloudOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = qiniuError("GetObjectRange", objectName, err) }()
+	var rng string
+	rng, err = rangeHeader(offset, length)
 	if err != nil {
-		exist = false
 		return
 	}
-	exist = true
+	var resp *http.Response
+	resp, err = client.get(ctx, "GetObjectRange", objectName, rng)
+	if err != nil {
+		return
+	}
+	body = resp.Body
+	return
+}
+
+// get 通过私有下载链接下载对象，rng不为空时作为Range请求头
+func (client *QiNiuCloudOss) get(ctx context.Context, op, objectName, rng string) (resp *http.Response, err error) {
+	deadline := time.Now().Add(time.Second * 3600).Unix() //1小时有效期
+	privateAccessURL := storage.MakePrivateURL(client.mac, client.Endpoint, objectName, deadline)
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, privateAccessURL, nil)
+	if err != nil {
+		return
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	httpClient := client.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err = httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		err = newError("qiniu", op, objectName, resp.StatusCode, "", resp.Header.Get("X-Reqid"), fmt.Errorf("get %s: %s", objectName, resp.Status))
+		resp = nil
+	}
+	return
+}
+
+func (client *QiNiuCloudOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.StatObjectCtx(ctx, objectName)
+}
+
+// StatObjectCtx 获取对象信息，七牛云的ETag为文件的hash值
+func (client *QiNiuCloudOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = qiniuError("StatObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var fi storage.FileInfo
+	fi, err = client.bucketManager.Stat(client.Bucket, objectName)
+	if err != nil {
+		return
+	}
+	info = ossmod.ObjectInfo{
+		Key:           objectName,
+		Size:          fi.Fsize,
+		ETag:          fi.Hash,
+		LastModified:  qiniuPutTime(fi.PutTime),
+		StorageClass:  qiniuStorageClass(fi.Type),
+		ContentType:   fi.MimeType,
+		ContentLength: fi.Fsize,
+	}
+	if fi.Expiration > 0 {
+		info.Expires = time.Unix(fi.Expiration, 0)
+	}
+	return
+}
+
+// qiniuPutTime 七牛云的上传时间单位为100纳秒
+func qiniuPutTime(putTime int64) time.Time {
+	return time.Unix(0, putTime*100)
+}
+
+// qiniuStorageClass 将七牛云的存储类型转换为名称
+func qiniuStorageClass(fileType int) string {
+	switch fileType {
+	case 0:
+		return "STANDARD"
+	case 1:
+		return "LINE"
+	case 2:
+		return "ARCHIVE"
+	case 3:
+		return "DEEP_ARCHIVE"
+	}
+	return ""
+}
+
+// qiniuFileType 将存储类型名称转换为七牛云的存储类型
+func qiniuFileType(storageClass string) (fileType int, err error) {
+	switch strings.ToUpper(storageClass) {
+	case "STANDARD":
+		fileType = 0
+	case "LINE", "IA":
+		fileType = 1
+	case "ARCHIVE":
+		fileType = 2
+	case "DEEP_ARCHIVE":
+		fileType = 3
+	default:
+		err = fmt.Errorf("qiniu: unsupported storage class %q", storageClass)
+	}
+	return
+}
+
+func (client *QiNiuCloudOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.CopyObjectCtx(ctx, src, dst, opts)
+}
+
+// CopyObjectCtx 在服务端复制对象，SDK不支持context，仅在请求前检查ctx
+func (client *QiNiuCloudOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = qiniuError("CopyObject", src, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = client.bucketManager.Copy(copySource(opts, client.Bucket), src, client.Bucket, dst, true)
+	return
+}
+
+func (client *QiNiuCloudOss) MoveObject(src, dst string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.MoveObjectCtx(ctx, src, dst)
+}
+
+// MoveObjectCtx 使用七牛云原生的移动接口
+func (client *QiNiuCloudOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = qiniuError("MoveObject", src, err) }()
+	if src == dst {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = client.bucketManager.Move(client.Bucket, src, client.Bucket, dst, true)
+	return
+}
+
+// PresignGet 生成私有空间的下载URL，Endpoint须为空间绑定的下载域名
+func (client *QiNiuCloudOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = qiniuError("PresignGet", objectName, err) }()
+	var seconds int64
+	seconds, err = presignSeconds(ttl)
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Unix() + seconds
+	signedURL = storage.MakePrivateURL(client.mac, client.Endpoint, objectName, deadline)
 	return
 }
+
+// PresignPut 七牛云不支持预签名的上传URL，前端直传请使用UploadToken生成上传凭证
+func (client *QiNiuCloudOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	err = kindError("qiniu", "PresignPut", objectName, ErrNotSupported)
+	return
+}
+
+// UploadToken
+/**
+ *  @Description: 生成仅能上传指定对象的上传凭证，用于表单上传
+ *  @receiver client
+ *  @param objectName 对象名，允许覆盖同名对象
+ *  @param ttl 有效期
+ *  @return token
+ */
+func (client *QiNiuCloudOss) UploadToken(objectName string, ttl time.Duration) (token string) {
+	putPolicy := storage.PutPolicy{
+		Scope: client.putPolicy.Scope + ":" + objectName,
+	}
+	if seconds, err := presignSeconds(ttl); err == nil {
+		putPolicy.Expires = uint64(seconds)
+	}
+	token = putPolicy.UploadToken(client.mac)
+	return
+}
+
+// MinPartSize 七牛云分片上传v2要求除最后一个分片外不小于1MB
+func (client *QiNiuCloudOss) MinPartSize() int64 {
+	return 1 << 20
+}
+
+// InitMultipartUploadCtx 初始化分片上传（分片上传v2），七牛云在完成上传时才设置MIME类型与元数据，此处忽略opts
+func (client *QiNiuCloudOss) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = qiniuError("InitMultipartUpload", objectName, err) }()
+	uploader := storage.NewResumeUploaderV2(&storage.Config{})
+	var upHost string
+	upHost, err = uploader.UpHost(client.AccessKey, client.Bucket)
+	if err != nil {
+		return
+	}
+	var ret storage.InitPartsRet
+	err = uploader.InitParts(ctx, client.UploadToken(objectName, 0), upHost, client.Bucket, objectName, true, &ret)
+	if err != nil {
+		return
+	}
+	uploadID = ret.UploadID
+	return
+}
+
+// UploadPartCtx 上传分片
+func (client *QiNiuCloudOss) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = qiniuError("UploadPart", objectName, err) }()
+	uploader := storage.NewResumeUploaderV2(&storage.Config{})
+	var upHost string
+	upHost, err = uploader.UpHost(client.AccessKey, client.Bucket)
+	if err != nil {
+		return
+	}
+	var ret storage.UploadPartsRet
+	err = uploader.UploadParts(ctx, client.UploadToken(objectName, 0), upHost, client.Bucket, objectName, true, uploadID, int64(partNumber), "", &ret, &ctxReader{ctx: ctx, r: r}, int(size))
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: partNumber, ETag: ret.Etag, Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx 完成分片上传
+func (client *QiNiuCloudOss) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = qiniuError("CompleteMultipartUpload", objectName, err) }()
+	uploader := storage.NewResumeUploaderV2(&storage.Config{})
+	var upHost string
+	upHost, err = uploader.UpHost(client.AccessKey, client.Bucket)
+	if err != nil {
+		return
+	}
+	extra := &storage.RputV2Extra{}
+	for _, part := range parts {
+		extra.Progresses = append(extra.Progresses, storage.UploadPartInfo{Etag: part.ETag, PartNumber: int64(part.PartNumber)})
+	}
+	var ret storage.PutRet
+	err = uploader.CompleteParts(ctx, client.UploadToken(objectName, 0), upHost, &ret, client.Bucket, objectName, true, uploadID, extra)
+	return
+}
+
+// AbortMultipartUploadCtx 终止分片上传，SDK未封装该接口，直接调用 DELETE /buckets/<bucket>/objects/<key>/uploads/<uploadId>
+func (client *QiNiuCloudOss) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = qiniuError("AbortMultipartUpload", objectName, err) }()
+	uploader := storage.NewResumeUploaderV2(&storage.Config{})
+	var upHost string
+	upHost, err = uploader.UpHost(client.AccessKey, client.Bucket)
+	if err != nil {
+		return
+	}
+	reqURL := upHost + "/buckets/" + client.Bucket + "/objects/" + base64.URLEncoding.EncodeToString([]byte(objectName)) + "/uploads/" + uploadID
+	header := http.Header{"Authorization": []string{"UpToken " + client.UploadToken(objectName, 0)}}
+	err = uploader.Client.Call(ctx, nil, http.MethodDelete, reqURL, header)
+	return
+}
+
+// qiniuBatchError 将batch接口中单个操作的结果转换为*Error
+func qiniuBatchError(op, key string, ret storage.BatchOpRet) error {
+	return qiniuError(op, key, &client.ErrorInfo{Code: ret.Code, Err: ret.Data.Error})
+}
+
+// qiniuError 将七牛云SDK的错误转换为*Error，七牛云使用612、631等自定义状态码
+func qiniuError(op, key string, err error) error {
+	var ei *client.ErrorInfo
+	if !errors.As(err, &ei) {
+		return newError("qiniu", op, key, 0, "", "", err)
+	}
+	status := ei.Code
+	switch ei.Code {
+	case 612:
+		// 文件不存在，分片上传接口中表示uploadId不存在
+		if strings.HasSuffix(op, "MultipartUpload") || op == "UploadPart" {
+			return &Error{Provider: "qiniu", Op: op, Key: key, StatusCode: ei.Code, Code: ei.Err, RequestID: ei.Reqid, Err: err, kind: ErrUploadNotFound}
+		}
+		status = http.StatusNotFound
+	case 631:
+		// 空间不存在
+		return &Error{Provider: "qiniu", Op: op, Key: key, StatusCode: ei.Code, Code: ei.Err, RequestID: ei.Reqid, Err: err, kind: ErrBucketNotFound}
+	}
+	return newError("qiniu", op, key, status, ei.Err, ei.Reqid, err)
+}
@@ -0,0 +1,86 @@
+/**
+ * @Time    :2026/10/18 21:10
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// provider 已注册的服务商
+type provider struct {
+	factory func(Config) (ClientI, error)
+	// required 必填字段，使用配置文件中的字段名，由Config.Validate校验
+	required []string
+	// offline 不发起网络请求，不支持timeout与use_ssl
+	offline bool
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]provider)
+)
+
+// Register
+/**
+ *  @Description: 注册服务商，注册后可通过NewClientFromConfig与NewClient按名称创建客户端，通常在包的init中调用
+ *  名称为空、factory为nil或名称已注册时panic
+ *  @param name 服务商名称，与Config.Provider对应
+ *  @param factory 根据配置创建客户端，需自行校验所需的字段
+ */
+func Register(name string, factory func(Config) (ClientI, error)) {
+	register(name, factory)
+}
+
+// register 注册服务商并声明必填字段，用于内置服务商
+func register(name string, factory func(Config) (ClientI, error), required ...string) {
+	if name == "" {
+		panic("oss: Register with empty provider name")
+	}
+	if factory == nil {
+		panic("oss: Register factory is nil for provider " + name)
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if _, dup := providers[name]; dup {
+		panic("oss: Register called twice for provider " + name)
+	}
+	providers[name] = provider{factory: factory, required: required}
+}
+
+// registerOffline 注册不发起网络请求的内置服务商，Config.Validate拒绝为其设置timeout与use_ssl
+func registerOffline(name string, factory func(Config) (ClientI, error), required ...string) {
+	register(name, factory, required...)
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	p := providers[name]
+	p.offline = true
+	providers[name] = p
+}
+
+// Providers 返回已注册的服务商名称，按字典序排列
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupProvider 查找已注册的服务商
+func lookupProvider(name string) (p provider, err error) {
+	providersMu.RLock()
+	p, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		err = fmt.Errorf("oss config: unknown provider %q, registered providers: %v", name, Providers())
+	}
+	return
+}
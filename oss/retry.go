@@ -0,0 +1,572 @@
+/**
+ * @Time    :2026/10/18 21:40
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy 重试策略，零值字段使用默认值
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（含首次请求），默认3
+	MaxAttempts int
+	// BaseDelay 第一次重试前的等待时间，之后每次翻倍，默认100ms
+	BaseDelay time.Duration
+	// MaxDelay 单次等待时间的上限，默认5s
+	MaxDelay time.Duration
+	// Jitter 等待时间随机缩短的最大比例，取值(0, 1]，默认0.5；为负数时不加抖动
+	Jitter float64
+	// Retryable 判断错误是否可以重试，默认为IsRetryable
+	Retryable func(err error) bool
+	// OnRetry 每次重试前调用，attempt为已失败的次数，err为本次失败的错误，delay为即将等待的时间
+	OnRetry func(op, key string, attempt int, err error, delay time.Duration)
+}
+
+// withDefaults 补全未设置的字段
+func (policy RetryPolicy) withDefaults() RetryPolicy {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = 100 * time.Millisecond
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = 5 * time.Second
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = 0.5
+	}
+	if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+	return policy
+}
+
+// backoff 第attempt次失败后的等待时间
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if policy.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * policy.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// RetryError 重试后仍然失败时返回，可通过errors.Is与errors.As检查最后一次的错误
+type RetryError struct {
+	// Op 失败的操作
+	Op string
+	// Key 操作的对象名
+	Key string
+	// Attempts 总尝试次数
+	Attempts int
+	// Err 最后一次尝试的错误
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// retryableCodes 表示服务端暂时不可用或限流的错误码，键为服务商，""对应各服务商S3风格接口共用的错误码
+// 七牛云的限流（573）与又拍云的限流（429）没有错误码，按状态码判断
+var retryableCodes = map[string][]string{
+	"":      {"InternalError", "ServiceUnavailable", "SlowDown", "RequestTimeout", "Throttling"},
+	"minio": {"XMinioServerNotInitialized"},
+	"baidu": {"ServiceUnavailable", "InternalError", "RequestRateLimitExceeded"},
+}
+
+// IsRetryable
+/**
+ *  @Description: 判断错误是否为可重试的暂时性错误：5xx、429、408、服务端的限流错误码、连接重置与网络超时
+ *  对象不存在、无权限等已归类的错误以及ctx的取消与超时不重试
+ *  @param err
+ *  @return bool
+ */
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e.kind != nil {
+			return false
+		}
+		for _, provider := range []string{"", e.Provider} {
+			for _, code := range retryableCodes[provider] {
+				if e.Code == code {
+					return true
+				}
+			}
+		}
+		switch {
+		case e.StatusCode >= http.StatusInternalServerError && e.StatusCode != http.StatusNotImplemented:
+			return true
+		case e.StatusCode == http.StatusTooManyRequests, e.StatusCode == http.StatusRequestTimeout:
+			return true
+		case e.StatusCode != 0:
+			return false
+		}
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// 部分SDK只保留了底层错误的文本
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") || strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "unexpected EOF")
+}
+
+// RetryClient 为任意ClientI增加重试的装饰器，只重试幂等的操作：
+// 查询、下载、列举、删除、复制，以及数据来源可重新读取的上传（本地文件或实现了io.Seeker的Reader）；
+// NewBucket、RemoveBucket、MoveObject、预签名等操作直接调用被包装的客户端
+type RetryClient struct {
+	ClientI
+	policy RetryPolicy
+}
+
+// retryMultipartClient 被包装的客户端实现MultipartI时使用，使UploadFile仍可分片上传
+type retryMultipartClient struct {
+	*RetryClient
+	mp MultipartI
+}
+
+// NewRetryClient
+/**
+ *  @Description: 创建重试装饰器
+ *  @param client 被包装的客户端
+ *  @param policy 重试策略
+ *  @return ClientI client实现MultipartI时返回值同样实现MultipartI
+ */
+func NewRetryClient(client ClientI, policy RetryPolicy) ClientI {
+	rc := &RetryClient{ClientI: client, policy: policy.withDefaults()}
+	if mp, ok := client.(MultipartI); ok {
+		return &retryMultipartClient{RetryClient: rc, mp: mp}
+	}
+	return rc
+}
+
+// wait
+/**
+ *  @Description: 第attempt次尝试失败后判断是否重试，需要重试时等待退避时间
+ *  错误不可重试、已达到最大次数、ctx在等待结束前到期或被取消时返回false
+ *  @receiver client
+ *  @param ctx
+ *  @param op
+ *  @param key
+ *  @param attempt
+ *  @param err
+ *  @return bool
+ */
+func (client *RetryClient) wait(ctx context.Context, op, key string, attempt int, err error) bool {
+	if attempt >= client.policy.MaxAttempts || !client.policy.Retryable(err) || ctx.Err() != nil {
+		return false
+	}
+	delay := client.policy.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+	if client.policy.OnRetry != nil {
+		client.policy.OnRetry(op, key, attempt, err, delay)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// do 执行fn，失败时按重试策略重试，重试过的错误包装为*RetryError
+func (client *RetryClient) do(ctx context.Context, op, key string, fn func() error) (err error) {
+	attempt := 1
+	for ; ; attempt++ {
+		if err = fn(); err == nil || !client.wait(ctx, op, key, attempt, err) {
+			break
+		}
+	}
+	if err != nil && attempt > 1 {
+		err = &RetryError{Op: op, Key: key, Attempts: attempt, Err: err}
+	}
+	return
+}
+
+// rewind 返回重新读取r的函数，r未实现io.Seeker时返回nil，此时不重试
+func rewind(r io.Reader) func() error {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() (err error) {
+		_, err = seeker.Seek(start, io.SeekStart)
+		return
+	}
+}
+
+// doReader 数据来源为r的上传，r可重新读取时才重试
+func (client *RetryClient) doReader(ctx context.Context, op, key string, r io.Reader, fn func() error) (err error) {
+	reset := rewind(r)
+	if reset == nil {
+		err = fn()
+		return
+	}
+	first := true
+	err = client.do(ctx, op, key, func() error {
+		if !first {
+			if err := reset(); err != nil {
+				return err
+			}
+		}
+		first = false
+		return fn()
+	})
+	return
+}
+
+func (client *RetryClient) BucketExist() (exist bool, err error) {
+	err = client.do(context.Background(), "BucketExist", "", func() (err error) {
+		exist, err = client.ClientI.BucketExist()
+		return
+	})
+	return
+}
+
+func (client *RetryClient) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	err = client.do(ctx, "BucketExist", "", func() (err error) {
+		exist, err = client.ClientI.BucketExistCtx(ctx)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) PutObject(objectName string, filePath string) (err error) {
+	return client.do(context.Background(), "PutObject", objectName, func() error {
+		return client.ClientI.PutObject(objectName, filePath)
+	})
+}
+
+func (client *RetryClient) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	return client.do(ctx, "PutObject", objectName, func() error {
+		return client.ClientI.PutObjectCtx(ctx, objectName, filePath)
+	})
+}
+
+func (client *RetryClient) GetObject(objectName string, filePath string) (err error) {
+	return client.do(context.Background(), "GetObject", objectName, func() error {
+		return client.ClientI.GetObject(objectName, filePath)
+	})
+}
+
+func (client *RetryClient) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	return client.do(ctx, "GetObject", objectName, func() error {
+		return client.ClientI.GetObjectCtx(ctx, objectName, filePath)
+	})
+}
+
+func (client *RetryClient) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	err = client.do(context.Background(), "ListObjects", prefix, func() (err error) {
+		objects, err = client.ClientI.ListObjects(prefix, startAfter)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	err = client.do(ctx, "ListObjects", prefix, func() (err error) {
+		objects, err = client.ClientI.ListObjectsCtx(ctx, prefix, startAfter)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	err = client.do(context.Background(), "ListDir", prefix, func() (err error) {
+		objects, prefixes, err = client.ClientI.ListDir(prefix, delimiter)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	err = client.do(ctx, "ListDir", prefix, func() (err error) {
+		objects, prefixes, err = client.ClientI.ListDirCtx(ctx, prefix, delimiter)
+		return
+	})
+	return
+}
+
+// ListObjectsIter 某一页请求失败时，从已遍历到的最后一个对象之后重新请求
+func (client *RetryClient) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return &retryIterator{
+		ctx:    ctx,
+		client: client,
+		opts:   opts,
+		it:     client.ClientI.ListObjectsIter(ctx, opts),
+	}
+}
+
+func (client *RetryClient) RemoveObject(objectName string) (err error) {
+	return client.do(context.Background(), "RemoveObject", objectName, func() error {
+		return client.ClientI.RemoveObject(objectName)
+	})
+}
+
+func (client *RetryClient) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	return client.do(ctx, "RemoveObject", objectName, func() error {
+		return client.ClientI.RemoveObjectCtx(ctx, objectName)
+	})
+}
+
+func (client *RetryClient) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.removeObjects(context.Background(), keys, client.ClientI.RemoveObjects)
+}
+
+func (client *RetryClient) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return client.removeObjects(ctx, keys, func(keys []string) map[string]error {
+		return client.ClientI.RemoveObjectsCtx(ctx, keys)
+	})
+}
+
+// removeObjects 只重试因暂时性错误删除失败的对象
+func (client *RetryClient) removeObjects(ctx context.Context, keys []string, remove func(keys []string) map[string]error) (failed map[string]error) {
+	failed = make(map[string]error)
+	pending := keys
+	_ = client.do(ctx, "RemoveObjects", "", func() (err error) {
+		for _, key := range pending {
+			delete(failed, key)
+		}
+		result := remove(pending)
+		pending = nil
+		for key, keyErr := range result {
+			failed[key] = keyErr
+			if client.policy.Retryable(keyErr) {
+				pending = append(pending, key)
+				err = keyErr
+			}
+		}
+		return
+	})
+	if len(failed) == 0 {
+		failed = nil
+	}
+	return
+}
+
+func (client *RetryClient) RemovePrefix(prefix string) (err error) {
+	return client.do(context.Background(), "RemovePrefix", prefix, func() error {
+		return client.ClientI.RemovePrefix(prefix)
+	})
+}
+
+func (client *RetryClient) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	return client.do(ctx, "RemovePrefix", prefix, func() error {
+		return client.ClientI.RemovePrefixCtx(ctx, prefix)
+	})
+}
+
+func (client *RetryClient) ObjectExist(objectName string) (exist bool, err error) {
+	err = client.do(context.Background(), "ObjectExist", objectName, func() (err error) {
+		exist, err = client.ClientI.ObjectExist(objectName)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	err = client.do(ctx, "ObjectExist", objectName, func() (err error) {
+		exist, err = client.ClientI.ObjectExistCtx(ctx, objectName)
+		return
+	})
+	return
+}
+
+// PutObjectStream r实现io.Seeker时重试前回到起始位置，否则不重试
+func (client *RetryClient) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.doReader(context.Background(), "PutObjectStream", objectName, r, func() error {
+		return client.ClientI.PutObjectStream(objectName, r, size, opts)
+	})
+}
+
+func (client *RetryClient) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.doReader(ctx, "PutObjectStream", objectName, r, func() error {
+		return client.ClientI.PutObjectStreamCtx(ctx, objectName, r, size, opts)
+	})
+}
+
+// GetObjectStream 只重试请求本身，读取body时的错误由调用方处理
+func (client *RetryClient) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	err = client.do(context.Background(), "GetObjectStream", objectName, func() (err error) {
+		body, info, err = client.ClientI.GetObjectStream(objectName)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	err = client.do(ctx, "GetObjectStream", objectName, func() (err error) {
+		body, info, err = client.ClientI.GetObjectStreamCtx(ctx, objectName)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	err = client.do(context.Background(), "GetObjectRange", objectName, func() (err error) {
+		body, err = client.ClientI.GetObjectRange(objectName, offset, length)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	err = client.do(ctx, "GetObjectRange", objectName, func() (err error) {
+		body, err = client.ClientI.GetObjectRangeCtx(ctx, objectName, offset, length)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	err = client.do(context.Background(), "StatObject", objectName, func() (err error) {
+		info, err = client.ClientI.StatObject(objectName)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	err = client.do(ctx, "StatObject", objectName, func() (err error) {
+		info, err = client.ClientI.StatObjectCtx(ctx, objectName)
+		return
+	})
+	return
+}
+
+func (client *RetryClient) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.do(context.Background(), "CopyObject", src, func() error {
+		return client.ClientI.CopyObject(src, dst, opts)
+	})
+}
+
+func (client *RetryClient) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.do(ctx, "CopyObject", src, func() error {
+		return client.ClientI.CopyObjectCtx(ctx, src, dst, opts)
+	})
+}
+
+// MinPartSize 返回被包装的客户端的分片大小下限
+func (client *retryMultipartClient) MinPartSize() int64 {
+	return client.mp.MinPartSize()
+}
+
+// InitMultipartUploadCtx 重试可能产生多余的分片上传，因此不重试
+func (client *retryMultipartClient) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	return client.mp.InitMultipartUploadCtx(ctx, objectName, opts)
+}
+
+// UploadPartCtx r实现io.Seeker时重试，UploadFile传入的分片均可重新读取
+func (client *retryMultipartClient) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	err = client.doReader(ctx, "UploadPart", objectName, r, func() (err error) {
+		part, err = client.mp.UploadPartCtx(ctx, objectName, uploadID, partNumber, r, size)
+		return
+	})
+	return
+}
+
+// CompleteMultipartUploadCtx 首次请求可能已在服务端生效，重试会返回ErrUploadNotFound，因此不重试
+func (client *retryMultipartClient) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	return client.mp.CompleteMultipartUploadCtx(ctx, objectName, uploadID, parts)
+}
+
+func (client *retryMultipartClient) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	return client.do(ctx, "AbortMultipartUpload", objectName, func() error {
+		return client.mp.AbortMultipartUploadCtx(ctx, objectName, uploadID)
+	})
+}
+
+// retryIterator 出错时从已遍历到的位置重新创建被包装客户端的迭代器，连续失败的次数受MaxAttempts限制
+type retryIterator struct {
+	ctx    context.Context
+	client *RetryClient
+	opts   ossmod.ListOptions
+	it     ObjectIterator
+	// failures 自上一个对象以来连续失败的次数
+	failures int
+	err      error
+}
+
+func (it *retryIterator) Next() bool {
+	for {
+		if it.it.Next() {
+			it.failures = 0
+			return true
+		}
+		err := it.it.Err()
+		if err == nil {
+			return false
+		}
+		it.failures++
+		if !it.client.wait(it.ctx, "ListObjectsIter", it.opts.Prefix, it.failures, err) {
+			if it.failures > 1 {
+				err = &RetryError{Op: "ListObjectsIter", Key: it.opts.Prefix, Attempts: it.failures, Err: err}
+			}
+			it.err = err
+			return false
+		}
+		if token := it.it.PageToken(); token != "" {
+			it.opts.PageToken = token
+		}
+		it.it = it.client.ClientI.ListObjectsIter(it.ctx, it.opts)
+	}
+}
+
+func (it *retryIterator) Object() ossmod.ObjectInfo {
+	return it.it.Object()
+}
+
+func (it *retryIterator) Err() error {
+	return it.err
+}
+
+func (it *retryIterator) PageToken() string {
+	if token := it.it.PageToken(); token != "" {
+		return token
+	}
+	return it.opts.PageToken
+}
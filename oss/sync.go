@@ -0,0 +1,176 @@
+/**
+ * @Time    :2026/10/18 23:50
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"context"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"strings"
+	"sync"
+)
+
+// Sync
+/**
+ *  @Description: 将src中的对象同步到dst，src与dst可以是不同的服务商
+ *  按对象名逐个比较两个存储桶：dst中不存在、大小不同或内容已变化的对象通过流式下载再上传的方式复制，
+ *  opts.Delete为true时删除dst中多余的对象。判断内容是否变化时，两侧的ETag相同视为一致，
+ *  两侧的ETag都是MD5时按ETag比较，否则（如分片上传的ETag或不同服务商的ETag算法不同）src比dst新时视为已变化
+ *  单个对象失败不会中断同步，失败的对象记录在result.Failed中
+ *  @param ctx
+ *  @param src 源客户端
+ *  @param dst 目标客户端
+ *  @param opts
+ *  @return result
+ *  @return err 列举失败、ctx结束或有对象失败时不为nil
+ */
+func Sync(ctx context.Context, src, dst ClientI, opts ossmod.SyncOptions) (result ossmod.SyncResult, err error) {
+	s := &syncer{src: src, dst: dst, opts: opts}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	actions := make(chan ossmod.SyncAction)
+	var wg sync.WaitGroup
+	if !opts.DryRun {
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for action := range actions {
+					action.Err = s.apply(ctx, action)
+					s.record(action)
+				}
+			}()
+		}
+	}
+	err = s.diff(ctx, func(action ossmod.SyncAction) {
+		if opts.DryRun {
+			s.record(action)
+			return
+		}
+		actions <- action
+	})
+	close(actions)
+	wg.Wait()
+	result = s.result
+	if err == nil && len(result.Failed) > 0 {
+		err = syncFailure(result.Failed)
+	}
+	return
+}
+
+// syncer 一次同步的状态
+type syncer struct {
+	src, dst ClientI
+	opts     ossmod.SyncOptions
+	// mu 保护result，并使OnAction串行调用
+	mu     sync.Mutex
+	result ossmod.SyncResult
+}
+
+// diff 同时按对象名升序遍历两个存储桶，对需要复制或删除的对象调用emit
+func (s *syncer) diff(ctx context.Context, emit func(action ossmod.SyncAction)) (err error) {
+	listOpts := ossmod.ListOptions{Prefix: s.opts.Prefix}
+	srcIt := s.src.ListObjectsIter(ctx, listOpts)
+	dstIt := s.dst.ListObjectsIter(ctx, listOpts)
+	srcOK, dstOK := srcIt.Next(), dstIt.Next()
+	for (srcOK || dstOK) && ctx.Err() == nil {
+		// 任一侧因出错而结束时立即返回，否则另一侧剩余的对象会被当作新增或多余的对象
+		if !srcOK && srcIt.Err() != nil || !dstOK && dstIt.Err() != nil {
+			break
+		}
+		switch {
+		case !dstOK || srcOK && srcIt.Object().Key < dstIt.Object().Key:
+			o := srcIt.Object()
+			emit(ossmod.SyncAction{Op: ossmod.SyncCopy, Key: o.Key, Size: o.Size, Reason: "new"})
+			srcOK = srcIt.Next()
+		case !srcOK || dstIt.Object().Key < srcIt.Object().Key:
+			if o := dstIt.Object(); s.opts.Delete {
+				emit(ossmod.SyncAction{Op: ossmod.SyncDelete, Key: o.Key, Size: o.Size, Reason: "extraneous"})
+			}
+			dstOK = dstIt.Next()
+		default:
+			o := srcIt.Object()
+			if reason := syncReason(o, dstIt.Object()); reason != "" {
+				emit(ossmod.SyncAction{Op: ossmod.SyncCopy, Key: o.Key, Size: o.Size, Reason: reason})
+			} else {
+				s.mu.Lock()
+				s.result.Skipped++
+				s.mu.Unlock()
+			}
+			srcOK, dstOK = srcIt.Next(), dstIt.Next()
+		}
+	}
+	if err = srcIt.Err(); err == nil {
+		err = dstIt.Err()
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+// syncReason 判断同名的源对象与目标对象是否需要复制，返回原因，无需复制时为空
+func syncReason(src, dst ossmod.ObjectInfo) string {
+	switch {
+	case src.Size != dst.Size:
+		return "size"
+	case src.ETag != "" && src.ETag == dst.ETag:
+		return ""
+	case isMD5ETag(src.ETag) && isMD5ETag(dst.ETag):
+		if !strings.EqualFold(src.ETag, dst.ETag) {
+			return "etag"
+		}
+		return ""
+	case src.LastModified.After(dst.LastModified):
+		return "modified"
+	}
+	return ""
+}
+
+// apply 执行一个操作
+func (s *syncer) apply(ctx context.Context, action ossmod.SyncAction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if action.Op == ossmod.SyncDelete {
+		return s.dst.RemoveObjectCtx(ctx, action.Key)
+	}
+	return streamCopy(ctx, s.src, s.dst, action.Key, action.Key)
+}
+
+// record 统计操作的结果并调用OnAction
+func (s *syncer) record(action ossmod.SyncAction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case action.Err != nil:
+		if s.result.Failed == nil {
+			s.result.Failed = make(map[string]error)
+		}
+		s.result.Failed[action.Key] = action.Err
+	case action.Op == ossmod.SyncDelete:
+		s.result.Deleted++
+	default:
+		s.result.Copied++
+		s.result.CopiedBytes += action.Size
+	}
+	if s.opts.OnAction != nil {
+		s.opts.OnAction(action)
+	}
+}
+
+// syncFailure 将失败的对象合并为一个错误，包装对象名最小的失败原因
+func syncFailure(failed map[string]error) error {
+	first := ""
+	for key := range failed {
+		if first == "" || key < first {
+			first = key
+		}
+	}
+	return fmt.Errorf("sync: %d objects failed, first %s: %w", len(failed), first, failed[first])
+}
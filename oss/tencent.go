@@ -7,10 +7,13 @@ package oss
 
 import (
 	"context"
+	"errors"
 	"github.com/melf-xyzh/go-oss-client/model"
 	"github.com/tencentyun/cos-go-sdk-v5"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -22,6 +25,12 @@ type TencentCloudOss struct {
 	Client    *cos.Client
 }
 
+func init() {
+	register("tencent", func(cfg Config) (ClientI, error) {
+		return NewTencentCloudOss(endpointURL(cfg.Endpoint, cfg.UseSSL), cfg.AccessKey, cfg.SecretKey, cfg.Timeout)
+	}, "endpoint", "access_key", "secret_key")
+}
+
 func NewTencentCloudOss(endpoint, secretId, secretKey string, timeOut int) (tencentCloudOss *TencentCloudOss, err error) {
 	tencentCloudOss = &TencentCloudOss{
 		Endpoint:  endpoint,
@@ -41,90 +50,449 @@ func NewTencentCloudOss(endpoint, secretId, secretKey string, timeOut int) (tenc
 	return
 }
 
+// Target 返回服务商名称与存储桶，腾讯云的存储桶由访问域名确定，返回Endpoint
+func (client *TencentCloudOss) Target() (provider, bucket string) {
+	return "tencent", client.Endpoint
+}
+
 func (client *TencentCloudOss) NewBucket() (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.NewBucketCtx(ctx)
+}
+
+// NewBucketCtx 创建存储桶，超时与取消由ctx控制
+func (client *TencentCloudOss) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = tencentError("NewBucket", "", err) }()
 	_, err = client.Client.Bucket.Put(ctx, nil)
 	return
 }
 
 func (client *TencentCloudOss) RemoveBucket() (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.RemoveBucketCtx(ctx)
+}
+
+// RemoveBucketCtx 删除存储桶，超时与取消由ctx控制
+func (client *TencentCloudOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = tencentError("RemoveBucket", "", err) }()
 	_, err = client.Client.Bucket.Delete(ctx)
 	return
 }
 
 func (client *TencentCloudOss) BucketExist() (exist bool, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.BucketExistCtx(ctx)
+}
+
+// BucketExistCtx 判断存储桶是否存在，超时与取消由ctx控制
+func (client *TencentCloudOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = tencentError("BucketExist", "", err) }()
 	exist, err = client.Client.Bucket.IsExist(ctx)
 	return
 }
 
 func (client *TencentCloudOss) PutObject(objectName string, filePath string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
-	opt := &cos.ObjectPutOptions{
-		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
-			ContentType: "application/octet-stream",
-		},
-	}
-	_, err = client.Client.Object.PutFromFile(ctx, objectName, filePath, opt)
+	return client.PutObjectCtx(ctx, objectName, filePath)
+}
+
+// PutObjectCtx 上传文件，超时与取消由ctx控制
+func (client *TencentCloudOss) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = tencentError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
 func (client *TencentCloudOss) GetObject(objectName string, filePath string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.GetObjectCtx(ctx, objectName, filePath)
+}
+
+// GetObjectCtx 下载文件，超时与取消由ctx控制
+func (client *TencentCloudOss) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = tencentError("GetObject", objectName, err) }()
+	var resp *cos.Response
+	resp, err = client.Client.Object.Get(ctx, objectName, nil)
+	if err != nil {
+		return
+	}
 	// 下载对象到本地文件
-	_, err = client.Client.Object.GetToFile(ctx, objectName, filePath, nil)
+	err = copyToFile(ctx, resp.Body, filePath)
 	return
 }
 
 func (client *TencentCloudOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
-	var marker string
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ListObjectsCtx(ctx, prefix, startAfter)
+}
+
+// ListObjectsCtx 获取对象列表，超时与取消由ctx控制
+func (client *TencentCloudOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = tencentError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象
+func (client *TencentCloudOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *TencentCloudOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.ListDirCtx(ctx, prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *TencentCloudOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = tencentError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 请求一页对象，使用marker续接
+func (client *TencentCloudOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = tencentError("ListObjects", "", err) }()
+	if marker == "" {
+		marker = startAfter
+	}
 	opt := &cos.BucketGetOptions{
-		Prefix:    prefix,     // prefix 表示要查询的文件夹
-		Delimiter: startAfter, // deliter 表示分隔符, 设置为/表示列出当前目录下的 object, 设置为空表示列出所有的 object
-		MaxKeys:   1000,       // 设置最大遍历出多少个对象, 一次 listobject 最大支持1000
+		Prefix:    prefix,
+		Delimiter: delimiter, // 设置为/表示列出当前目录下的 object, 设置为空表示列出所有的 object
+		Marker:    marker,
+		MaxKeys:   maxKeys,
 	}
-	isTruncated := true
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
-	defer cancel()
-	for isTruncated {
-		opt.Marker = marker
-		var v *cos.BucketGetResult
-		v, _, err = client.Client.Bucket.Get(ctx, opt)
-		if err != nil {
-			break
-		}
-		for _, content := range v.Contents {
-			o := ossmod.ObjectInfo{
-				Key:          content.Key,
-				Size:         content.Size,
-				ETag:         content.ETag,
-				StorageClass: content.StorageClass,
-			}
-			o.LastModified, _ = time.Parse("2006-01-02T15:04:05.000Z", content.LastModified)
-			objects = append(objects, o)
+	var v *cos.BucketGetResult
+	v, _, err = client.Client.Bucket.Get(ctx, opt)
+	if err != nil {
+		return
+	}
+	for _, content := range v.Contents {
+		o := ossmod.ObjectInfo{
+			Key:          content.Key,
+			Size:         content.Size,
+			ETag:         trimETag(content.ETag),
+			StorageClass: content.StorageClass,
 		}
-		isTruncated = v.IsTruncated // 是否还有数据
-		marker = v.NextMarker       // 设置下次请求的起始 key
+		o.LastModified, _ = time.Parse("2006-01-02T15:04:05.000Z", content.LastModified)
+		page.objects = append(page.objects, o)
 	}
+	page.prefixes = v.CommonPrefixes
+	page.nextMarker, page.truncated = v.NextMarker, v.IsTruncated
 	return
 }
 
 func (client *TencentCloudOss) RemoveObject(objectName string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.RemoveObjectCtx(ctx, objectName)
+}
+
+// RemoveObjectCtx 删除单个文件，超时与取消由ctx控制
+func (client *TencentCloudOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = tencentError("RemoveObject", objectName, err) }()
 	_, err = client.Client.Object.Delete(ctx, objectName)
 	return
 }
 
+func (client *TencentCloudOss) RemoveObjects(keys []string) (failed map[string]error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemoveObjectsCtx(ctx, keys)
+}
+
+// RemoveObjectsCtx 批量删除对象，使用静默模式只返回删除失败的对象
+func (client *TencentCloudOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		defer func() { err = tencentError("RemoveObjects", "", err) }()
+		opt := &cos.ObjectDeleteMultiOptions{Quiet: true}
+		for _, key := range batch {
+			opt.Objects = append(opt.Objects, cos.Object{Key: key})
+		}
+		var result *cos.ObjectDeleteMultiResult
+		result, _, err = client.Client.Object.DeleteMulti(ctx, opt)
+		if err != nil {
+			return
+		}
+		for _, e := range result.Errors {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[e.Key] = deleteError("tencent", e.Key, e.Code, e.Message)
+		}
+		return
+	})
+}
+
+func (client *TencentCloudOss) RemovePrefix(prefix string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.RemovePrefixCtx(ctx, prefix)
+}
+
+// RemovePrefixCtx 删除以prefix开头的全部对象
+func (client *TencentCloudOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = tencentError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
+	return
+}
+
 func (client *TencentCloudOss) ObjectExist(objectName string) (exist bool, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(client.TimeOut)*time.Second)
+	ctx, cancel := timeoutCtx(client.TimeOut)
 	defer cancel()
+	return client.ObjectExistCtx(ctx, objectName)
+}
+
+// ObjectExistCtx 判断文件是否存在，超时与取消由ctx控制
+func (client *TencentCloudOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = tencentError("ObjectExist", objectName, err) }()
 	exist, err = client.Client.Object.IsExist(ctx, objectName)
 	return
 }
+
+func (client *TencentCloudOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.PutObjectStreamCtx(ctx, objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 从io.Reader上传对象，超时与取消由ctx控制
+func (client *TencentCloudOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = tencentError("PutObjectStream", objectName, err) }()
+	r, opts, err = detectContentType(objectName, r, opts)
+	if err != nil {
+		return
+	}
+	r, size, err = sizedReader(r, size)
+	if err != nil {
+		return
+	}
+	header := tencentPutHeader(opts)
+	header.ContentLength = size
+	_, err = client.Client.Object.Put(ctx, objectName, r, &cos.ObjectPutOptions{ObjectPutHeaderOptions: header})
+	return
+}
+
+// GetObjectStream 以流的方式下载对象，超时时间覆盖整个读取过程
+func (client *TencentCloudOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	body, info, err = client.GetObjectStreamCtx(ctx, objectName)
+	if err != nil {
+		cancel()
+		return
+	}
+	body = &cancelReadCloser{ReadCloser: body, cancel: cancel}
+	return
+}
+
+// GetObjectStreamCtx 以流的方式下载对象，超时与取消由ctx控制
+func (client *TencentCloudOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = tencentError("GetObjectStream", objectName, err) }()
+	var resp *cos.Response
+	resp, err = client.Client.Object.Get(ctx, objectName, nil)
+	if err != nil {
+		return
+	}
+	info = objectInfoFromHeader(objectName, resp.Header, "x-cos-")
+	info.StorageClass = resp.Header.Get("x-cos-storage-class")
+	body = resp.Body
+	return
+}
+
+func (client *TencentCloudOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	body, err = client.GetObjectRangeCtx(ctx, objectName, offset, length)
+	if err != nil {
+		cancel()
+		return
+	}
+	body = &cancelReadCloser{ReadCloser: body, cancel: cancel}
+	return
+}
+
+// GetObjectRangeCtx 读取对象的指定范围
+func (client *TencentCloudOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = tencentError("GetObjectRange", objectName, err) }()
+	var rng string
+	rng, err = rangeHeader(offset, length)
+	if err != nil {
+		return
+	}
+	var resp *cos.Response
+	resp, err = client.Client.Object.Get(ctx, objectName, &cos.ObjectGetOptions{Range: rng})
+	if err != nil {
+		return
+	}
+	body = resp.Body
+	return
+}
+
+func (client *TencentCloudOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.StatObjectCtx(ctx, objectName)
+}
+
+// StatObjectCtx 获取对象信息，超时与取消由ctx控制
+func (client *TencentCloudOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = tencentError("StatObject", objectName, err) }()
+	var resp *cos.Response
+	resp, err = client.Client.Object.Head(ctx, objectName, nil)
+	if err != nil {
+		return
+	}
+	info = objectInfoFromHeader(objectName, resp.Header, "x-cos-")
+	info.StorageClass = resp.Header.Get("x-cos-storage-class")
+	return
+}
+
+func (client *TencentCloudOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.CopyObjectCtx(ctx, src, dst, opts)
+}
+
+// CopyObjectCtx 在服务端复制对象，源存储桶与当前存储桶位于同一地域
+func (client *TencentCloudOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = tencentError("CopyObject", src, err) }()
+	// 源对象以 <bucket-appid>.cos.<region>.myqcloud.com/<key> 的形式指定
+	host := client.Client.BaseURL.BucketURL.Host
+	if opts.SrcBucket != "" {
+		if i := strings.IndexByte(host, '.'); i >= 0 {
+			host = opts.SrcBucket + host[i:]
+		}
+	}
+	_, _, err = client.Client.Object.Copy(ctx, dst, host+"/"+src, nil)
+	return
+}
+
+func (client *TencentCloudOss) MoveObject(src, dst string) (err error) {
+	ctx, cancel := timeoutCtx(client.TimeOut)
+	defer cancel()
+	return client.MoveObjectCtx(ctx, src, dst)
+}
+
+// MoveObjectCtx 移动对象，先复制再删除源对象
+func (client *TencentCloudOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = tencentError("MoveObject", src, err) }()
+	err = moveObject(ctx, client, src, dst)
+	return
+}
+
+// PresignGet 生成下载URL，签名在本地完成
+func (client *TencentCloudOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = tencentError("PresignGet", objectName, err) }()
+	signedURL, err = client.signURL(http.MethodGet, objectName, ttl, nil)
+	return
+}
+
+// PresignPut 生成上传URL，contentType不为空时参与签名
+func (client *TencentCloudOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	defer func() { err = tencentError("PresignPut", objectName, err) }()
+	var opt interface{}
+	if contentType != "" {
+		opt = &cos.PresignedURLOptions{Header: &http.Header{"Content-Type": []string{contentType}}}
+	}
+	signedURL, err = client.signURL(http.MethodPut, objectName, ttl, opt)
+	return
+}
+
+func (client *TencentCloudOss) signURL(method, objectName string, ttl time.Duration, opt interface{}) (signedURL string, err error) {
+	if _, err = presignSeconds(ttl); err != nil {
+		return
+	}
+	var u *url.URL
+	u, err = client.Client.Object.GetPresignedURL(context.Background(), method, objectName, client.SecretId, client.SecretKey, ttl, opt)
+	if err != nil {
+		return
+	}
+	signedURL = u.String()
+	return
+}
+
+// MinPartSize 腾讯云要求除最后一个分片外不小于1MB
+func (client *TencentCloudOss) MinPartSize() int64 {
+	return 1 << 20
+}
+
+// InitMultipartUploadCtx 初始化分片上传
+func (client *TencentCloudOss) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (uploadID string, err error) {
+	defer func() { err = tencentError("InitMultipartUpload", objectName, err) }()
+	opt := &cos.InitiateMultipartUploadOptions{ObjectPutHeaderOptions: tencentPutHeader(opts)}
+	var result *cos.InitiateMultipartUploadResult
+	result, _, err = client.Client.Object.InitiateMultipartUpload(ctx, objectName, opt)
+	if err != nil {
+		return
+	}
+	uploadID = result.UploadID
+	return
+}
+
+// UploadPartCtx 上传分片
+func (client *TencentCloudOss) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (part ossmod.Part, err error) {
+	defer func() { err = tencentError("UploadPart", objectName, err) }()
+	var resp *cos.Response
+	resp, err = client.Client.Object.UploadPart(ctx, objectName, uploadID, partNumber, r, &cos.ObjectUploadPartOptions{ContentLength: size})
+	if err != nil {
+		return
+	}
+	part = ossmod.Part{PartNumber: partNumber, ETag: trimETag(resp.Header.Get("ETag")), Size: size}
+	return
+}
+
+// CompleteMultipartUploadCtx 完成分片上传
+func (client *TencentCloudOss) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) (err error) {
+	defer func() { err = tencentError("CompleteMultipartUpload", objectName, err) }()
+	opt := &cos.CompleteMultipartUploadOptions{}
+	for _, part := range parts {
+		opt.Parts = append(opt.Parts, cos.Object{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	_, _, err = client.Client.Object.CompleteMultipartUpload(ctx, objectName, uploadID, opt)
+	return
+}
+
+// AbortMultipartUploadCtx 终止分片上传
+func (client *TencentCloudOss) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) (err error) {
+	defer func() { err = tencentError("AbortMultipartUpload", objectName, err) }()
+	_, err = client.Client.Object.AbortMultipartUpload(ctx, objectName, uploadID)
+	return
+}
+
+// tencentPutHeader 将上传参数转换为cos的请求头
+func tencentPutHeader(opts ossmod.PutOptions) *cos.ObjectPutHeaderOptions {
+	header := &cos.ObjectPutHeaderOptions{
+		ContentType:        opts.ContentType,
+		ContentDisposition: opts.ContentDisposition,
+		CacheControl:       opts.CacheControl,
+		ContentEncoding:    opts.ContentEncoding,
+		XCosStorageClass:   opts.StorageClass,
+	}
+	if len(opts.Metadata) > 0 {
+		meta := make(http.Header, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			meta.Set("x-cos-meta-"+k, v)
+		}
+		header.XCosMetaXXX = &meta
+	}
+	return header
+}
+
+// tencentError 将腾讯云SDK的错误转换为*Error
+func tencentError(op, key string, err error) error {
+	var re *cos.ErrorResponse
+	if errors.As(err, &re) {
+		status := 0
+		if re.Response != nil {
+			status = re.Response.StatusCode
+		}
+		return newError("tencent", op, key, status, re.Code, re.RequestID, err)
+	}
+	return newError("tencent", op, key, 0, "", "", err)
+}
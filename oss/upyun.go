@@ -6,9 +6,21 @@
 package oss
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
 	"github.com/melf-xyzh/go-oss-client/model"
 	"github.com/upyun/go-sdk/v3/upyun"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type UpYunOss struct {
@@ -17,10 +29,37 @@ type UpYunOss struct {
 	Password string
 	Bucket   string
 	TimeOut  int
-	Client   *upyun.UpYun
+	// Domain 空间绑定的加速域名，如 https://cdn.example.com，用于生成下载URL
+	Domain string
+	// Secret token防盗链密钥，用于生成下载URL
+	Secret string
+	Client *upyun.UpYun
+}
+
+func init() {
+	register("upyun", func(cfg Config) (ClientI, error) {
+		client := newUpYunOss(cfg.AccessKey, cfg.SecretKey, cfg.Bucket, !cfg.UseSSL)
+		client.Domain = endpointURL(cfg.Endpoint, cfg.UseSSL)
+		client.TimeOut = cfg.Timeout
+		if cfg.Timeout > 0 {
+			d := time.Duration(cfg.Timeout) * time.Second
+			client.Client.SetHTTPClient(&http.Client{Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   d,
+				ResponseHeaderTimeout: d,
+			}})
+		}
+		return client, nil
+	}, "access_key", "secret_key", "bucket")
 }
 
 func NewUpYunOss(operator, password, bucket string) (client *UpYunOss) {
+	return newUpYunOss(operator, password, bucket, false)
+}
+
+// newUpYunOss useHTTP为true时使用http访问接口
+func newUpYunOss(operator, password, bucket string, useHTTP bool) (client *UpYunOss) {
 	client = &UpYunOss{
 		Operator: operator,
 		Password: password,
@@ -31,74 +70,419 @@ func NewUpYunOss(operator, password, bucket string) (client *UpYunOss) {
 		Bucket:   client.Bucket,
 		Operator: client.Operator,
 		Password: client.Password,
+		UseHTTP:  useHTTP,
 	})
 	return
 }
 
+// Target 返回服务商名称与存储桶
+func (client *UpYunOss) Target() (provider, bucket string) {
+	return "upyun", client.Bucket
+}
+
 func (client *UpYunOss) NewBucket() (err error) {
 	return nil
 }
 
+func (client *UpYunOss) NewBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = upyunError("NewBucket", "", err) }()
+	return ctx.Err()
+}
+
 func (client *UpYunOss) RemoveBucket() (err error) {
 	return nil
 }
 
+func (client *UpYunOss) RemoveBucketCtx(ctx context.Context) (err error) {
+	defer func() { err = upyunError("RemoveBucket", "", err) }()
+	return ctx.Err()
+}
+
 func (client *UpYunOss) BucketExist() (exist bool, err error) {
 	return true, err
 }
 
+func (client *UpYunOss) BucketExistCtx(ctx context.Context) (exist bool, err error) {
+	defer func() { err = upyunError("BucketExist", "", err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	return true, err
+}
+
 func (client *UpYunOss) PutObject(objectName string, filePath string) (err error) {
-	// 上传文件
-	err = client.Client.Put(&upyun.PutObjectConfig{
-		Path:      objectName,
-		LocalPath: filePath,
-	})
+	return client.PutObjectCtx(context.Background(), objectName, filePath)
+}
+
+// PutObjectCtx 上传文件，ctx取消时中断传输
+func (client *UpYunOss) PutObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = upyunError("PutObject", objectName, err) }()
+	err = putFile(ctx, client, objectName, filePath)
 	return
 }
 
 func (client *UpYunOss) GetObject(objectName string, filePath string) (err error) {
-	_, err = client.Client.Get(&upyun.GetObjectConfig{
-		Path:      objectName,
-		LocalPath: filePath,
-	})
+	return client.GetObjectCtx(context.Background(), objectName, filePath)
+}
+
+// GetObjectCtx 下载文件，ctx取消时中断传输
+func (client *UpYunOss) GetObjectCtx(ctx context.Context, objectName string, filePath string) (err error) {
+	defer func() { err = upyunError("GetObject", objectName, err) }()
+	var body io.ReadCloser
+	body, _, err = client.GetObjectStreamCtx(ctx, objectName)
+	if err != nil {
+		return
+	}
+	err = copyToFile(ctx, body, filePath)
 	return
 }
 
 func (client *UpYunOss) ListObjects(prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
-	// 列目录
+	return client.ListObjectsCtx(context.Background(), prefix, startAfter)
+}
+
+// ListObjectsCtx 列目录，ctx取消时停止遍历
+func (client *UpYunOss) ListObjectsCtx(ctx context.Context, prefix, startAfter string) (objects []ossmod.ObjectInfo, err error) {
+	defer func() { err = upyunError("ListObjects", "", err) }()
+	objects, err = collectObjects(client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix, StartAfter: startAfter}))
+	return
+}
+
+// ListObjectsIter 分页遍历对象，又拍云没有分页接口，全部对象在首次请求时取回
+func (client *UpYunOss) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) ObjectIterator {
+	return newObjectIterator(ctx, opts, client.listPage)
+}
+
+func (client *UpYunOss) ListDir(prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	return client.ListDirCtx(context.Background(), prefix, delimiter)
+}
+
+// ListDirCtx 按分隔符列举prefix下一级的对象与公共前缀，delimiter为空时使用"/"
+func (client *UpYunOss) ListDirCtx(ctx context.Context, prefix, delimiter string) (objects []ossmod.ObjectInfo, prefixes []string, err error) {
+	defer func() { err = upyunError("ListDir", "", err) }()
+	objects, prefixes, err = listDir(ctx, client.listPage, prefix, delimiter)
+	return
+}
+
+// listPage 又拍云按目录列举且不保证顺序，每次请求都递归列出prefix所在目录下的全部文件，排序后一次返回
+func (client *UpYunOss) listPage(ctx context.Context, prefix, delimiter, startAfter, marker string, maxKeys int) (page objectPage, err error) {
+	defer func() { err = upyunError("ListObjects", "", err) }()
+	var all []ossmod.ObjectInfo
+	if all, err = client.listAll(ctx, prefix); err != nil {
+		return
+	}
+	page = pageObjects(all, prefix, delimiter, startAfter, len(all))
+	return
+}
+
+// listAll 递归列出prefix所在目录下以prefix开头的文件，按对象名升序排列
+func (client *UpYunOss) listAll(ctx context.Context, prefix string) (objects []ossmod.ObjectInfo, err error) {
+	dir := "/"
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = "/" + prefix[:i]
+	}
 	objsChan := make(chan *upyun.FileInfo, 10)
+	quitChan := make(chan bool)
+	stop := closeOnDone(ctx, closerFunc(func() error {
+		close(quitChan)
+		return nil
+	}))
+	defer stop()
+	errChan := make(chan error, 1)
 	go func() {
-		err = client.Client.List(&upyun.GetObjectsConfig{
-			Path:        startAfter,
-			ObjectsChan: objsChan,
+		errChan <- client.Client.List(&upyun.GetObjectsConfig{
+			Path:         dir,
+			ObjectsChan:  objsChan,
+			QuitChan:     quitChan,
+			MaxListLevel: -1,
 		})
 	}()
 	for obj := range objsChan {
-		if strings.HasPrefix(obj.Name, prefix) {
+		if obj.IsDir {
+			continue
+		}
+		key := strings.TrimPrefix(path.Join(dir, obj.Name), "/")
+		if strings.HasPrefix(key, prefix) {
 			o := ossmod.ObjectInfo{
-				Key:          obj.Name,
+				Key:          key,
 				Size:         obj.Size,
+				ETag:         obj.MD5,
 				LastModified: obj.Time,
 			}
 			objects = append(objects, o)
 		}
 	}
+	err = <-errChan
+	// 目录不存在时没有对象
+	if upyun.IsNotExist(err) {
+		err = nil
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
 	return
 }
 
 func (client *UpYunOss) RemoveObject(objectName string) (err error) {
+	return client.RemoveObjectCtx(context.Background(), objectName)
+}
+
+func (client *UpYunOss) RemoveObjectCtx(ctx context.Context, objectName string) (err error) {
+	defer func() { err = upyunError("RemoveObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
 	err = client.Client.Delete(&upyun.DeleteObjectConfig{
 		Path: objectName,
 	})
+	// 删除不存在的对象不视为错误
+	if upyun.IsNotExist(err) {
+		err = nil
+	}
+	return
+}
+
+func (client *UpYunOss) RemoveObjects(keys []string) (failed map[string]error) {
+	return client.RemoveObjectsCtx(context.Background(), keys)
+}
+
+// RemoveObjectsCtx 又拍云没有批量删除接口，逐个删除
+func (client *UpYunOss) RemoveObjectsCtx(ctx context.Context, keys []string) (failed map[string]error) {
+	return removeEach(ctx, client, keys)
+}
+
+func (client *UpYunOss) RemovePrefix(prefix string) (err error) {
+	return client.RemovePrefixCtx(context.Background(), prefix)
+}
+
+// RemovePrefixCtx 删除以prefix开头的全部对象
+func (client *UpYunOss) RemovePrefixCtx(ctx context.Context, prefix string) (err error) {
+	defer func() { err = upyunError("RemovePrefix", prefix, err) }()
+	err = removePrefix(ctx, client, prefix)
 	return
 }
 
 func (client *UpYunOss) ObjectExist(objectName string) (exist bool, err error) {
-	_, err = client.Client.GetInfo(objectName)
+	return client.ObjectExistCtx(context.Background(), objectName)
+}
+
+func (client *UpYunOss) ObjectExistCtx(ctx context.Context, objectName string) (exist bool, err error) {
+	defer func() { err = upyunError("ObjectExist", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	_, err = client.StatObjectCtx(ctx, objectName)
+	exist, err = existResult(err, ErrObjectNotFound)
+	return
+}
+
+func (client *UpYunOss) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	return client.PutObjectStreamCtx(context.Background(), objectName, r, size, opts)
+}
+
+// PutObjectStreamCtx 从io.Reader上传对象，ctx取消时中断传输
+func (client *UpYunOss) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) (err error) {
+	defer func() { err = upyunError("PutObjectStream", objectName, err) }()
+	r, opts, err = detectContentType(objectName, r, opts)
 	if err != nil {
-		exist = false
 		return
 	}
-	exist = true
+	r, size, err = sizedReader(&ctxReader{ctx: ctx, r: r}, size)
+	if err != nil {
+		return
+	}
+	headers := upyunPutHeaders(opts)
+	headers["Content-Length"] = strconv.FormatInt(size, 10)
+	err = client.Client.Put(&upyun.PutObjectConfig{
+		Path:    objectName,
+		Reader:  r,
+		Headers: headers,
+	})
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
 	return
 }
+
+func (client *UpYunOss) GetObjectStream(objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	return client.GetObjectStreamCtx(context.Background(), objectName)
+}
+
+// GetObjectStreamCtx 以流的方式下载对象
+func (client *UpYunOss) GetObjectStreamCtx(ctx context.Context, objectName string) (body io.ReadCloser, info ossmod.ObjectInfo, err error) {
+	defer func() { err = upyunError("GetObjectStream", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var resp *http.Response
+	resp, err = client.Client.GetRequest(&upyun.GetRequestConfig{
+		Path:    objectName,
+		Headers: map[string]string{"x-upyun-folder": "false"},
+	})
+	if err != nil {
+		return
+	}
+	info = objectInfoFromHeader(objectName, resp.Header, "x-upyun-")
+	body = newCtxReadCloser(ctx, resp.Body)
+	return
+}
+
+func (client *UpYunOss) GetObjectRange(objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	return client.GetObjectRangeCtx(context.Background(), objectName, offset, length)
+}
+
+// GetObjectRangeCtx 读取对象的指定范围
+func (client *UpYunOss) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (body io.ReadCloser, err error) {
+	defer func() { err = upyunError("GetObjectRange", objectName, err) }()
+	var rng string
+	rng, err = rangeHeader(offset, length)
+	if err != nil {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var resp *http.Response
+	resp, err = client.Client.GetRequest(&upyun.GetRequestConfig{
+		Path:    objectName,
+		Headers: map[string]string{"x-upyun-folder": "false", "Range": rng},
+	})
+	if err != nil {
+		return
+	}
+	body = newCtxReadCloser(ctx, resp.Body)
+	return
+}
+
+func (client *UpYunOss) StatObject(objectName string) (info ossmod.ObjectInfo, err error) {
+	return client.StatObjectCtx(context.Background(), objectName)
+}
+
+// StatObjectCtx 获取对象信息，又拍云的ETag为文件的MD5
+func (client *UpYunOss) StatObjectCtx(ctx context.Context, objectName string) (info ossmod.ObjectInfo, err error) {
+	defer func() { err = upyunError("StatObject", objectName, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	var fi *upyun.FileInfo
+	fi, err = client.Client.GetInfo(objectName)
+	if err != nil {
+		return
+	}
+	info = ossmod.ObjectInfo{
+		Key:           objectName,
+		Size:          fi.Size,
+		ETag:          fi.MD5,
+		LastModified:  fi.Time,
+		ContentType:   fi.ContentType,
+		ContentLength: fi.Size,
+	}
+	meta := make(map[string]string)
+	for k, v := range fi.Meta {
+		meta[strings.TrimPrefix(k, "x-upyun-meta-")] = v
+	}
+	info.Metadata = userMeta(meta)
+	return
+}
+
+func (client *UpYunOss) CopyObject(src, dst string, opts ossmod.CopyOptions) (err error) {
+	return client.CopyObjectCtx(context.Background(), src, dst, opts)
+}
+
+// CopyObjectCtx 复制对象，又拍云只支持同一服务内的复制，源为其他服务时使用相同的操作员与接口地址下载后重新上传
+func (client *UpYunOss) CopyObjectCtx(ctx context.Context, src, dst string, opts ossmod.CopyOptions) (err error) {
+	defer func() { err = upyunError("CopyObject", src, err) }()
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if opts.SrcBucket != "" && opts.SrcBucket != client.Bucket {
+		from := newUpYunOss(client.Operator, client.Password, opts.SrcBucket, client.Client.UseHTTP)
+		from.Client.Hosts = client.Client.Hosts
+		err = streamCopy(ctx, from, client, src, dst)
+		return
+	}
+	err = client.Client.Copy(&upyun.CopyObjectConfig{SrcPath: src, DestPath: dst})
+	return
+}
+
+func (client *UpYunOss) MoveObject(src, dst string) (err error) {
+	return client.MoveObjectCtx(context.Background(), src, dst)
+}
+
+// MoveObjectCtx 使用又拍云原生的移动接口
+func (client *UpYunOss) MoveObjectCtx(ctx context.Context, src, dst string) (err error) {
+	defer func() { err = upyunError("MoveObject", src, err) }()
+	if src == dst {
+		return
+	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = client.Client.Move(&upyun.MoveObjectConfig{SrcPath: src, DestPath: dst})
+	return
+}
+
+// PresignGet
+/**
+ *  @Description: 生成带token防盗链签名的下载URL，需要配置Domain与Secret
+ *  @receiver client
+ *  @param objectName
+ *  @param ttl 有效期
+ *  @return signedURL
+ *  @return err
+ */
+func (client *UpYunOss) PresignGet(objectName string, ttl time.Duration) (signedURL string, err error) {
+	defer func() { err = upyunError("PresignGet", objectName, err) }()
+	if client.Domain == "" || client.Secret == "" {
+		err = kindError("upyun", "PresignGet", objectName, ErrNotSupported)
+		return
+	}
+	var seconds int64
+	seconds, err = presignSeconds(ttl)
+	if err != nil {
+		return
+	}
+	etime := strconv.FormatInt(time.Now().Unix()+seconds, 10)
+	uri := "/" + strings.TrimPrefix(objectName, "/")
+	sum := md5.Sum([]byte(client.Secret + "&" + etime + "&" + uri))
+	upt := hex.EncodeToString(sum[:])[12:20] + etime
+	signedURL = strings.TrimSuffix(client.Domain, "/") + (&url.URL{Path: uri}).EscapedPath() + "?_upt=" + upt
+	return
+}
+
+// PresignPut 又拍云的REST接口仅支持请求头签名，不支持预签名的上传URL
+func (client *UpYunOss) PresignPut(objectName string, ttl time.Duration, contentType string) (signedURL string, err error) {
+	err = kindError("upyun", "PresignPut", objectName, ErrNotSupported)
+	return
+}
+
+// upyunPutHeaders 将上传参数转换为又拍云的请求头，又拍云不支持指定存储类型
+func upyunPutHeaders(opts ossmod.PutOptions) map[string]string {
+	headers := make(map[string]string)
+	for name, value := range map[string]string{
+		"Content-Type":        opts.ContentType,
+		"Content-Disposition": opts.ContentDisposition,
+		"Cache-Control":       opts.CacheControl,
+		"Content-Encoding":    opts.ContentEncoding,
+	} {
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	for k, v := range opts.Metadata {
+		headers["x-upyun-meta-"+k] = v
+	}
+	return headers
+}
+
+// upyunError 将又拍云SDK的错误转换为*Error
+func upyunError(op, key string, err error) error {
+	var ue *upyun.Error
+	if errors.As(err, &ue) {
+		return newError("upyun", op, key, ue.StatusCode, strconv.Itoa(ue.Code), ue.RequestID, err)
+	}
+	return newError("upyun", op, key, 0, "", "", err)
+}
@@ -0,0 +1,333 @@
+/**
+ * @Time    :2026/10/18 11:03
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package oss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizedReader
+/**
+ *  @Description: 部分SDK上传时必须指定长度，size未知（小于0）时将数据读入内存以获得长度
+ *  @param r
+ *  @param size
+ *  @return io.Reader
+ *  @return int64
+ *  @return error
+ */
+func sizedReader(r io.Reader, size int64) (io.Reader, int64, error) {
+	if size >= 0 {
+		return r, size, nil
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &buf, n, nil
+}
+
+// presignSeconds 将预签名URL的有效期转换为秒，不足一秒按一秒计
+func presignSeconds(ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		return 0, fmt.Errorf("invalid presign ttl %s", ttl)
+	}
+	return int64((ttl + time.Second - 1) / time.Second), nil
+}
+
+// rangeHeader
+/**
+ *  @Description: 生成Range请求头
+ *  @param offset 起始位置
+ *  @param length 读取的长度，为-1时读取到对象末尾
+ *  @return string 如 "bytes=0-99"
+ *  @return error 参数无效时可通过errors.Is匹配ErrInvalidRange
+ */
+func rangeHeader(offset, length int64) (string, error) {
+	if offset < 0 || length == 0 || length < -1 {
+		return "", fmt.Errorf("offset %d, length %d: %w", offset, length, ErrInvalidRange)
+	}
+	if length < 0 {
+		return fmt.Sprintf("bytes=%d-", offset), nil
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1), nil
+}
+
+// copySource 返回复制的源存储桶，未指定时为当前存储桶
+func copySource(opts ossmod.CopyOptions, bucket string) string {
+	if opts.SrcBucket == "" {
+		return bucket
+	}
+	return opts.SrcBucket
+}
+
+// moveObject 先复制再删除源对象，用于没有原生移动接口的服务商；src与dst相同时不做任何操作
+func moveObject(ctx context.Context, client ClientI, src, dst string) (err error) {
+	if src == dst {
+		return
+	}
+	err = client.CopyObjectCtx(ctx, src, dst, ossmod.CopyOptions{})
+	if err != nil {
+		return
+	}
+	err = client.RemoveObjectCtx(ctx, src)
+	return
+}
+
+// maxDeleteKeys 单次批量删除的最大对象数，与各服务商的上限一致
+const maxDeleteKeys = 1000
+
+// removeBatches
+/**
+ *  @Description: 将keys按maxDeleteKeys分批删除，合并各批删除失败的对象，对象不存在不视为失败
+ *  @param ctx ctx结束后剩余的对象均以ctx的错误记为失败
+ *  @param keys
+ *  @param remove 删除一批对象，返回删除失败的对象；err不为nil时整批视为失败
+ *  @return failed 全部删除成功时为nil
+ */
+func removeBatches(ctx context.Context, keys []string, remove func(batch []string) (failed map[string]error, err error)) (failed map[string]error) {
+	fail := func(key string, err error) {
+		if errors.Is(err, ErrObjectNotFound) {
+			return
+		}
+		if failed == nil {
+			failed = make(map[string]error)
+		}
+		failed[key] = err
+	}
+	for start := 0; start < len(keys); start += maxDeleteKeys {
+		end := start + maxDeleteKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		if err := ctx.Err(); err != nil {
+			for _, key := range keys[start:] {
+				fail(key, err)
+			}
+			return
+		}
+		errs, err := remove(batch)
+		if err != nil {
+			for _, key := range batch {
+				fail(key, err)
+			}
+			continue
+		}
+		for key, e := range errs {
+			fail(key, e)
+		}
+	}
+	return
+}
+
+// removeEach 逐个删除对象，用于没有批量删除接口的实现
+func removeEach(ctx context.Context, client ClientI, keys []string) (failed map[string]error) {
+	return removeBatches(ctx, keys, func(batch []string) (failed map[string]error, err error) {
+		for _, key := range batch {
+			if e := client.RemoveObjectCtx(ctx, key); e != nil {
+				if failed == nil {
+					failed = make(map[string]error)
+				}
+				failed[key] = e
+			}
+		}
+		return
+	})
+}
+
+// deleteError 构造批量删除中单个对象的错误
+func deleteError(provider, key, code, message string) error {
+	return newError(provider, "RemoveObjects", key, 0, code, "", fmt.Errorf("%s: %s", code, message))
+}
+
+// removePrefix
+/**
+ *  @Description: 分页列举并删除以prefix开头的全部对象，为避免误删整个存储桶，prefix不能为空
+ *  @param ctx
+ *  @param client
+ *  @param prefix
+ *  @return err 部分对象删除失败时返回其中对象名最小的错误
+ */
+func removePrefix(ctx context.Context, client ClientI, prefix string) (err error) {
+	if prefix == "" {
+		err = errors.New("remove prefix: empty prefix")
+		return
+	}
+	var failed map[string]error
+	keys := make([]string, 0, maxDeleteKeys)
+	flush := func() {
+		for key, e := range client.RemoveObjectsCtx(ctx, keys) {
+			if failed == nil {
+				failed = make(map[string]error)
+			}
+			failed[key] = e
+		}
+		keys = keys[:0]
+	}
+	it := client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: prefix})
+	for it.Next() {
+		keys = append(keys, it.Object().Key)
+		if len(keys) == maxDeleteKeys {
+			flush()
+		}
+	}
+	if err = it.Err(); err != nil {
+		return
+	}
+	flush()
+	err = firstFailure(failed)
+	return
+}
+
+// firstFailure 返回对象名最小的失败对象的错误，failed为空时返回nil
+func firstFailure(failed map[string]error) error {
+	first := ""
+	for key := range failed {
+		if first == "" || key < first {
+			first = key
+		}
+	}
+	if first == "" {
+		return nil
+	}
+	if len(failed) == 1 {
+		return failed[first]
+	}
+	return fmt.Errorf("%d objects were not removed, first %s: %w", len(failed), first, failed[first])
+}
+
+// copyPutOptions 复制对象时沿用源对象的元数据，存储类型的取值因服务商而异，不沿用
+func copyPutOptions(info ossmod.ObjectInfo) ossmod.PutOptions {
+	return ossmod.PutOptions{
+		ContentType:        info.ContentType,
+		ContentDisposition: info.ContentDisposition,
+		CacheControl:       info.CacheControl,
+		ContentEncoding:    info.ContentEncoding,
+		Metadata:           info.Metadata,
+	}
+}
+
+// streamCopy 无法在服务端复制时，从from下载源对象后上传到to
+func streamCopy(ctx context.Context, from, to ClientI, src, dst string) (err error) {
+	var body io.ReadCloser
+	var info ossmod.ObjectInfo
+	body, info, err = from.GetObjectStreamCtx(ctx, src)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	err = to.PutObjectStreamCtx(ctx, dst, body, info.Size, copyPutOptions(info))
+	return
+}
+
+// trimETag 去除ETag两侧的引号
+func trimETag(etag string) string {
+	return strings.Trim(etag, "\"")
+}
+
+// detectContentType
+/**
+ *  @Description: 补全上传参数中的ContentType，为空时先根据对象名的扩展名推断，无法推断时读取数据的前512字节检测
+ *  @param objectName
+ *  @param r
+ *  @param opts
+ *  @return io.Reader 检测时已读取的数据会重新拼接到返回的Reader前
+ *  @return ossmod.PutOptions
+ *  @return error
+ */
+func detectContentType(objectName string, r io.Reader, opts ossmod.PutOptions) (io.Reader, ossmod.PutOptions, error) {
+	if opts.ContentType != "" {
+		return r, opts, nil
+	}
+	if opts.ContentType = mime.TypeByExtension(path.Ext(objectName)); opts.ContentType != "" {
+		return r, opts, nil
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, opts, err
+	}
+	head = head[:n]
+	opts.ContentType = http.DetectContentType(head)
+	return io.MultiReader(bytes.NewReader(head), r), opts, nil
+}
+
+// putFile 打开本地文件，通过PutObjectStreamCtx上传，ContentType由detectContentType补全
+func putFile(ctx context.Context, client ClientI, objectName, filePath string) (err error) {
+	var file *os.File
+	file, err = os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	var fi os.FileInfo
+	fi, err = file.Stat()
+	if err != nil {
+		return
+	}
+	err = client.PutObjectStreamCtx(ctx, objectName, file, fi.Size(), ossmod.PutOptions{})
+	return
+}
+
+// objectInfoFromHeader
+/**
+ *  @Description: 从HTTP响应头中解析对象信息
+ *  @param key
+ *  @param header
+ *  @param prefix 服务商自定义响应头的前缀，如 "x-oss-"，用于解析用户元数据与版本号
+ *  @return info
+ */
+func objectInfoFromHeader(key string, header http.Header, prefix string) (info ossmod.ObjectInfo) {
+	info.Key = key
+	info.Size, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	info.ContentLength = info.Size
+	info.ETag = trimETag(header.Get("ETag"))
+	info.LastModified, _ = http.ParseTime(header.Get("Last-Modified"))
+	info.ContentType = header.Get("Content-Type")
+	info.ContentDisposition = header.Get("Content-Disposition")
+	info.CacheControl = header.Get("Cache-Control")
+	info.ContentEncoding = header.Get("Content-Encoding")
+	info.Expires, _ = http.ParseTime(header.Get("Expires"))
+	if prefix == "" {
+		return
+	}
+	info.VersionID = header.Get(prefix + "version-id")
+	metaPrefix := strings.ToLower(prefix + "meta-")
+	meta := make(map[string]string)
+	for name, values := range header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, metaPrefix) && len(values) > 0 {
+			meta[strings.TrimPrefix(name, metaPrefix)] = values[0]
+		}
+	}
+	info.Metadata = userMeta(meta)
+	return
+}
+
+// userMeta 将用户元数据的键统一为小写，没有元数据时返回nil
+func userMeta(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(meta))
+	for k, v := range meta {
+		m[strings.ToLower(k)] = v
+	}
+	return m
+}
@@ -0,0 +1,113 @@
+/**
+ * @Time    :2026/10/19 17:20
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"context"
+	"errors"
+	ossmod "github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadCheckpointTarget(t *testing.T) {
+	memory := func(t *testing.T) oss.ClientI { return oss.NewMemoryOss(bucketName()) }
+	bucketA, bucketB := newBucket(t, memory), newBucket(t, memory)
+	filePath, content := multipartFile(t, 1024)
+	cpPath := filepath.Join(t.TempDir(), "upload.cp")
+	opts := ossmod.MultipartOptions{PartSize: 1024, Concurrency: 1, CheckpointFile: cpPath}
+	err := oss.UploadFile(context.Background(), newMultipartClient(t, bucketA, 3), "file.bin", filePath, opts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("UploadFile error = %v, want %v", err, errInjected)
+	}
+	// 断点属于其他存储桶时拒绝续传，且保留断点文件
+	mc := newMultipartClient(t, bucketB, 0)
+	if err = oss.UploadFile(context.Background(), mc, "file.bin", filePath, opts); err == nil {
+		t.Fatal("UploadFile resumed a checkpoint of another bucket")
+	}
+	if mc.parts != 0 {
+		t.Errorf("UploadFile uploaded %d parts to another bucket", mc.parts)
+	}
+	if _, err = os.Stat(cpPath); err != nil {
+		t.Fatalf("checkpoint file removed after a mismatched resume: %v", err)
+	}
+	mc = newMultipartClient(t, bucketA, 0)
+	if err = oss.UploadFile(context.Background(), mc, "file.bin", filePath, opts); err != nil {
+		t.Fatalf("resumed UploadFile: %v", err)
+	}
+	if mc.parts != 3 {
+		t.Errorf("resumed UploadFile uploaded %d parts, want 3", mc.parts)
+	}
+	assertContent(t, bucketA, "file.bin", content)
+}
+
+func TestDownloadCheckpointTarget(t *testing.T) {
+	memory := func(t *testing.T) oss.ClientI { return oss.NewMemoryOss(bucketName()) }
+	bucketA, bucketB := newBucket(t, memory), newBucket(t, memory)
+	content := putMultipartFile(t, bucketA, "file.bin")
+	putMultipartFile(t, bucketB, "file.bin")
+	filePath := filepath.Join(t.TempDir(), "file.bin")
+	opts := ossmod.DownloadOptions{PartSize: 1024, Concurrency: 1}
+	err := oss.DownloadFile(context.Background(), &rangeClient{ClientI: bucketA, failAt: 3}, "file.bin", filePath, opts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("DownloadFile error = %v, want %v", err, errInjected)
+	}
+	// 断点属于其他存储桶时拒绝续传，且保留临时文件与断点文件
+	rc := &rangeClient{ClientI: bucketB}
+	if err = oss.DownloadFile(context.Background(), rc, "file.bin", filePath, opts); err == nil {
+		t.Fatal("DownloadFile resumed a checkpoint of another bucket")
+	}
+	if rc.calls != 0 {
+		t.Errorf("DownloadFile requested %d ranges from another bucket", rc.calls)
+	}
+	for _, p := range []string{filePath + ".download", filePath + ".download.cp"} {
+		if _, err = os.Stat(p); err != nil {
+			t.Fatalf("%s removed after a mismatched resume: %v", p, err)
+		}
+	}
+	rc = &rangeClient{ClientI: bucketA}
+	if err = oss.DownloadFile(context.Background(), rc, "file.bin", filePath, opts); err != nil {
+		t.Fatalf("resumed DownloadFile: %v", err)
+	}
+	if rc.calls != 3 {
+		t.Errorf("resumed DownloadFile requested %d ranges, want 3", rc.calls)
+	}
+	assertFile(t, filePath, content)
+}
+
+func TestCheckpointSuffix(t *testing.T) {
+	client := newBucket(t, func(t *testing.T) oss.ClientI { return oss.NewMemoryOss(bucketName()) })
+	filePath, content := multipartFile(t, 1024)
+	putMultipartFile(t, client, "download.bin")
+	// 同一本地文件的上传与下载断点互不覆盖
+	uploadOpts := ossmod.MultipartOptions{PartSize: 1024, Concurrency: 1}
+	err := oss.UploadFile(context.Background(), newMultipartClient(t, client, 3), "upload.bin", filePath, uploadOpts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("UploadFile error = %v, want %v", err, errInjected)
+	}
+	downloadOpts := ossmod.DownloadOptions{PartSize: 1024, Concurrency: 1}
+	err = oss.DownloadFile(context.Background(), &rangeClient{ClientI: client, failAt: 3}, "download.bin", filePath, downloadOpts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("DownloadFile error = %v, want %v", err, errInjected)
+	}
+	mc := newMultipartClient(t, client, 0)
+	if err = oss.UploadFile(context.Background(), mc, "upload.bin", filePath, uploadOpts); err != nil {
+		t.Fatalf("resumed UploadFile: %v", err)
+	}
+	if mc.parts != 3 {
+		t.Errorf("resumed UploadFile uploaded %d parts, want 3", mc.parts)
+	}
+	assertContent(t, client, "upload.bin", content)
+	rc := &rangeClient{ClientI: client}
+	if err = oss.DownloadFile(context.Background(), rc, "download.bin", filePath, downloadOpts); err != nil {
+		t.Fatalf("resumed DownloadFile: %v", err)
+	}
+	if rc.calls != 3 {
+		t.Errorf("resumed DownloadFile requested %d ranges, want 3", rc.calls)
+	}
+}
@@ -0,0 +1,190 @@
+/**
+ * @Time    :2026/10/18 20:50
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clearConfigEnv 清除可能影响测试的环境变量
+func clearConfigEnv(t *testing.T) {
+	for _, name := range []string{oss.EnvProvider, oss.EnvEndpoint, oss.EnvRegion, oss.EnvBucket,
+		oss.EnvAccessKey, oss.EnvSecretKey, oss.EnvTimeout, oss.EnvUseSSL} {
+		if v, ok := os.LookupEnv(name); ok {
+			os.Unsetenv(name)
+			t.Cleanup(func() { os.Setenv(name, v) })
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	clearConfigEnv(t)
+	want := oss.Config{
+		Provider:  "minio",
+		Endpoint:  "127.0.0.1:9000",
+		Bucket:    "photos",
+		AccessKey: "ak",
+		SecretKey: "sk",
+		Timeout:   30,
+		UseSSL:    true,
+	}
+	files := map[string]string{
+		"oss.yaml": "provider: minio\nendpoint: 127.0.0.1:9000\nbucket: photos\naccess_key: ak\nsecret_key: sk\ntimeout: 30\nuse_ssl: true\n",
+		"oss.json": `{"provider": "minio", "endpoint": "127.0.0.1:9000", "bucket": "photos", "access_key": "ak", "secret_key": "sk", "timeout": 30, "use_ssl": true}`,
+		"oss.toml": "provider = \"minio\"\nendpoint = \"127.0.0.1:9000\"\nbucket = \"photos\"\naccess_key = \"ak\"\nsecret_key = \"sk\"\ntimeout = 30\nuse_ssl = true\n",
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := oss.LoadConfig(filePath)
+		if err != nil {
+			t.Fatalf("LoadConfig(%s): %v", name, err)
+		}
+		if cfg != want {
+			t.Errorf("LoadConfig(%s) = %+v, want %+v", name, cfg, want)
+		}
+	}
+	filePath := filepath.Join(dir, "oss.ini")
+	if err := ioutil.WriteFile(filePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oss.LoadConfig(filePath); err == nil {
+		t.Errorf("LoadConfig(oss.ini): want error")
+	}
+
+	// 环境变量覆盖文件中的配置
+	t.Setenv(oss.EnvBucket, "videos")
+	t.Setenv(oss.EnvUseSSL, "false")
+	cfg, err := oss.LoadConfig(filepath.Join(dir, "oss.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig with env: %v", err)
+	}
+	if cfg.Bucket != "videos" || cfg.UseSSL || cfg.AccessKey != "ak" {
+		t.Errorf("LoadConfig with env = %+v", cfg)
+	}
+	// 使用其他前缀时OSS_开头的环境变量不生效
+	t.Setenv("OSS_DST_BUCKET", "backup")
+	cfg, err = oss.LoadConfigWithEnvPrefix(filepath.Join(dir, "oss.yaml"), "OSS_DST_")
+	if err != nil {
+		t.Fatalf("LoadConfigWithEnvPrefix: %v", err)
+	}
+	if cfg.Bucket != "backup" || !cfg.UseSSL {
+		t.Errorf("LoadConfigWithEnvPrefix = %+v", cfg)
+	}
+	t.Setenv(oss.EnvTimeout, "soon")
+	if _, err = oss.ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), oss.EnvTimeout) {
+		t.Errorf("ConfigFromEnv with invalid %s: err = %v", oss.EnvTimeout, err)
+	}
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	clearConfigEnv(t)
+	tests := []struct {
+		cfg     oss.Config
+		wantErr string
+	}{
+		{oss.Config{}, "missing provider"},
+		{oss.Config{Provider: "ftp"}, `unknown provider "ftp"`},
+		{oss.Config{Provider: "aliyun", Endpoint: "oss-cn-hangzhou.aliyuncs.com", AccessKey: "ak"}, "provider aliyun requires secret_key, bucket"},
+		{oss.Config{Provider: "tencent", AccessKey: "ak", SecretKey: "sk"}, "provider tencent requires endpoint"},
+		{oss.Config{Provider: "local", Bucket: "b"}, "provider local requires endpoint"},
+		{oss.Config{Provider: "memory", Timeout: -1}, "timeout"},
+		{oss.Config{Provider: "memory", Timeout: 10}, "provider memory does not support timeout"},
+		{oss.Config{Provider: "local", Endpoint: "/tmp", Bucket: "b", UseSSL: true}, "provider local does not support timeout or use_ssl"},
+		{oss.Config{Provider: "baidu", Endpoint: "http://bj.bcebos.com", Bucket: "b", AccessKey: "ak", SecretKey: "sk", UseSSL: true}, "use_ssl conflicts"},
+	}
+	for _, tt := range tests {
+		client, err := oss.NewClientFromConfig(tt.cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) || client != nil {
+			t.Errorf("NewClientFromConfig(%+v) = %v, %v, want error containing %q", tt.cfg, client, err, tt.wantErr)
+		}
+	}
+
+	client, err := oss.NewClientFromConfig(oss.Config{Provider: "local", Endpoint: t.TempDir(), Bucket: "b"})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig(local): %v", err)
+	}
+	if _, ok := client.(*oss.LocalFsOss); !ok {
+		t.Errorf("NewClientFromConfig(local) = %T", client)
+	}
+
+	// 不含协议的Endpoint按UseSSL补充协议
+	client, err = oss.NewClientFromConfig(oss.Config{Provider: "aliyun", Endpoint: "oss-cn-hangzhou.aliyuncs.com",
+		Bucket: "b", AccessKey: "ak", SecretKey: "sk", Timeout: 5, UseSSL: true})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig(aliyun): %v", err)
+	}
+	if c := client.(*oss.ALiYunOss); c.Endpoint != "https://oss-cn-hangzhou.aliyuncs.com" || c.Client.Config.HTTPTimeout.ConnectTimeout != 5*time.Second {
+		t.Errorf("NewClientFromConfig(aliyun) endpoint = %s, timeout = %v", c.Endpoint, c.Client.Config.HTTPTimeout.ConnectTimeout)
+	}
+	client, err = oss.NewClientFromConfig(oss.Config{Provider: "baidu", Endpoint: "bj.bcebos.com",
+		Bucket: "b", AccessKey: "ak", SecretKey: "sk", Timeout: 5})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig(baidu): %v", err)
+	}
+	if c := client.(*oss.BaiduCloudBos); c.Endpoint != "http://bj.bcebos.com" || c.Client.Config.ConnectionTimeoutInMillis != 5000 {
+		t.Errorf("NewClientFromConfig(baidu) endpoint = %s, timeout = %d", c.Endpoint, c.Client.Config.ConnectionTimeoutInMillis)
+	}
+
+	t.Setenv(oss.EnvBucket, "from-env")
+	client, err = oss.NewClient("memory")
+	if err != nil {
+		t.Fatalf("NewClient(memory): %v", err)
+	}
+	if m, ok := client.(*oss.MemoryOss); !ok || m.Bucket != "from-env" {
+		t.Errorf("NewClient(memory) = %#v", client)
+	}
+}
+
+// fakeConfig 注册的测试服务商收到的配置，同一进程中只能注册一次
+var (
+	registerFake sync.Once
+	fakeConfig   oss.Config
+)
+
+func TestRegister(t *testing.T) {
+	clearConfigEnv(t)
+	registerFake.Do(func() {
+		oss.Register("osstest-fake", func(cfg oss.Config) (oss.ClientI, error) {
+			fakeConfig = cfg
+			return oss.NewMemoryOss(cfg.Bucket), nil
+		})
+	})
+	providers := strings.Join(oss.Providers(), ",")
+	for _, name := range []string{"aliyun", "baidu", "huawei", "local", "memory", "minio", "osstest-fake", "qiniu", "tencent", "upyun"} {
+		if !strings.Contains(providers, name) {
+			t.Errorf("Providers() = %s, missing %s", providers, name)
+		}
+	}
+	// 第三方服务商的字段由factory自行校验
+	cfg := oss.Config{Provider: "osstest-fake", Bucket: "b", Region: "r"}
+	client, err := oss.NewClientFromConfig(cfg)
+	if err != nil || client == nil || fakeConfig != cfg {
+		t.Errorf("NewClientFromConfig(osstest-fake) = %v, %v, factory got %+v", client, err, fakeConfig)
+	}
+	client, err = oss.NewClient("missing")
+	if err == nil || client != nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("NewClient(missing) = %v, %v, want error", client, err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Register with a duplicate name: want panic")
+			}
+		}()
+		oss.Register("memory", func(oss.Config) (oss.ClientI, error) { return nil, nil })
+	}()
+}
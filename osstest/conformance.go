@@ -0,0 +1,1019 @@
+/**
+ * @Time    :2026/10/18 14:30
+ * @Author  :Xiaoyu.Zhang
+ */
+
+// Package osstest 提供oss.ClientI实现的一致性测试，用于发现不同存储服务之间的行为差异
+package osstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// presignTransport 请求预签名URL时使用的Transport，为nil时使用http.DefaultTransport
+var presignTransport http.RoundTripper
+
+// Factory 返回一个客户端，其存储桶在每次调用时都应是全新且尚未创建的
+type Factory func(t *testing.T) oss.ClientI
+
+// RunConformance
+/**
+ *  @Description: 对factory创建的客户端执行全部一致性测试
+ *  @param t
+ *  @param factory
+ */
+func RunConformance(t *testing.T, factory Factory) {
+	RunConformanceExcept(t, factory, nil)
+}
+
+// RunConformanceExcept
+/**
+ *  @Description: 对factory创建的客户端执行一致性测试，跳过服务商本身不支持的测试
+ *  @param t
+ *  @param factory
+ *  @param skip 测试名到跳过原因的映射
+ */
+func RunConformanceExcept(t *testing.T, factory Factory, skip map[string]string) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"BucketLifecycle", func(t *testing.T) { testBucketLifecycle(t, factory(t)) }},
+		{"PutGetObject", func(t *testing.T) { testPutGetObject(t, newBucket(t, factory)) }},
+		{"PutGetObjectStream", func(t *testing.T) { testPutGetObjectStream(t, newBucket(t, factory)) }},
+		{"ObjectExist", func(t *testing.T) { testObjectExist(t, newBucket(t, factory)) }},
+		{"ListObjects", func(t *testing.T) { testListObjects(t, newBucket(t, factory)) }},
+		{"ListObjectsPrefix", func(t *testing.T) { testListObjectsPrefix(t, newBucket(t, factory)) }},
+		{"ListObjectsIter", func(t *testing.T) { testListObjectsIter(t, newBucket(t, factory)) }},
+		{"ListDir", func(t *testing.T) { testListDir(t, newBucket(t, factory)) }},
+		{"RemoveObject", func(t *testing.T) { testRemoveObject(t, newBucket(t, factory)) }},
+		{"Canceled", func(t *testing.T) { testCanceled(t, newBucket(t, factory)) }},
+		{"Errors", func(t *testing.T) { testErrors(t, newBucket(t, factory)) }},
+		{"StatObject", func(t *testing.T) { testStatObject(t, newBucket(t, factory)) }},
+		{"PutOptions", func(t *testing.T) { testPutOptions(t, newBucket(t, factory)) }},
+		{"DetectContentType", func(t *testing.T) { testDetectContentType(t, newBucket(t, factory)) }},
+		{"Presign", func(t *testing.T) { testPresign(t, newBucket(t, factory)) }},
+		{"Multipart", func(t *testing.T) { testMultipart(t, newBucket(t, factory)) }},
+		{"MultipartResume", func(t *testing.T) { testMultipartResume(t, newBucket(t, factory)) }},
+		{"MultipartAbort", func(t *testing.T) { testMultipartAbort(t, newBucket(t, factory)) }},
+		{"GetObjectRange", func(t *testing.T) { testGetObjectRange(t, newBucket(t, factory)) }},
+		{"DownloadFile", func(t *testing.T) { testDownloadFile(t, newBucket(t, factory)) }},
+		{"DownloadFileResume", func(t *testing.T) { testDownloadFileResume(t, newBucket(t, factory)) }},
+		{"CopyObject", func(t *testing.T) { testCopyObject(t, newBucket(t, factory)) }},
+		{"CopyObjectCrossBucket", func(t *testing.T) { testCopyObjectCrossBucket(t, newBucket(t, factory), newBucket(t, factory)) }},
+		{"MoveObject", func(t *testing.T) { testMoveObject(t, newBucket(t, factory)) }},
+		{"RemoveObjects", func(t *testing.T) { testRemoveObjects(t, newBucket(t, factory)) }},
+		{"RemovePrefix", func(t *testing.T) { testRemovePrefix(t, newBucket(t, factory)) }},
+	}
+	for _, test := range tests {
+		run := test.run
+		if reason, ok := skip[test.name]; ok {
+			run = func(t *testing.T) { t.Skip(reason) }
+		}
+		t.Run(test.name, run)
+	}
+}
+
+// newBucket 创建存储桶，并在测试结束时清空并删除
+func newBucket(t *testing.T, factory Factory) oss.ClientI {
+	t.Helper()
+	client := factory(t)
+	if err := client.NewBucket(); err != nil {
+		t.Fatalf("NewBucket: %v", err)
+	}
+	t.Cleanup(func() {
+		objects, err := client.ListObjects("", "")
+		if err != nil {
+			t.Errorf("cleanup ListObjects: %v", err)
+			return
+		}
+		for _, o := range objects {
+			if err = client.RemoveObject(o.Key); err != nil {
+				t.Errorf("cleanup RemoveObject(%s): %v", o.Key, err)
+			}
+		}
+		if err = client.RemoveBucket(); err != nil {
+			t.Errorf("cleanup RemoveBucket: %v", err)
+		}
+	})
+	return client
+}
+
+// putString 上传字符串内容
+func putString(t *testing.T, client oss.ClientI, key, content string) {
+	t.Helper()
+	err := client.PutObjectStream(key, strings.NewReader(content), int64(len(content)), ossmod.PutOptions{})
+	if err != nil {
+		t.Fatalf("PutObjectStream(%s): %v", key, err)
+	}
+}
+
+// keysOf 提取对象名
+func keysOf(objects []ossmod.ObjectInfo) []string {
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	return keys
+}
+
+func assertKeys(t *testing.T, name string, got []ossmod.ObjectInfo, want ...string) {
+	t.Helper()
+	if fmt.Sprint(keysOf(got)) != fmt.Sprint(want) {
+		t.Errorf("%s = %v, want %v", name, keysOf(got), want)
+	}
+}
+
+// assertErrorIs 检查err可以通过errors.Is匹配target，且包装为*oss.Error
+func assertErrorIs(t *testing.T, name string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s error = %v, want %v", name, err, target)
+		return
+	}
+	var ossErr *oss.Error
+	if !errors.As(err, &ossErr) || ossErr.Provider == "" || ossErr.Op == "" {
+		t.Errorf("%s error = %#v, want a *oss.Error with provider and operation", name, err)
+	}
+}
+
+func testBucketLifecycle(t *testing.T, client oss.ClientI) {
+	exist, err := client.BucketExist()
+	if err != nil || exist {
+		t.Fatalf("BucketExist before NewBucket = %v, %v; want false, nil", exist, err)
+	}
+	if err = client.NewBucket(); err != nil {
+		t.Fatalf("NewBucket: %v", err)
+	}
+	exist, err = client.BucketExist()
+	if err != nil || !exist {
+		t.Fatalf("BucketExist after NewBucket = %v, %v; want true, nil", exist, err)
+	}
+	putString(t, client, "object", "data")
+	err = client.RemoveBucket()
+	assertErrorIs(t, "RemoveBucket on a non-empty bucket", err, oss.ErrBucketNotEmpty)
+	if err != nil && strings.Count(err.Error(), "bucket not empty") > 1 {
+		t.Errorf("RemoveBucket error repeats its kind: %v", err)
+	}
+	if err = client.RemoveObject("object"); err != nil {
+		t.Fatalf("RemoveObject: %v", err)
+	}
+	if err = client.RemoveBucket(); err != nil {
+		t.Fatalf("RemoveBucket: %v", err)
+	}
+	exist, err = client.BucketExist()
+	if err != nil || exist {
+		t.Fatalf("BucketExist after RemoveBucket = %v, %v; want false, nil", exist, err)
+	}
+	_, err = client.ListObjects("", "")
+	assertErrorIs(t, "ListObjects on a missing bucket", err, oss.ErrBucketNotFound)
+}
+
+func testPutGetObject(t *testing.T, client oss.ClientI) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	content := []byte("hello go-oss-client")
+	if err := ioutil.WriteFile(src, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PutObject("dir/file.txt", src); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	dst := filepath.Join(dir, "dst.txt")
+	if err := client.GetObject("dir/file.txt", dst); err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	got, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("GetObject content = %q, want %q", got, content)
+	}
+	if err = client.GetObject("dir/missing.txt", filepath.Join(dir, "missing.txt")); err == nil {
+		t.Fatal("GetObject on a missing key succeeded")
+	}
+}
+
+func testPutGetObjectStream(t *testing.T, client oss.ClientI) {
+	content := strings.Repeat("0123456789", 1000)
+	putString(t, client, "stream", content)
+	// 长度未知
+	err := client.PutObjectStream("stream-unsized", strings.NewReader(content), -1, ossmod.PutOptions{ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("PutObjectStream with unknown size: %v", err)
+	}
+	for _, key := range []string{"stream", "stream-unsized"} {
+		body, info, err := client.GetObjectStream(key)
+		if err != nil {
+			t.Fatalf("GetObjectStream(%s): %v", key, err)
+		}
+		got, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", key, err)
+		}
+		if string(got) != content {
+			t.Errorf("GetObjectStream(%s) content mismatch: got %d bytes", key, len(got))
+		}
+		if info.Key != key || info.Size != int64(len(content)) || info.ETag == "" {
+			t.Errorf("GetObjectStream(%s) info = %+v", key, info)
+		}
+	}
+	if _, _, err = client.GetObjectStream("missing"); err == nil {
+		t.Fatal("GetObjectStream on a missing key succeeded")
+	}
+}
+
+func testObjectExist(t *testing.T, client oss.ClientI) {
+	exist, err := client.ObjectExist("missing")
+	if err != nil || exist {
+		t.Fatalf("ObjectExist on a missing key = %v, %v; want false, nil", exist, err)
+	}
+	putString(t, client, "present", "x")
+	exist, err = client.ObjectExist("present")
+	if err != nil || !exist {
+		t.Fatalf("ObjectExist on an existing key = %v, %v; want true, nil", exist, err)
+	}
+}
+
+func testListObjects(t *testing.T, client oss.ClientI) {
+	keys := []string{"a1", "a2", "a3", "b1", "b2", "c1", "c2"}
+	// 逆序上传，确保结果顺序不依赖写入顺序
+	for i := len(keys) - 1; i >= 0; i-- {
+		putString(t, client, keys[i], keys[i])
+	}
+	objects, err := client.ListObjects("", "")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"\", \"\")", objects, keys...)
+	for _, o := range objects {
+		if o.Size != int64(len(o.Key)) || o.ETag == "" || o.LastModified.IsZero() {
+			t.Errorf("ListObjects entry = %+v", o)
+		}
+		// 列举与StatObject返回的ETag格式一致，Sync依赖此比较对象是否变化
+		if info, err := client.StatObject(o.Key); err != nil || info.ETag != o.ETag {
+			t.Errorf("ListObjects ETag of %s = %q, StatObject = %q, %v", o.Key, o.ETag, info.ETag, err)
+		}
+	}
+	// startAfter不包含自身
+	objects, err = client.ListObjects("", "b1")
+	if err != nil {
+		t.Fatalf("ListObjects startAfter: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"\", \"b1\")", objects, "b2", "c1", "c2")
+	// startAfter可以不是已存在的对象
+	objects, err = client.ListObjects("", "a9")
+	if err != nil {
+		t.Fatalf("ListObjects startAfter: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"\", \"a9\")", objects, "b1", "b2", "c1", "c2")
+	objects, err = client.ListObjects("a", "a1")
+	if err != nil {
+		t.Fatalf("ListObjects prefix and startAfter: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"a\", \"a1\")", objects, "a2", "a3")
+	objects, err = client.ListObjects("", "c2")
+	if err != nil {
+		t.Fatalf("ListObjects startAfter last key: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"\", \"c2\")", objects)
+}
+
+func testListObjectsPrefix(t *testing.T, client oss.ClientI) {
+	for _, key := range []string{"dir/a", "dir/sub/b", "dir/sub/c", "dir2/d", "e"} {
+		putString(t, client, key, key)
+	}
+	objects, err := client.ListObjects("dir/", "")
+	if err != nil {
+		t.Fatalf("ListObjects prefix: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"dir/\", \"\")", objects, "dir/a", "dir/sub/b", "dir/sub/c")
+	objects, err = client.ListObjects("missing/", "")
+	if err != nil {
+		t.Fatalf("ListObjects missing prefix: %v", err)
+	}
+	assertKeys(t, "ListObjects(\"missing/\", \"\")", objects)
+}
+
+// iterKeys 遍历迭代器，最多取n个对象，n为-1时遍历到结束
+func iterKeys(t *testing.T, it oss.ObjectIterator, n int) []string {
+	t.Helper()
+	keys := []string{}
+	for n != 0 && it.Next() {
+		keys = append(keys, it.Object().Key)
+		n--
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("ObjectIterator: %v", err)
+	}
+	return keys
+}
+
+func testListObjectsIter(t *testing.T, client oss.ClientI) {
+	for _, key := range []string{"it/a", "it/b", "it/c", "it/d", "it/e", "it2", "other"} {
+		putString(t, client, key, key)
+	}
+	ctx := context.Background()
+	assertIter := func(name string, got []string, want ...string) {
+		t.Helper()
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	// 每页2个对象，强制多次分页
+	opts := ossmod.ListOptions{Prefix: "it/", MaxKeys: 2}
+	assertIter("ListObjectsIter", iterKeys(t, client.ListObjectsIter(ctx, opts), -1), "it/a", "it/b", "it/c", "it/d", "it/e")
+	opts.StartAfter = "it/b"
+	assertIter("ListObjectsIter StartAfter", iterKeys(t, client.ListObjectsIter(ctx, opts), -1), "it/c", "it/d", "it/e")
+	// 中断后使用PageToken从当前对象之后继续
+	opts.StartAfter = ""
+	it := client.ListObjectsIter(ctx, opts)
+	assertIter("ListObjectsIter before PageToken", iterKeys(t, it, 3), "it/a", "it/b", "it/c")
+	opts.PageToken = it.PageToken()
+	assertIter("ListObjectsIter PageToken", iterKeys(t, client.ListObjectsIter(ctx, opts), -1), "it/d", "it/e")
+	assertIter("ListObjectsIter missing prefix", iterKeys(t, client.ListObjectsIter(ctx, ossmod.ListOptions{Prefix: "none/"}), -1))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	it = client.ListObjectsIter(canceled, ossmod.ListOptions{})
+	if it.Next() || !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("ListObjectsIter with canceled context: err = %v, want context.Canceled", it.Err())
+	}
+}
+
+func testListDir(t *testing.T, client oss.ClientI) {
+	for _, key := range []string{"root.txt", "docs/a.txt", "docs/b/c.txt", "docs/b/d.txt", "img/x.png", "img2/y.png"} {
+		putString(t, client, key, key)
+	}
+	// 目录占位对象，又拍云以"/"结尾的路径表示目录，无法保存这样的对象
+	placeholder := client.PutObjectStream("docs/", strings.NewReader(""), 0, ossmod.PutOptions{}) == nil
+	assertDir := func(prefix, delimiter string, wantObjects []string, wantPrefixes ...string) {
+		t.Helper()
+		objects, prefixes, err := client.ListDir(prefix, delimiter)
+		if err != nil {
+			t.Fatalf("ListDir(%q, %q): %v", prefix, delimiter, err)
+		}
+		if fmt.Sprint(keysOf(objects)) != fmt.Sprint(wantObjects) || fmt.Sprint(prefixes) != fmt.Sprint(wantPrefixes) {
+			t.Errorf("ListDir(%q, %q) = %v %v, want %v %v", prefix, delimiter, keysOf(objects), prefixes, wantObjects, wantPrefixes)
+		}
+		for _, o := range objects {
+			if o.IsDir != strings.HasSuffix(o.Key, "/") {
+				t.Errorf("ListDir(%q, %q) entry %s IsDir = %v", prefix, delimiter, o.Key, o.IsDir)
+			}
+		}
+	}
+	assertDir("", "", []string{"root.txt"}, "docs/", "img/", "img2/")
+	if placeholder {
+		assertDir("docs/", "/", []string{"docs/", "docs/a.txt"}, "docs/b/")
+	} else {
+		assertDir("docs/", "/", []string{"docs/a.txt"}, "docs/b/")
+	}
+	assertDir("docs/b/", "", []string{"docs/b/c.txt", "docs/b/d.txt"})
+	assertDir("missing/", "", nil)
+}
+
+func testRemoveObject(t *testing.T, client oss.ClientI) {
+	putString(t, client, "dir/remove", "x")
+	if err := client.RemoveObject("dir/remove"); err != nil {
+		t.Fatalf("RemoveObject: %v", err)
+	}
+	exist, err := client.ObjectExist("dir/remove")
+	if err != nil || exist {
+		t.Fatalf("ObjectExist after RemoveObject = %v, %v; want false, nil", exist, err)
+	}
+	// 删除不存在的对象不视为错误
+	if err = client.RemoveObject("dir/remove"); err != nil {
+		t.Fatalf("RemoveObject on a missing key: %v", err)
+	}
+}
+
+func testCanceled(t *testing.T, client oss.ClientI) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := client.PutObjectStreamCtx(ctx, "canceled", strings.NewReader("x"), 1, ossmod.PutOptions{})
+	if err == nil {
+		t.Error("PutObjectStreamCtx with a canceled context succeeded")
+	}
+	if _, err = client.ListObjectsCtx(ctx, "", ""); err == nil {
+		t.Error("ListObjectsCtx with a canceled context succeeded")
+	}
+	if _, _, err = client.GetObjectStreamCtx(ctx, "canceled"); err == nil {
+		t.Error("GetObjectStreamCtx with a canceled context succeeded")
+	}
+	exist, err := client.ObjectExist("canceled")
+	if err != nil || exist {
+		t.Errorf("object written with a canceled context: exist = %v, err = %v", exist, err)
+	}
+}
+
+func testErrors(t *testing.T, client oss.ClientI) {
+	_, _, err := client.GetObjectStream("missing")
+	assertErrorIs(t, "GetObjectStream on a missing key", err, oss.ErrObjectNotFound)
+	err = client.GetObject("missing", filepath.Join(t.TempDir(), "missing"))
+	assertErrorIs(t, "GetObject on a missing key", err, oss.ErrObjectNotFound)
+	// 本地源文件的错误同样包装为*oss.Error，且不归类为对象不存在
+	missing := filepath.Join(t.TempDir(), "missing")
+	err = client.PutObject("missing", missing)
+	var ossErr *oss.Error
+	if !errors.As(err, &ossErr) || !errors.Is(err, os.ErrNotExist) || errors.Is(err, oss.ErrObjectNotFound) {
+		t.Errorf("PutObject from a missing file error = %#v, want a *oss.Error wrapping os.ErrNotExist", err)
+	}
+	// ctx的错误仍可通过errors.Is匹配
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.ListObjectsCtx(ctx, "", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ListObjectsCtx with a canceled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func testStatObject(t *testing.T, client oss.ClientI) {
+	content := "stat object"
+	err := client.PutObjectStream("dir/stat.txt", strings.NewReader(content), int64(len(content)), ossmod.PutOptions{ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("PutObjectStream: %v", err)
+	}
+	info, err := client.StatObject("dir/stat.txt")
+	if err != nil {
+		t.Fatalf("StatObject: %v", err)
+	}
+	if info.Key != "dir/stat.txt" || info.Size != int64(len(content)) || info.ContentLength != info.Size {
+		t.Errorf("StatObject info = %+v", info)
+	}
+	if info.ETag == "" || info.LastModified.IsZero() {
+		t.Errorf("StatObject info missing ETag or LastModified: %+v", info)
+	}
+	if info.ContentType != "text/plain" {
+		t.Errorf("StatObject ContentType = %q, want %q", info.ContentType, "text/plain")
+	}
+	// 与GetObjectStream返回的信息一致
+	body, streamInfo, err := client.GetObjectStream("dir/stat.txt")
+	if err != nil {
+		t.Fatalf("GetObjectStream: %v", err)
+	}
+	body.Close()
+	if streamInfo.ETag != info.ETag || streamInfo.Size != info.Size {
+		t.Errorf("GetObjectStream info = %+v, StatObject info = %+v", streamInfo, info)
+	}
+	_, err = client.StatObject("dir/missing.txt")
+	assertErrorIs(t, "StatObject on a missing key", err, oss.ErrObjectNotFound)
+}
+
+func testPutOptions(t *testing.T, client oss.ClientI) {
+	content := "report content"
+	opts := ossmod.PutOptions{
+		ContentType:        "application/pdf",
+		ContentDisposition: `attachment; filename="report.pdf"`,
+		CacheControl:       "max-age=3600",
+		ContentEncoding:    "gzip",
+		Metadata:           map[string]string{"Owner": "alice"},
+	}
+	err := client.PutObjectStream("meta/report", strings.NewReader(content), int64(len(content)), opts)
+	if err != nil {
+		t.Fatalf("PutObjectStream: %v", err)
+	}
+	// 复制对象时保留元数据
+	if err = client.CopyObject("meta/report", "meta/copy", ossmod.CopyOptions{}); err != nil {
+		t.Fatalf("CopyObject: %v", err)
+	}
+	for _, key := range []string{"meta/report", "meta/copy"} {
+		info, err := client.StatObject(key)
+		if err != nil {
+			t.Fatalf("StatObject(%s): %v", key, err)
+		}
+		got := ossmod.PutOptions{
+			ContentType:        info.ContentType,
+			ContentDisposition: info.ContentDisposition,
+			CacheControl:       info.CacheControl,
+			ContentEncoding:    info.ContentEncoding,
+			Metadata:           info.Metadata,
+		}
+		want := opts
+		want.Metadata = map[string]string{"owner": "alice"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("StatObject(%s) = %+v, want %+v", key, got, want)
+		}
+		// 修改返回的元数据不影响存储的对象
+		info.Metadata["owner"] = "mallory"
+	}
+	for _, key := range []string{"meta/report", "meta/copy"} {
+		info, err := client.StatObject(key)
+		if err != nil {
+			t.Fatalf("StatObject(%s): %v", key, err)
+		}
+		if info.Metadata["owner"] != "alice" {
+			t.Errorf("StatObject(%s) after modifying a returned Metadata = %v", key, info.Metadata)
+		}
+	}
+}
+
+func testDetectContentType(t *testing.T, client oss.ClientI) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	html := []byte("<!DOCTYPE html><html><body>hello</body></html>")
+	filePath := filepath.Join(t.TempDir(), "data.json")
+	if err := ioutil.WriteFile(filePath, []byte(`{"hello": "world"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PutObject("detect/data.json", filePath); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	for key, data := range map[string][]byte{"detect/photo.png": png, "detect/page": html, "detect/image": png} {
+		if err := client.PutObjectStream(key, bytes.NewReader(data), int64(len(data)), ossmod.PutOptions{}); err != nil {
+			t.Fatalf("PutObjectStream(%s): %v", key, err)
+		}
+	}
+	// 扩展名优先，没有扩展名时根据内容检测
+	for key, want := range map[string]string{
+		"detect/data.json": "application/json",
+		"detect/photo.png": "image/png",
+		"detect/page":      "text/html",
+		"detect/image":     "image/png",
+	} {
+		info, err := client.StatObject(key)
+		if err != nil {
+			t.Fatalf("StatObject(%s): %v", key, err)
+		}
+		if !strings.HasPrefix(info.ContentType, want) {
+			t.Errorf("StatObject(%s) ContentType = %q, want %q", key, info.ContentType, want)
+		}
+	}
+	assertContent(t, client, "detect/page", html)
+}
+
+func testPresign(t *testing.T, client oss.ClientI) {
+	putURL, err := client.PresignPut("presign/file.txt", time.Minute, "text/plain")
+	if errors.Is(err, oss.ErrNotSupported) {
+		t.Skip("presigned upload is not supported")
+	}
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	httpClient := &http.Client{Transport: presignTransport}
+	content := "uploaded with a presigned url"
+	req, err := http.NewRequest(http.MethodPut, putURL, strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("PresignPut returned an invalid url %q: %v", putURL, err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT %s: %v", putURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT %s: %s", putURL, resp.Status)
+	}
+	info, err := client.StatObject("presign/file.txt")
+	if err != nil || info.Size != int64(len(content)) {
+		t.Fatalf("StatObject after presigned upload = %+v, %v", info, err)
+	}
+	getURL, err := client.PresignGet("presign/file.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+	resp, err = httpClient.Get(getURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", getURL, err)
+	}
+	got, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK || string(got) != content {
+		t.Fatalf("GET %s = %s %q, %v", getURL, resp.Status, got, err)
+	}
+	if _, err = client.PresignGet("presign/file.txt", 0); err == nil {
+		t.Error("PresignGet with a zero ttl succeeded")
+	}
+}
+
+// multipartClient 包装MultipartI，记录分片上传的调用，并可使第failAt次UploadPartCtx失败
+type multipartClient struct {
+	oss.ClientI
+	mp       oss.MultipartI
+	failAt   int32
+	parts    int32
+	uploadID atomic.Value
+}
+
+func newMultipartClient(t *testing.T, client oss.ClientI, failAt int32) *multipartClient {
+	mp, ok := client.(oss.MultipartI)
+	if !ok {
+		t.Skip("multipart upload is not supported")
+	}
+	return &multipartClient{ClientI: client, mp: mp, failAt: failAt}
+}
+
+func (c *multipartClient) InitMultipartUploadCtx(ctx context.Context, objectName string, opts ossmod.PutOptions) (string, error) {
+	uploadID, err := c.mp.InitMultipartUploadCtx(ctx, objectName, opts)
+	c.uploadID.Store(uploadID)
+	return uploadID, err
+}
+
+func (c *multipartClient) UploadPartCtx(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (ossmod.Part, error) {
+	if atomic.AddInt32(&c.parts, 1) == c.failAt {
+		return ossmod.Part{}, errInjected
+	}
+	return c.mp.UploadPartCtx(ctx, objectName, uploadID, partNumber, r, size)
+}
+
+func (c *multipartClient) CompleteMultipartUploadCtx(ctx context.Context, objectName, uploadID string, parts []ossmod.Part) error {
+	return c.mp.CompleteMultipartUploadCtx(ctx, objectName, uploadID, parts)
+}
+
+func (c *multipartClient) AbortMultipartUploadCtx(ctx context.Context, objectName, uploadID string) error {
+	return c.mp.AbortMultipartUploadCtx(ctx, objectName, uploadID)
+}
+
+func (c *multipartClient) MinPartSize() int64 {
+	return c.mp.MinPartSize()
+}
+
+var errInjected = errors.New("injected failure")
+
+// multipartFile 生成5个partSize字节分片大小的本地文件，最后一个分片不满
+func multipartFile(t *testing.T, partSize int64) (filePath string, content []byte) {
+	t.Helper()
+	content = make([]byte, 4*partSize+300)
+	for i := range content {
+		content[i] = byte(i*7 + i/1024)
+	}
+	filePath = filepath.Join(t.TempDir(), "multipart.bin")
+	if err := ioutil.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// assertContent 检查对象内容
+func assertContent(t *testing.T, client oss.ClientI, key string, want []byte) {
+	t.Helper()
+	body, _, err := client.GetObjectStream(key)
+	if err != nil {
+		t.Fatalf("GetObjectStream(%s): %v", key, err)
+	}
+	got, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("content of %s has %d bytes, want %d bytes", key, len(got), len(want))
+	}
+}
+
+// uploadPartSize 上传测试使用的分片大小，不小于服务商要求的最小分片大小
+func uploadPartSize(mp oss.MultipartI) int64 {
+	if min := mp.MinPartSize(); min > 1024 {
+		return min
+	}
+	return 1024
+}
+
+func testMultipart(t *testing.T, client oss.ClientI) {
+	mc := newMultipartClient(t, client, 0)
+	partSize := uploadPartSize(mc)
+	filePath, content := multipartFile(t, partSize)
+	// 小于最小分片大小时在初始化分片上传前返回错误
+	if min := mc.MinPartSize(); min > 1 {
+		opts := ossmod.MultipartOptions{PartSize: min - 1}
+		if err := oss.UploadFile(context.Background(), mc, "multipart/file.bin", filePath, opts); err == nil || mc.uploadID.Load() != nil {
+			t.Errorf("UploadFile with a part size below %d = %v, initiated = %v", min, err, mc.uploadID.Load() != nil)
+		}
+	}
+	opts := ossmod.MultipartOptions{PartSize: partSize, Concurrency: 3}
+	if err := oss.UploadFile(context.Background(), mc, "multipart/file.bin", filePath, opts); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	if mc.parts != 5 {
+		t.Errorf("UploadFile uploaded %d parts, want 5", mc.parts)
+	}
+	assertContent(t, client, "multipart/file.bin", content)
+	if _, err := os.Stat(filePath + ".upload.cp"); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file remains after a successful upload: %v", err)
+	}
+	// 不大于一个分片的文件直接上传
+	mc = newMultipartClient(t, client, 0)
+	opts.PartSize = int64(len(content))
+	if err := oss.UploadFile(context.Background(), mc, "multipart/small.bin", filePath, opts); err != nil {
+		t.Fatalf("UploadFile of a single part: %v", err)
+	}
+	if mc.parts != 0 {
+		t.Errorf("UploadFile of a single part uploaded %d parts, want 0", mc.parts)
+	}
+	assertContent(t, client, "multipart/small.bin", content)
+}
+
+func testMultipartResume(t *testing.T, client oss.ClientI) {
+	mc := newMultipartClient(t, client, 3)
+	partSize := uploadPartSize(mc)
+	filePath, content := multipartFile(t, partSize)
+	cpPath := filepath.Join(t.TempDir(), "upload.cp")
+	opts := ossmod.MultipartOptions{PartSize: partSize, Concurrency: 1, CheckpointFile: cpPath}
+	err := oss.UploadFile(context.Background(), mc, "multipart/resume.bin", filePath, opts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("UploadFile error = %v, want %v", err, errInjected)
+	}
+	if _, err = os.Stat(cpPath); err != nil {
+		t.Fatalf("checkpoint file missing after a failed upload: %v", err)
+	}
+	exist, err := client.ObjectExist("multipart/resume.bin")
+	if err != nil || exist {
+		t.Errorf("object visible after a failed upload: exist = %v, err = %v", exist, err)
+	}
+	// 续传时只上传剩余的分片
+	mc = newMultipartClient(t, client, 0)
+	if err = oss.UploadFile(context.Background(), mc, "multipart/resume.bin", filePath, opts); err != nil {
+		t.Fatalf("resumed UploadFile: %v", err)
+	}
+	if mc.parts != 3 {
+		t.Errorf("resumed UploadFile uploaded %d parts, want 3", mc.parts)
+	}
+	assertContent(t, client, "multipart/resume.bin", content)
+	if _, err = os.Stat(cpPath); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file remains after a successful upload: %v", err)
+	}
+}
+
+func testMultipartAbort(t *testing.T, client oss.ClientI) {
+	mc := newMultipartClient(t, client, 3)
+	partSize := uploadPartSize(mc)
+	filePath, _ := multipartFile(t, partSize)
+	opts := ossmod.MultipartOptions{PartSize: partSize, Concurrency: 1, DisableCheckpoint: true}
+	err := oss.UploadFile(context.Background(), mc, "multipart/abort.bin", filePath, opts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("UploadFile error = %v, want %v", err, errInjected)
+	}
+	if _, err = os.Stat(filePath + ".upload.cp"); !os.IsNotExist(err) {
+		t.Errorf("checkpoint file written with checkpoints disabled: %v", err)
+	}
+	// 失败后分片上传已被终止
+	uploadID, _ := mc.uploadID.Load().(string)
+	_, err = mc.mp.UploadPartCtx(context.Background(), "multipart/abort.bin", uploadID, 1, strings.NewReader("x"), 1)
+	assertErrorIs(t, "UploadPartCtx on an aborted upload", err, oss.ErrUploadNotFound)
+}
+
+func testGetObjectRange(t *testing.T, client oss.ClientI) {
+	content := "0123456789abcdefghij"
+	putString(t, client, "range.txt", content)
+	cases := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 5, "01234"},
+		{10, -1, "abcdefghij"},
+		{19, 1, "j"},
+		{15, 100, "fghij"},
+	}
+	for _, c := range cases {
+		body, err := client.GetObjectRange("range.txt", c.offset, c.length)
+		if err != nil {
+			t.Errorf("GetObjectRange(%d, %d): %v", c.offset, c.length, err)
+			continue
+		}
+		got, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil || string(got) != c.want {
+			t.Errorf("GetObjectRange(%d, %d) = %q, %v, want %q", c.offset, c.length, got, err, c.want)
+		}
+	}
+	_, err := client.GetObjectRange("range.txt", int64(len(content)), 1)
+	assertErrorIs(t, "GetObjectRange beyond the end", err, oss.ErrInvalidRange)
+	_, err = client.GetObjectRange("range.txt", 0, 0)
+	assertErrorIs(t, "GetObjectRange with a zero length", err, oss.ErrInvalidRange)
+	_, err = client.GetObjectRange("missing.txt", 0, 1)
+	assertErrorIs(t, "GetObjectRange on a missing key", err, oss.ErrObjectNotFound)
+}
+
+// rangeClient 记录GetObjectRangeCtx的调用次数，并可使第failAt次调用失败
+type rangeClient struct {
+	oss.ClientI
+	failAt int32
+	calls  int32
+}
+
+func (c *rangeClient) GetObjectRangeCtx(ctx context.Context, objectName string, offset, length int64) (io.ReadCloser, error) {
+	if atomic.AddInt32(&c.calls, 1) == c.failAt {
+		return nil, errInjected
+	}
+	return c.ClientI.GetObjectRangeCtx(ctx, objectName, offset, length)
+}
+
+// putMultipartFile 上传multipartFile生成的内容
+func putMultipartFile(t *testing.T, client oss.ClientI, key string) []byte {
+	t.Helper()
+	filePath, content := multipartFile(t, 1024)
+	if err := client.PutObject(key, filePath); err != nil {
+		t.Fatalf("PutObject(%s): %v", key, err)
+	}
+	return content
+}
+
+// assertFile 检查本地文件内容，并确认临时文件与断点文件已被清理
+func assertFile(t *testing.T, filePath string, want []byte) {
+	t.Helper()
+	got, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("read %s: %v", filePath, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s has %d bytes, want %d bytes", filePath, len(got), len(want))
+	}
+	for _, p := range []string{filePath + ".download", filePath + ".download.cp"} {
+		if _, err = os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s remains after a successful download: %v", p, err)
+		}
+	}
+}
+
+func testDownloadFile(t *testing.T, client oss.ClientI) {
+	content := putMultipartFile(t, client, "download/file.bin")
+	filePath := filepath.Join(t.TempDir(), "file.bin")
+	// 没有断点的残留临时文件会被丢弃
+	if err := ioutil.WriteFile(filePath+".download", []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rc := &rangeClient{ClientI: client}
+	opts := ossmod.DownloadOptions{PartSize: 1024, Concurrency: 3}
+	if err := oss.DownloadFile(context.Background(), rc, "download/file.bin", filePath, opts); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if rc.calls != 5 {
+		t.Errorf("DownloadFile requested %d ranges, want 5", rc.calls)
+	}
+	assertFile(t, filePath, content)
+	// 空对象
+	putString(t, client, "download/empty", "")
+	emptyPath := filepath.Join(t.TempDir(), "empty")
+	if err := oss.DownloadFile(context.Background(), client, "download/empty", emptyPath, opts); err != nil {
+		t.Fatalf("DownloadFile of an empty object: %v", err)
+	}
+	assertFile(t, emptyPath, nil)
+	err := oss.DownloadFile(context.Background(), client, "download/missing", filepath.Join(t.TempDir(), "missing"), opts)
+	assertErrorIs(t, "DownloadFile of a missing key", err, oss.ErrObjectNotFound)
+}
+
+func testDownloadFileResume(t *testing.T, client oss.ClientI) {
+	content := putMultipartFile(t, client, "download/resume.bin")
+	filePath := filepath.Join(t.TempDir(), "resume.bin")
+	opts := ossmod.DownloadOptions{PartSize: 1024, Concurrency: 1}
+	rc := &rangeClient{ClientI: client, failAt: 3}
+	err := oss.DownloadFile(context.Background(), rc, "download/resume.bin", filePath, opts)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("DownloadFile error = %v, want %v", err, errInjected)
+	}
+	for _, p := range []string{filePath + ".download", filePath + ".download.cp"} {
+		if _, err = os.Stat(p); err != nil {
+			t.Fatalf("%s missing after a failed download: %v", p, err)
+		}
+	}
+	if _, err = os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("%s created by a failed download: %v", filePath, err)
+	}
+	// 续传时只下载缺失的分片
+	rc = &rangeClient{ClientI: client}
+	if err = oss.DownloadFile(context.Background(), rc, "download/resume.bin", filePath, opts); err != nil {
+		t.Fatalf("resumed DownloadFile: %v", err)
+	}
+	if rc.calls != 3 {
+		t.Errorf("resumed DownloadFile requested %d ranges, want 3", rc.calls)
+	}
+	assertFile(t, filePath, content)
+}
+
+// assertObject 检查对象内容
+func assertObject(t *testing.T, client oss.ClientI, key, want string) {
+	t.Helper()
+	assertContent(t, client, key, []byte(want))
+}
+
+func testCopyObject(t *testing.T, client oss.ClientI) {
+	content := "copied on the server"
+	err := client.PutObjectStream("copy/src.txt", strings.NewReader(content), int64(len(content)), ossmod.PutOptions{ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("PutObjectStream: %v", err)
+	}
+	if err = client.CopyObject("copy/src.txt", "copy/dst.txt", ossmod.CopyOptions{}); err != nil {
+		t.Fatalf("CopyObject: %v", err)
+	}
+	assertObject(t, client, "copy/dst.txt", content)
+	assertObject(t, client, "copy/src.txt", content)
+	info, err := client.StatObject("copy/dst.txt")
+	if err != nil || info.ContentType != "text/plain" {
+		t.Errorf("StatObject of the copy = %+v, %v, want ContentType %q", info, err, "text/plain")
+	}
+	// 覆盖已存在的对象
+	putString(t, client, "copy/other.txt", "other")
+	if err = client.CopyObject("copy/other.txt", "copy/dst.txt", ossmod.CopyOptions{}); err != nil {
+		t.Fatalf("CopyObject onto an existing key: %v", err)
+	}
+	assertObject(t, client, "copy/dst.txt", "other")
+	err = client.CopyObject("copy/missing.txt", "copy/dst2.txt", ossmod.CopyOptions{})
+	assertErrorIs(t, "CopyObject from a missing key", err, oss.ErrObjectNotFound)
+}
+
+// bucketOf 通过导出的Bucket字段获取客户端的存储桶名，没有该字段时返回空
+func bucketOf(client oss.ClientI) string {
+	// 腾讯云的存储桶名是访问域名的第一段
+	if c, ok := client.(*oss.TencentCloudOss); ok {
+		host := c.Client.BaseURL.BucketURL.Host
+		return strings.SplitN(host, ".", 2)[0]
+	}
+	v := reflect.Indirect(reflect.ValueOf(client))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("Bucket"); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
+func testCopyObjectCrossBucket(t *testing.T, src, dst oss.ClientI) {
+	bucket := bucketOf(src)
+	if bucket == "" {
+		t.Skip("bucket name is not exposed")
+	}
+	putString(t, src, "cross.txt", "across buckets")
+	err := dst.CopyObject("cross.txt", "copied/cross.txt", ossmod.CopyOptions{SrcBucket: bucket})
+	if errors.Is(err, oss.ErrNotSupported) {
+		t.Skip("copying across buckets is not supported")
+	}
+	if err != nil {
+		t.Fatalf("CopyObject across buckets: %v", err)
+	}
+	assertObject(t, dst, "copied/cross.txt", "across buckets")
+}
+
+func testMoveObject(t *testing.T, client oss.ClientI) {
+	putString(t, client, "move/src.txt", "moved")
+	if err := client.MoveObject("move/src.txt", "move/dir/dst.txt"); err != nil {
+		t.Fatalf("MoveObject: %v", err)
+	}
+	assertObject(t, client, "move/dir/dst.txt", "moved")
+	exist, err := client.ObjectExist("move/src.txt")
+	if err != nil || exist {
+		t.Errorf("source after MoveObject: exist = %v, err = %v", exist, err)
+	}
+	// 移动到自身不会删除对象
+	if err = client.MoveObject("move/dir/dst.txt", "move/dir/dst.txt"); err != nil {
+		t.Fatalf("MoveObject onto itself: %v", err)
+	}
+	assertObject(t, client, "move/dir/dst.txt", "moved")
+	err = client.MoveObject("move/missing.txt", "move/dst2.txt")
+	assertErrorIs(t, "MoveObject from a missing key", err, oss.ErrObjectNotFound)
+}
+
+func testRemoveObjects(t *testing.T, client oss.ClientI) {
+	for _, key := range []string{"batch/a.txt", "batch/b.txt", "batch/c.txt", "keep.txt"} {
+		putString(t, client, key, key)
+	}
+	// 不存在的对象不视为失败
+	failed := client.RemoveObjects([]string{"batch/a.txt", "batch/c.txt", "batch/missing.txt", "keep.txt"})
+	if failed != nil {
+		t.Fatalf("RemoveObjects failed = %v", failed)
+	}
+	objects, err := client.ListObjects("", "")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	assertKeys(t, "ListObjects after RemoveObjects", objects, "batch/b.txt")
+	if failed = client.RemoveObjects(nil); failed != nil {
+		t.Errorf("RemoveObjects(nil) failed = %v", failed)
+	}
+}
+
+func testRemovePrefix(t *testing.T, client oss.ClientI) {
+	for _, key := range []string{"logs/2026/a.log", "logs/2026/b.log", "logs/old.log", "logs2.txt", "data.txt"} {
+		putString(t, client, key, key)
+	}
+	if err := client.RemovePrefix(""); err == nil {
+		t.Errorf("RemovePrefix with an empty prefix: want error")
+	}
+	if err := client.RemovePrefix("logs/"); err != nil {
+		t.Fatalf("RemovePrefix: %v", err)
+	}
+	objects, err := client.ListObjects("", "")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	assertKeys(t, "ListObjects after RemovePrefix", objects, "data.txt", "logs2.txt")
+	if err = client.RemovePrefix("nothing/"); err != nil {
+		t.Errorf("RemovePrefix with no matches: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+/**
+ * @Time    :2026/10/18 15:10
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"context"
+	"fmt"
+	"github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"github.com/qiniu/go-sdk/v7/storage"
+	"github.com/tencentyun/cos-go-sdk-v5"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var bucketSeq int64
+
+// bucketName 生成唯一的存储桶名
+func bucketName() string {
+	return fmt.Sprintf("bucket-%d", atomic.AddInt64(&bucketSeq, 1))
+}
+
+// newServer 启动模拟服务，并将单页条目数设置得足够小以覆盖分页逻辑
+func newServer(t *testing.T) *Server {
+	srv := NewServer()
+	srv.MaxKeys = 2
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMemoryOss(t *testing.T) {
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		return oss.NewMemoryOss(bucketName())
+	})
+}
+
+func TestLocalFsOss(t *testing.T) {
+	root := t.TempDir()
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		return oss.NewLocalFsOss(root, bucketName())
+	})
+}
+
+func TestMinioOss(t *testing.T) {
+	srv := newServer(t)
+	endpoint := strings.TrimPrefix(srv.URL, "http://")
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		client, err := oss.NewMinioOss(endpoint, "ak", "sk", bucketName(), 15, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+}
+
+// TestMinioListCanceled 列举请求进行中时取消ctx应立即返回
+func TestMinioListCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	client, err := oss.NewMinioOss(strings.TrimPrefix(srv.URL, "http://"), "ak", "sk", bucketName(), 15, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, list := range map[string]func(ctx context.Context) error{
+		"ListObjectsCtx": func(ctx context.Context) error {
+			_, err := client.ListObjectsCtx(ctx, "", "")
+			return err
+		},
+		"ListDirCtx": func(ctx context.Context) error {
+			_, _, err := client.ListDirCtx(ctx, "", "/")
+			return err
+		},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		err = list(ctx)
+		cancel()
+		if err == nil || time.Since(start) > 2*time.Second {
+			t.Errorf("%s with an expiring context returned %v after %v", name, err, time.Since(start))
+		}
+	}
+}
+
+func TestALiYunOss(t *testing.T) {
+	srv := newServer(t)
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		client, err := oss.NewALiYunOss(srv.URL, "ak", "sk", bucketName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+}
+
+// TestListDirWithoutNextMarker 截断的页只有公共前缀且没有NextMarker时从最后一个公共前缀之后继续
+func TestListDirWithoutNextMarker(t *testing.T) {
+	srv := newServer(t)
+	srv.MaxKeys = 1
+	srv.OmitNextMarker = true
+	client := newBucket(t, func(t *testing.T) oss.ClientI {
+		client, err := oss.NewALiYunOss(srv.URL, "ak", "sk", bucketName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+	for _, key := range []string{"a/1", "a/2", "b/1", "c.txt", "d/1"} {
+		putString(t, client, key, key)
+	}
+	objects, prefixes, err := client.ListDir("", "/")
+	if err != nil {
+		t.Fatalf("ListDir: %v", err)
+	}
+	if fmt.Sprint(keysOf(objects)) != "[c.txt]" || fmt.Sprint(prefixes) != "[a/ b/ d/]" {
+		t.Errorf("ListDir = %v %v, want [c.txt] [a/ b/ d/]", keysOf(objects), prefixes)
+	}
+}
+
+func TestHuaweiCloudObs(t *testing.T) {
+	srv := newServer(t)
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		client := &oss.HuaweiCloudObs{
+			Endpoint:  srv.URL,
+			AccessKey: "ak",
+			SecretKey: "sk",
+			Bucket:    bucketName(),
+		}
+		var err error
+		client.Client, err = obs.New(client.AccessKey, client.SecretKey, client.Endpoint, obs.WithPathStyle(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+}
+
+func TestBaiduCloudBos(t *testing.T) {
+	srv := newServer(t)
+	RunConformanceExcept(t, func(t *testing.T) oss.ClientI {
+		client, err := oss.NewBaiduCloudBos(srv.URL, "ak", "sk", bucketName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}, map[string]string{
+		"PutOptions": "bos上传时不支持设置ContentEncoding",
+	})
+}
+
+func TestQiNiuCloudOss(t *testing.T) {
+	srv := NewQiniuServer()
+	srv.MaxKeys = 2
+	t.Cleanup(srv.Close)
+	// SDK将区域查询结果缓存到文件，使用独立的缓存目录，避免读到之前的测试中已关闭的模拟服务地址
+	storage.SetRegionCachePath(filepath.Join(t.TempDir(), "region.cache"))
+	storage.SetUcHost(strings.TrimPrefix(srv.URL, "http://"), false)
+	t.Cleanup(func() { storage.SetUcHost("", true) })
+	RunConformanceExcept(t, func(t *testing.T) oss.ClientI {
+		bucket := bucketName()
+		return oss.NewQiNiuCloudOss(srv.Domain(bucket), "ak", "sk", bucket, 15, false, storage.RIDHuadong)
+	}, map[string]string{
+		"PutOptions": "七牛云不支持设置ContentDisposition、CacheControl与ContentEncoding，StatObject不返回用户元数据",
+	})
+}
+
+func TestUpYunOss(t *testing.T) {
+	srv := NewUpYunServer()
+	srv.MaxKeys = 2
+	t.Cleanup(srv.Close)
+	RunConformanceExcept(t, func(t *testing.T) oss.ClientI {
+		client := oss.NewUpYunOss("operator", "password", bucketName())
+		client.Client.Hosts = map[string]string{"host": srv.Host()}
+		client.Client.UseHTTP = true
+		return client
+	}, map[string]string{
+		"BucketLifecycle": "又拍云的服务只能在控制台创建与删除",
+		"ListObjects":     "又拍云的列目录接口不返回文件的MD5",
+		"PutOptions":      "又拍云的文件信息接口只返回ContentType与用户元数据",
+	})
+}
+
+func TestTencentCloudOss(t *testing.T) {
+	srv := newServer(t)
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	// 腾讯云SDK只支持虚拟主机风格，将所有连接转发到模拟服务
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+	// 预签名URL同样使用虚拟主机风格
+	presignTransport = transport
+	t.Cleanup(func() { presignTransport = nil })
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		u, _ := url.Parse("http://" + bucketName() + ".cos.test")
+		client := &oss.TencentCloudOss{
+			Endpoint:  u.String(),
+			SecretId:  "ak",
+			SecretKey: "sk",
+			TimeOut:   15,
+		}
+		client.Client = cos.NewClient(&cos.BaseURL{BucketURL: u}, &http.Client{
+			Transport: &cos.AuthorizationTransport{
+				SecretID:  client.SecretId,
+				SecretKey: client.SecretKey,
+				Transport: transport,
+			},
+		})
+		return client
+	})
+}
@@ -0,0 +1,228 @@
+/**
+ * @Time    :2026/10/18 23:20
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// masterKey 生成测试用的32字节主密钥
+func masterKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newKeyProvider(t *testing.T, current string, ids ...string) *oss.StaticKeyProvider {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, id := range ids {
+		keys[id] = masterKey(id[0])
+	}
+	provider, err := oss.NewStaticKeyProvider(current, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestEncryptedClientConformance(t *testing.T) {
+	for _, sidecar := range []bool{false, true} {
+		opts := oss.EncryptionOptions{Sidecar: sidecar}
+		name := "Metadata"
+		if sidecar {
+			name = "Sidecar"
+		}
+		t.Run(name+"/Memory", func(t *testing.T) {
+			RunConformance(t, func(t *testing.T) oss.ClientI {
+				return oss.NewEncryptedClient(oss.NewMemoryOss(bucketName()), newKeyProvider(t, "k1", "k1"), opts)
+			})
+		})
+		t.Run(name+"/Minio", func(t *testing.T) {
+			srv := newServer(t)
+			endpoint := strings.TrimPrefix(srv.URL, "http://")
+			RunConformance(t, func(t *testing.T) oss.ClientI {
+				client, err := oss.NewMinioOss(endpoint, "ak", "sk", bucketName(), 15, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return oss.NewEncryptedClient(client, newKeyProvider(t, "k1", "k1"), opts)
+			})
+		})
+	}
+}
+
+// readAll 读取对象的全部内容
+func readAll(t *testing.T, client oss.ClientI, key string) ([]byte, error) {
+	t.Helper()
+	body, _, err := client.GetObjectStream(key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+func TestEncryptedClient(t *testing.T) {
+	inner := oss.NewMemoryOss(bucketName())
+	if err := inner.NewBucket(); err != nil {
+		t.Fatal(err)
+	}
+	client := oss.NewEncryptedClient(inner, newKeyProvider(t, "k1", "k1"), oss.EncryptionOptions{})
+	// 跨越多个分段，最后一段不满
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024/16*3+5)
+	opts := ossmod.PutOptions{Metadata: map[string]string{"owner": "alice"}}
+	if err := client.PutObjectStream("big.bin", bytes.NewReader(content), int64(len(content)), opts); err != nil {
+		t.Fatalf("PutObjectStream: %v", err)
+	}
+	assertContent(t, client, "big.bin", content)
+
+	// 服务商只保存密文
+	stored, err := readAll(t, inner, "big.bin")
+	if err != nil {
+		t.Fatalf("inner GetObjectStream: %v", err)
+	}
+	if len(stored) <= len(content) || bytes.Contains(stored, content[:64]) {
+		t.Errorf("stored object has %d bytes and contains the plaintext", len(stored))
+	}
+	info, err := client.StatObject("big.bin")
+	if err != nil {
+		t.Fatalf("StatObject: %v", err)
+	}
+	if info.Size != int64(len(content)) || len(info.Metadata) != 1 || info.Metadata["owner"] != "alice" {
+		t.Errorf("StatObject = size %d, metadata %v", info.Size, info.Metadata)
+	}
+
+	// 跨越分段边界的范围读取
+	for _, r := range [][2]int64{{64*1024 - 3, 10}, {64 * 1024, 64*1024 + 1}, {int64(len(content)) - 7, -1}} {
+		body, err := client.GetObjectRange("big.bin", r[0], r[1])
+		if err != nil {
+			t.Fatalf("GetObjectRange(%d, %d): %v", r[0], r[1], err)
+		}
+		got, err := ioutil.ReadAll(body)
+		body.Close()
+		end := int64(len(content))
+		if r[1] > 0 {
+			end = r[0] + r[1]
+		}
+		if err != nil || !bytes.Equal(got, content[r[0]:end]) {
+			t.Errorf("GetObjectRange(%d, %d) returned %d bytes, %v", r[0], r[1], len(got), err)
+		}
+	}
+
+	// 篡改密文后解密失败
+	stored[len(stored)/2] ^= 1
+	if err = inner.PutObjectStream("tampered.bin", bytes.NewReader(stored), int64(len(stored)), ossmod.PutOptions{Metadata: info.Metadata}); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.CopyObject("big.bin", "copied.bin", ossmod.CopyOptions{}); err != nil {
+		t.Fatalf("CopyObject: %v", err)
+	}
+	if _, err = readAll(t, client, "tampered.bin"); !errors.Is(err, oss.ErrEnvelopeNotFound) {
+		t.Errorf("GetObjectStream without an envelope: err = %v, want %v", err, oss.ErrEnvelopeNotFound)
+	}
+	copied, err := inner.StatObject("copied.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = inner.PutObjectStream("tampered.bin", bytes.NewReader(stored), int64(len(stored)), ossmod.PutOptions{Metadata: copied.Metadata}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readAll(t, client, "tampered.bin"); !errors.Is(err, oss.ErrDecryptFailed) {
+		t.Errorf("GetObjectStream of a tampered object: err = %v, want %v", err, oss.ErrDecryptFailed)
+	}
+	// 截断最后一段
+	truncated := stored[:len(stored)-64*1024/2]
+	if err = inner.PutObjectStream("truncated.bin", bytes.NewReader(truncated), int64(len(truncated)), ossmod.PutOptions{Metadata: copied.Metadata}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readAll(t, client, "truncated.bin"); !errors.Is(err, oss.ErrDecryptFailed) {
+		t.Errorf("GetObjectStream of a truncated object: err = %v, want %v", err, oss.ErrDecryptFailed)
+	}
+
+	// 主密钥错误时无法解开数据密钥
+	wrongKey, err := oss.NewStaticKeyProvider("k1", map[string][]byte{"k1": masterKey('x')})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readAll(t, oss.NewEncryptedClient(inner, wrongKey, oss.EncryptionOptions{}), "big.bin"); err == nil {
+		t.Error("GetObjectStream with a wrong master key succeeded")
+	}
+	_, err = client.PresignGet("big.bin", 0)
+	assertErrorIs(t, "PresignGet", err, oss.ErrNotSupported)
+}
+
+func TestEncryptedClientSidecar(t *testing.T) {
+	inner := oss.NewMemoryOss(bucketName())
+	if err := inner.NewBucket(); err != nil {
+		t.Fatal(err)
+	}
+	client := oss.NewEncryptedClient(inner, newKeyProvider(t, "k1", "k1"), oss.EncryptionOptions{Sidecar: true})
+	putString(t, client, "a.txt", "sidecar")
+	assertObject(t, client, "a.txt", "sidecar")
+	objects, err := inner.ListObjects("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, "inner ListObjects", objects, "a.txt", "a.txt.cse-envelope")
+	if info, err := inner.StatObject("a.txt"); err != nil || info.Metadata != nil {
+		t.Errorf("inner StatObject = %+v, %v, want no metadata", info, err)
+	}
+	if err = client.MoveObject("a.txt", "b.txt"); err != nil {
+		t.Fatalf("MoveObject: %v", err)
+	}
+	objects, err = inner.ListObjects("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, "inner ListObjects after MoveObject", objects, "b.txt", "b.txt.cse-envelope")
+	if failed := client.RemoveObjects([]string{"b.txt"}); failed != nil {
+		t.Fatalf("RemoveObjects: %v", failed)
+	}
+	objects, err = inner.ListObjects("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, "inner ListObjects after RemoveObjects", objects)
+}
+
+func TestEncryptedClientRewrap(t *testing.T) {
+	for _, sidecar := range []bool{false, true} {
+		inner := oss.NewMemoryOss(bucketName())
+		if err := inner.NewBucket(); err != nil {
+			t.Fatal(err)
+		}
+		opts := oss.EncryptionOptions{Sidecar: sidecar}
+		old := oss.NewEncryptedClient(inner, newKeyProvider(t, "k1", "k1"), opts)
+		putString(t, old, "logs/1", "one")
+		putString(t, old, "logs/2", "two")
+		putString(t, old, "other", "other")
+
+		// 加入新的主密钥后重新包装，之后只保留新的主密钥也可以解密
+		rotated := oss.NewEncryptedClient(inner, newKeyProvider(t, "k2", "k1", "k2"), opts)
+		putString(t, rotated, "logs/3", "three")
+		count, err := rotated.Rewrap(context.Background(), "logs/")
+		if err != nil || count != 2 {
+			t.Fatalf("Rewrap(sidecar=%v) = %d, %v, want 2", sidecar, count, err)
+		}
+		if count, err = rotated.Rewrap(context.Background(), "logs/"); err != nil || count != 0 {
+			t.Errorf("second Rewrap(sidecar=%v) = %d, %v, want 0", sidecar, count, err)
+		}
+		current := oss.NewEncryptedClient(inner, newKeyProvider(t, "k2", "k2"), opts)
+		assertObject(t, current, "logs/1", "one")
+		assertObject(t, current, "logs/2", "two")
+		assertObject(t, current, "logs/3", "three")
+		if _, err = readAll(t, current, "other"); err == nil {
+			t.Errorf("GetObjectStream(sidecar=%v) of an object outside the prefix succeeded without the old key", sidecar)
+		}
+		assertObject(t, old, "other", "other")
+	}
+}
@@ -0,0 +1,90 @@
+/**
+ * @Time    :2026/10/19 18:40
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"context"
+	"fmt"
+	ossmod "github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"strings"
+	"testing"
+)
+
+// newLocalBucket 在临时目录下创建本地存储桶
+func newLocalBucket(t *testing.T, root string) oss.ClientI {
+	return newBucket(t, func(t *testing.T) oss.ClientI { return oss.NewLocalFsOss(root, bucketName()) })
+}
+
+func TestLocalFsOssObjectName(t *testing.T) {
+	client := newLocalBucket(t, t.TempDir())
+	// 非规范的对象名直接拒绝，而不是规范化后映射到其他对象
+	for _, key := range []string{"", "/", "/a", "a//b", "./a", "a/./b", "a/../b", "../a", "a/..", "a/.oss-dir", "a//"} {
+		if err := client.PutObjectStream(key, strings.NewReader("x"), 1, ossmod.PutOptions{}); err == nil {
+			t.Errorf("PutObjectStream(%q) succeeded", key)
+		}
+	}
+	putString(t, client, "a/b", "b")
+	// 以"/"结尾的对象与目录下的对象互不影响
+	putString(t, client, "a/", "dir")
+	putString(t, client, "x", "x")
+	putString(t, client, "x.json/y", "y")
+	assertContent(t, client, "a/", []byte("dir"))
+	assertContent(t, client, "x", []byte("x"))
+	if err := client.RemoveObject("a/b"); err != nil {
+		t.Fatalf("RemoveObject: %v", err)
+	}
+	assertContent(t, client, "a/", []byte("dir"))
+	if err := client.MoveObject("a/", "c/"); err != nil {
+		t.Fatalf("MoveObject: %v", err)
+	}
+	assertContent(t, client, "c/", []byte("dir"))
+	objects, err := client.ListObjects("", "")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	assertKeys(t, "ListObjects", objects, "c/", "x", "x.json/y")
+}
+
+func TestLocalFsOssBucketName(t *testing.T) {
+	root := t.TempDir()
+	putString(t, newLocalBucket(t, root), "key", "x")
+	// 存储桶名不能与元数据目录冲突或越出Root
+	for _, bucket := range []string{"", ".meta", "..", "a/b"} {
+		client := oss.NewLocalFsOss(root, bucket)
+		if err := client.NewBucket(); err == nil {
+			t.Errorf("NewBucket(%q) succeeded", bucket)
+		}
+		if _, err := client.ListObjects("", ""); err == nil {
+			t.Errorf("ListObjects of bucket %q succeeded", bucket)
+		}
+	}
+}
+
+func TestLocalFsOssListOrder(t *testing.T) {
+	client := newLocalBucket(t, t.TempDir())
+	for _, key := range []string{"a/b", "a.txt", "a-c", "a/", "b", "a/d/e"} {
+		putString(t, client, key, key)
+	}
+	// 对象名的顺序与目录项的顺序不同，"a-c"与"a.txt"排在"a/"之前
+	want := []string{"a-c", "a.txt", "a/", "a/b", "a/d/e", "b"}
+	got := iterKeys(t, client.ListObjectsIter(context.Background(), ossmod.ListOptions{MaxKeys: 1}), -1)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ListObjectsIter = %v, want %v", got, want)
+	}
+	for i, startAfter := range want {
+		objects, err := client.ListObjects("", startAfter)
+		if err != nil {
+			t.Fatalf("ListObjects after %q: %v", startAfter, err)
+		}
+		assertKeys(t, "ListObjects after "+startAfter, objects, want[i+1:]...)
+	}
+	objects, err := client.ListObjects("a/", "a/b")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	assertKeys(t, "ListObjects(a/, a/b)", objects, "a/d/e")
+}
@@ -0,0 +1,562 @@
+/**
+ * @Time    :2026/10/19 10:20
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QiniuServer 基于httptest的七牛云对象存储模拟服务
+// 同一地址同时提供uc（区域查询与空间管理）、rs（对象管理）、rsf（列举）、up（表单上传与分片上传v2）接口，
+// 下载域名为Domain返回的地址；错误以JSON返回，并使用七牛云的612（文件不存在）、631（空间不存在）等自定义状态码
+type QiniuServer struct {
+	*httptest.Server
+	// MaxKeys 列举时单页返回的最大条目数，设置较小的值可强制SDK分页
+	MaxKeys int
+
+	mu      sync.Mutex
+	buckets map[string]*fakeBucket
+	uploads map[string]*fakeUpload
+	seq     int
+}
+
+// qiniuFileType 对象的存储类型保存在该请求头中，下载时不返回
+const qiniuFileType = "X-Qn-File-Type"
+
+// NewQiniuServer 启动模拟服务，使用完毕后需调用Close
+func NewQiniuServer() *QiniuServer {
+	s := &QiniuServer{
+		MaxKeys: 1000,
+		buckets: make(map[string]*fakeBucket),
+		uploads: make(map[string]*fakeUpload),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Domain 返回存储空间的下载域名，用作QiNiuCloudOss的Endpoint
+func (s *QiniuServer) Domain(bucket string) string {
+	return s.URL + "/download/" + bucket
+}
+
+func (s *QiniuServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	w.Header().Set("X-Reqid", fmt.Sprintf("fake-%d", s.seq))
+	p := r.URL.Path
+	switch {
+	case p == "/v2/query":
+		s.queryRegion(w, r)
+	case p == "/v2/bucketInfo":
+		if _, ok := s.buckets[r.URL.Query().Get("bucket")]; !ok {
+			writeQiniu(w, 631, qiniuError("no such bucket"))
+			return
+		}
+		writeQiniu(w, http.StatusOK, struct{}{})
+	case p == "/list":
+		s.list(w, r)
+	case p == "/batch":
+		s.batch(w, r)
+	case p == "/" && r.Method == http.MethodPost:
+		s.formUpload(w, r)
+	case strings.HasPrefix(p, "/buckets/"):
+		s.serveUpload(w, r, strings.Split(strings.TrimPrefix(p, "/buckets/"), "/"))
+	case strings.HasPrefix(p, "/download/"):
+		s.download(w, r, strings.TrimPrefix(p, "/download/"))
+	default:
+		code, ret := s.rsOp(p)
+		writeQiniu(w, code, ret)
+	}
+}
+
+// qiniuError 七牛云的错误响应体
+func qiniuError(msg string) interface{} {
+	return struct {
+		Error string `json:"error"`
+	}{msg}
+}
+
+func writeQiniu(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// queryRegion 区域查询，所有接口均指向模拟服务自身
+func (s *QiniuServer) queryRegion(w http.ResponseWriter, r *http.Request) {
+	type hosts struct {
+		Main []string `json:"main"`
+	}
+	src := map[string]hosts{"src": {Main: []string{r.Host}}}
+	writeQiniu(w, http.StatusOK, map[string]interface{}{
+		"ttl":    86400,
+		"io":     src,
+		"io_src": src,
+		"up":     src,
+		"rs":     src,
+		"rsf":    src,
+		"api":    src,
+	})
+}
+
+// decodeEntry 解析 base64(bucket:key) 形式的EncodedEntry
+func decodeEntry(entry string) (bucket, key string, ok bool) {
+	data, err := base64.URLEncoding.DecodeString(entry)
+	if err != nil {
+		return
+	}
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	return parts[0], parts[1], true
+}
+
+// rsOp
+/**
+ *  @Description: 执行空间管理与对象管理操作，batch接口中的每个操作同样由此执行
+ *  @receiver s
+ *  @param op 如 /stat/<EncodedEntry>、/copy/<EncodedEntry>/<EncodedEntry>/force/true
+ *  @return code 七牛云的状态码
+ *  @return ret 响应体
+ */
+func (s *QiniuServer) rsOp(op string) (code int, ret interface{}) {
+	parts := strings.Split(strings.TrimPrefix(op, "/"), "/")
+	if len(parts) < 2 {
+		return http.StatusNotFound, qiniuError("not found")
+	}
+	switch parts[0] {
+	case "mkbucketv3":
+		if _, ok := s.buckets[parts[1]]; ok {
+			return 614, qiniuError("the bucket already exists")
+		}
+		s.buckets[parts[1]] = &fakeBucket{created: time.Now(), objects: make(map[string]*fakeObject)}
+		return http.StatusOK, struct{}{}
+	case "drop":
+		b, ok := s.buckets[parts[1]]
+		if !ok {
+			return 631, qiniuError("no such bucket")
+		}
+		// 非空的空间不允许删除
+		if len(b.objects) > 0 {
+			return http.StatusConflict, qiniuError("the bucket is not empty")
+		}
+		delete(s.buckets, parts[1])
+		return http.StatusOK, struct{}{}
+	case "stat", "delete":
+		b, key, code := s.entry(parts[1])
+		if code != http.StatusOK {
+			return code, entryError(code)
+		}
+		o, ok := b.objects[key]
+		if !ok {
+			return 612, qiniuError("no such file or directory")
+		}
+		if parts[0] == "delete" {
+			delete(b.objects, key)
+			return http.StatusOK, struct{}{}
+		}
+		fileType, _ := strconv.Atoi(o.header.Get(qiniuFileType))
+		return http.StatusOK, qiniuItem{
+			Fsize:    int64(len(o.data)),
+			Hash:     o.etag,
+			MimeType: o.header.Get("Content-Type"),
+			Type:     fileType,
+			PutTime:  o.modified.UnixNano() / 100,
+		}
+	case "copy", "move":
+		if len(parts) < 3 {
+			return http.StatusBadRequest, qiniuError("invalid arguments")
+		}
+		src, srcKey, code := s.entry(parts[1])
+		if code != http.StatusOK {
+			return code, entryError(code)
+		}
+		dst, dstKey, code := s.entry(parts[2])
+		if code != http.StatusOK {
+			return code, entryError(code)
+		}
+		o, ok := src.objects[srcKey]
+		if !ok {
+			return 612, qiniuError("no such file or directory")
+		}
+		force := len(parts) >= 5 && parts[3] == "force" && parts[4] == "true"
+		if _, exist := dst.objects[dstKey]; exist && !force {
+			return 614, qiniuError("file exists")
+		}
+		header := make(http.Header)
+		for name, values := range o.header {
+			header[name] = append([]string(nil), values...)
+		}
+		dst.objects[dstKey] = &fakeObject{data: o.data, etag: o.etag, header: header, modified: time.Now()}
+		if parts[0] == "move" {
+			delete(src.objects, srcKey)
+		}
+		return http.StatusOK, struct{}{}
+	}
+	return http.StatusNotFound, qiniuError("not found")
+}
+
+// entry 解析EncodedEntry并查找空间
+func (s *QiniuServer) entry(entry string) (b *fakeBucket, key string, code int) {
+	bucket, key, ok := decodeEntry(entry)
+	if !ok {
+		return nil, "", http.StatusBadRequest
+	}
+	if b, ok = s.buckets[bucket]; !ok {
+		return nil, "", 631
+	}
+	return b, key, http.StatusOK
+}
+
+// entryError entry返回的状态码对应的错误
+func entryError(code int) interface{} {
+	if code == 631 {
+		return qiniuError("no such bucket")
+	}
+	return qiniuError("invalid entry")
+}
+
+// batch 批量执行操作，部分操作失败时状态码为298
+func (s *QiniuServer) batch(w http.ResponseWriter, r *http.Request) {
+	type opResult struct {
+		Code int         `json:"code"`
+		Data interface{} `json:"data"`
+	}
+	if err := r.ParseForm(); err != nil {
+		writeQiniu(w, http.StatusBadRequest, qiniuError("invalid form"))
+		return
+	}
+	status := http.StatusOK
+	var results []opResult
+	for _, op := range r.PostForm["op"] {
+		code, ret := s.rsOp(op)
+		if code != http.StatusOK {
+			status = 298
+		}
+		results = append(results, opResult{Code: code, Data: ret})
+	}
+	writeQiniu(w, status, results)
+}
+
+// qiniuItem 列举与stat接口返回的文件信息，putTime的单位为100纳秒
+type qiniuItem struct {
+	Key      string `json:"key,omitempty"`
+	Fsize    int64  `json:"fsize"`
+	Hash     string `json:"hash"`
+	MimeType string `json:"mimeType"`
+	Type     int    `json:"type"`
+	PutTime  int64  `json:"putTime"`
+}
+
+// qiniuMarker marker的格式为 base64({"c":0,"k":"<上一页最后一个条目>"})
+type qiniuMarker struct {
+	C int    `json:"c"`
+	K string `json:"k"`
+}
+
+func (s *QiniuServer) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	b, ok := s.buckets[query.Get("bucket")]
+	if !ok {
+		writeQiniu(w, 631, qiniuError("no such bucket"))
+		return
+	}
+	var marker qiniuMarker
+	if m := query.Get("marker"); m != "" {
+		data, err := base64.URLEncoding.DecodeString(m)
+		if err != nil || json.Unmarshal(data, &marker) != nil {
+			writeQiniu(w, http.StatusBadRequest, qiniuError("invalid marker"))
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	if s.MaxKeys > 0 && limit > s.MaxKeys {
+		limit = s.MaxKeys
+	}
+	type listResult struct {
+		Marker         string      `json:"marker"`
+		Items          []qiniuItem `json:"items"`
+		CommonPrefixes []string    `json:"commonPrefixes"`
+	}
+	keys, prefixes, next := b.list(query.Get("prefix"), query.Get("delimiter"), marker.K, limit)
+	result := listResult{Items: []qiniuItem{}, CommonPrefixes: prefixes}
+	for _, k := range keys {
+		o := b.objects[k]
+		fileType, _ := strconv.Atoi(o.header.Get(qiniuFileType))
+		result.Items = append(result.Items, qiniuItem{
+			Key:      k,
+			Fsize:    int64(len(o.data)),
+			Hash:     o.etag,
+			MimeType: o.header.Get("Content-Type"),
+			Type:     fileType,
+			PutTime:  o.modified.UnixNano() / 100,
+		})
+	}
+	if next != "" {
+		data, _ := json.Marshal(qiniuMarker{K: next})
+		result.Marker = base64.URLEncoding.EncodeToString(data)
+	}
+	writeQiniu(w, http.StatusOK, result)
+}
+
+// uploadPolicy 解析上传凭证 ak:sign:base64(putPolicy) 中的上传策略
+func uploadPolicy(token string) (bucket, key string, hasKey bool, fileType int, ok bool) {
+	token = strings.TrimPrefix(token, "UpToken ")
+	parts := strings.Split(token, ":")
+	if len(parts) != 3 {
+		return
+	}
+	data, err := base64.URLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return
+	}
+	var policy struct {
+		Scope    string `json:"scope"`
+		FileType int    `json:"fileType"`
+	}
+	if json.Unmarshal(data, &policy) != nil {
+		return
+	}
+	scope := strings.SplitN(policy.Scope, ":", 2)
+	bucket, fileType, ok = scope[0], policy.FileType, true
+	if len(scope) == 2 {
+		key, hasKey = scope[1], true
+	}
+	return
+}
+
+// formUpload 表单上传，上传凭证只指定空间时不允许覆盖内容不同的同名文件
+func (s *QiniuServer) formUpload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeQiniu(w, http.StatusBadRequest, qiniuError("invalid multipart form"))
+		return
+	}
+	bucket, scopeKey, hasKey, fileType, ok := uploadPolicy(r.FormValue("token"))
+	key := r.FormValue("key")
+	if !ok || hasKey && scopeKey != key {
+		writeQiniu(w, http.StatusUnauthorized, qiniuError("invalid upload token"))
+		return
+	}
+	b, ok := s.buckets[bucket]
+	if !ok {
+		writeQiniu(w, 631, qiniuError("no such bucket"))
+		return
+	}
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		writeQiniu(w, http.StatusBadRequest, qiniuError("file is not specified in multipart"))
+		return
+	}
+	f, err := files[0].Open()
+	if err != nil {
+		writeQiniu(w, http.StatusBadRequest, qiniuError(err.Error()))
+		return
+	}
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		writeQiniu(w, http.StatusBadRequest, qiniuError(err.Error()))
+		return
+	}
+	hash := qiniuHash(data)
+	if o, exist := b.objects[key]; exist && !hasKey && o.etag != hash {
+		writeQiniu(w, 614, qiniuError("file exists"))
+		return
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", files[0].Header.Get("Content-Type"))
+	for name, values := range r.MultipartForm.Value {
+		if strings.HasPrefix(strings.ToLower(name), "x-qn-meta-") {
+			header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	s.putObject(b, key, data, header, fileType)
+	writeQiniu(w, http.StatusOK, map[string]string{"hash": hash, "key": key})
+}
+
+// putObject 保存对象，未指定MIME类型时使用application/octet-stream
+func (s *QiniuServer) putObject(b *fakeBucket, key string, data []byte, header http.Header, fileType int) {
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	header.Set(qiniuFileType, strconv.Itoa(fileType))
+	b.objects[key] = &fakeObject{data: data, etag: qiniuHash(data), header: header, modified: time.Now()}
+}
+
+// serveUpload
+/**
+ *  @Description: 分片上传v2，路径为 /buckets/<bucket>/objects/<base64(key)>/uploads[/<uploadId>[/<partNumber>]]
+ *  @receiver s
+ *  @param w
+ *  @param r
+ *  @param parts 去掉 /buckets/ 后按"/"分割的路径
+ */
+func (s *QiniuServer) serveUpload(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) < 4 || parts[1] != "objects" || parts[3] != "uploads" {
+		writeQiniu(w, http.StatusNotFound, qiniuError("not found"))
+		return
+	}
+	b, ok := s.buckets[parts[0]]
+	if !ok {
+		writeQiniu(w, 631, qiniuError("no such bucket"))
+		return
+	}
+	rawKey, err := base64.URLEncoding.DecodeString(parts[2])
+	if err != nil {
+		writeQiniu(w, http.StatusBadRequest, qiniuError("invalid key"))
+		return
+	}
+	key := string(rawKey)
+	if len(parts) == 4 && r.Method == http.MethodPost {
+		s.seq++
+		uploadID := fmt.Sprintf("upload-%d", s.seq)
+		s.uploads[uploadID] = &fakeUpload{bucket: parts[0], key: key, parts: make(map[int][]byte)}
+		writeQiniu(w, http.StatusOK, map[string]interface{}{
+			"uploadId": uploadID,
+			"expireAt": time.Now().Add(7 * 24 * time.Hour).Unix(),
+		})
+		return
+	}
+	if len(parts) < 5 {
+		writeQiniu(w, http.StatusMethodNotAllowed, qiniuError("method not allowed"))
+		return
+	}
+	upload, ok := s.uploads[parts[4]]
+	if !ok || upload.bucket != parts[0] || upload.key != key {
+		writeQiniu(w, 612, qiniuError("no such uploadId"))
+		return
+	}
+	switch {
+	case len(parts) == 6 && r.Method == http.MethodPut:
+		partNumber, err := strconv.Atoi(parts[5])
+		if err != nil || partNumber < 1 {
+			writeQiniu(w, http.StatusBadRequest, qiniuError("invalid part number"))
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeQiniu(w, http.StatusBadRequest, qiniuError(err.Error()))
+			return
+		}
+		upload.parts[partNumber] = data
+		sum := md5.Sum(data)
+		writeQiniu(w, http.StatusOK, map[string]string{"etag": qiniuHash(data), "md5": hex.EncodeToString(sum[:])})
+	case len(parts) == 5 && r.Method == http.MethodPost:
+		var req struct {
+			Parts []struct {
+				Etag       string `json:"etag"`
+				PartNumber int    `json:"partNumber"`
+			} `json:"parts"`
+			MimeType string            `json:"mimeType"`
+			Metadata map[string]string `json:"metadata"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Parts) == 0 {
+			writeQiniu(w, http.StatusBadRequest, qiniuError("invalid parts"))
+			return
+		}
+		var data bytes.Buffer
+		for _, part := range req.Parts {
+			partData, ok := upload.parts[part.PartNumber]
+			if !ok || part.Etag != qiniuHash(partData) {
+				writeQiniu(w, http.StatusBadRequest, qiniuError("invalid part"))
+				return
+			}
+			data.Write(partData)
+		}
+		header := make(http.Header)
+		header.Set("Content-Type", req.MimeType)
+		for name, value := range req.Metadata {
+			header.Set(name, value)
+		}
+		s.putObject(b, key, data.Bytes(), header, 0)
+		delete(s.uploads, parts[4])
+		writeQiniu(w, http.StatusOK, map[string]string{"hash": qiniuHash(data.Bytes()), "key": key})
+	case len(parts) == 5 && r.Method == http.MethodDelete:
+		delete(s.uploads, parts[4])
+		writeQiniu(w, http.StatusOK, struct{}{})
+	default:
+		writeQiniu(w, http.StatusMethodNotAllowed, qiniuError("method not allowed"))
+	}
+}
+
+// download 通过下载域名下载文件，支持Range请求
+func (s *QiniuServer) download(w http.ResponseWriter, r *http.Request, p string) {
+	if r.URL.Query().Get("token") == "" {
+		writeQiniu(w, http.StatusUnauthorized, qiniuError("download token not specified"))
+		return
+	}
+	parts := strings.SplitN(p, "/", 2)
+	b, ok := s.buckets[parts[0]]
+	if !ok || len(parts) != 2 {
+		writeQiniu(w, http.StatusNotFound, qiniuError("Document not found"))
+		return
+	}
+	o, ok := b.objects[parts[1]]
+	if !ok {
+		writeQiniu(w, http.StatusNotFound, qiniuError("Document not found"))
+		return
+	}
+	data, status := o.data, http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end, ok := parseRange(rng, len(o.data))
+		if !ok {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(o.data)))
+			writeQiniu(w, http.StatusRequestedRangeNotSatisfiable, qiniuError("invalid range"))
+			return
+		}
+		data, status = o.data[start:end+1], http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(o.data)))
+	}
+	for name, values := range o.header {
+		if name != qiniuFileType {
+			w.Header()[name] = values
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("ETag", `"`+o.etag+`"`)
+	w.Header().Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// qiniuHash 七牛云的文件hash：按4MB分块计算SHA1，只有一块时为0x16与该块的SHA1，
+// 否则为0x96与各块SHA1拼接后的SHA1，最后使用URL安全的Base64编码
+func qiniuHash(data []byte) string {
+	const blockSize = 4 << 20
+	var sums []byte
+	for start := 0; start == 0 || start < len(data); start += blockSize {
+		end := start + blockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		sum := sha1.Sum(data[start:end])
+		sums = append(sums, sum[:]...)
+	}
+	prefix := byte(0x16)
+	if len(sums) > sha1.Size {
+		sum := sha1.Sum(sums)
+		sums, prefix = sum[:], 0x96
+	}
+	return base64.URLEncoding.EncodeToString(append([]byte{prefix}, sums...))
+}
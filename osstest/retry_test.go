@@ -0,0 +1,259 @@
+/**
+ * @Time    :2026/10/18 22:10
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// errUnavailable 模拟服务端暂时不可用
+var errUnavailable = &oss.Error{Provider: "memory", Op: "test", StatusCode: 503, Code: "ServiceUnavailable", Err: errors.New("service unavailable")}
+
+// flakyClient 在指定操作的前若干次调用时返回errUnavailable
+type flakyClient struct {
+	oss.ClientI
+	mu    sync.Mutex
+	fail  map[string]int
+	calls map[string]int
+}
+
+func newFlakyClient(t *testing.T) *flakyClient {
+	client := oss.NewMemoryOss(bucketName())
+	if err := client.NewBucket(); err != nil {
+		t.Fatal(err)
+	}
+	return &flakyClient{ClientI: client, fail: make(map[string]int), calls: make(map[string]int)}
+}
+
+// failNext 使op接下来的n次调用失败，并重置调用次数
+func (c *flakyClient) failNext(op string, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fail[op] = n
+	c.calls[op] = 0
+}
+
+func (c *flakyClient) inject(op string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[op]++
+	if c.fail[op] > 0 {
+		c.fail[op]--
+		return errUnavailable
+	}
+	return nil
+}
+
+func (c *flakyClient) callCount(op string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[op]
+}
+
+func (c *flakyClient) StatObject(objectName string) (ossmod.ObjectInfo, error) {
+	if err := c.inject("StatObject"); err != nil {
+		return ossmod.ObjectInfo{}, err
+	}
+	return c.ClientI.StatObject(objectName)
+}
+
+func (c *flakyClient) StatObjectCtx(ctx context.Context, objectName string) (ossmod.ObjectInfo, error) {
+	if err := c.inject("StatObject"); err != nil {
+		return ossmod.ObjectInfo{}, err
+	}
+	return c.ClientI.StatObjectCtx(ctx, objectName)
+}
+
+func (c *flakyClient) PutObjectStream(objectName string, r io.Reader, size int64, opts ossmod.PutOptions) error {
+	if err := c.inject("PutObjectStream"); err != nil {
+		// 模拟上传了一部分数据后连接中断
+		io.CopyN(ioutil.Discard, r, 3)
+		return err
+	}
+	return c.ClientI.PutObjectStream(objectName, r, size, opts)
+}
+
+func (c *flakyClient) RemoveObjects(keys []string) map[string]error {
+	if err := c.inject("RemoveObjects"); err != nil {
+		failed := make(map[string]error)
+		for _, key := range keys {
+			failed[key] = err
+		}
+		return failed
+	}
+	return c.ClientI.RemoveObjects(keys)
+}
+
+func (c *flakyClient) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) oss.ObjectIterator {
+	return &flakyIterator{ObjectIterator: c.ClientI.ListObjectsIter(ctx, opts), client: c}
+}
+
+// flakyIterator 返回第一个对象后按flakyClient的设置失败
+type flakyIterator struct {
+	oss.ObjectIterator
+	client *flakyClient
+	n      int
+	err    error
+}
+
+func (it *flakyIterator) Next() bool {
+	if it.n == 1 {
+		if it.err = it.client.inject("ListObjectsIter"); it.err != nil {
+			return false
+		}
+	}
+	it.n++
+	return it.ObjectIterator.Next()
+}
+
+func (it *flakyIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.ObjectIterator.Err()
+}
+
+func TestRetryClientConformance(t *testing.T) {
+	RunConformance(t, func(t *testing.T) oss.ClientI {
+		return oss.NewRetryClient(oss.NewMemoryOss(bucketName()), oss.RetryPolicy{BaseDelay: time.Millisecond})
+	})
+}
+
+func TestRetryClient(t *testing.T) {
+	flaky := newFlakyClient(t)
+	var retries int
+	client := oss.NewRetryClient(flaky, oss.RetryPolicy{
+		BaseDelay: time.Millisecond,
+		OnRetry: func(op, key string, attempt int, err error, delay time.Duration) {
+			retries++
+		},
+	})
+	// 只有被包装的客户端支持分片上传时才实现MultipartI
+	if _, ok := client.(oss.MultipartI); ok {
+		t.Errorf("NewRetryClient(%T) implements MultipartI", flaky)
+	}
+	if _, ok := oss.NewRetryClient(flaky.ClientI, oss.RetryPolicy{}).(oss.MultipartI); !ok {
+		t.Errorf("NewRetryClient(%T) does not implement MultipartI", flaky.ClientI)
+	}
+	putString(t, client, "a.txt", "a")
+
+	// 暂时性错误重试后成功
+	flaky.failNext("StatObject", 2)
+	if _, err := client.StatObject("a.txt"); err != nil {
+		t.Errorf("StatObject after 2 failures: %v", err)
+	}
+	if calls := flaky.callCount("StatObject"); calls != 3 || retries != 2 {
+		t.Errorf("StatObject calls = %d, retries = %d, want 3 and 2", calls, retries)
+	}
+
+	// 超过最大次数后返回最后一次的错误与尝试次数
+	flaky.failNext("StatObject", 5)
+	_, err := client.StatObject("a.txt")
+	var retryErr *oss.RetryError
+	var ossErr *oss.Error
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || !errors.As(err, &ossErr) || ossErr.StatusCode != 503 {
+		t.Errorf("StatObject after 5 failures: err = %v, want a RetryError after 3 attempts", err)
+	}
+
+	// 不可重试的错误不重试
+	flaky.failNext("StatObject", 0)
+	_, err = client.StatObject("missing.txt")
+	assertErrorIs(t, "StatObject on a missing key", err, oss.ErrObjectNotFound)
+	if calls := flaky.callCount("StatObject"); calls != 1 {
+		t.Errorf("StatObject on a missing key called %d times, want 1", calls)
+	}
+
+	// 可重新读取的数据在重试前回到起始位置
+	flaky.failNext("PutObjectStream", 1)
+	content := []byte("seekable content")
+	if err = client.PutObjectStream("b.txt", bytes.NewReader(content), int64(len(content)), ossmod.PutOptions{}); err != nil {
+		t.Fatalf("PutObjectStream with a seekable reader: %v", err)
+	}
+	assertContent(t, client, "b.txt", content)
+	flaky.failNext("PutObjectStream", 1)
+	reader := struct{ io.Reader }{bytes.NewReader(content)}
+	if err = client.PutObjectStream("c.txt", reader, int64(len(content)), ossmod.PutOptions{}); !errors.Is(err, errUnavailable) {
+		t.Errorf("PutObjectStream with a non-seekable reader: err = %v, want %v", err, errUnavailable)
+	}
+	if calls := flaky.callCount("PutObjectStream"); calls != 1 {
+		t.Errorf("PutObjectStream with a non-seekable reader called %d times, want 1", calls)
+	}
+
+	flaky.failNext("RemoveObjects", 1)
+	if failed := client.RemoveObjects([]string{"a.txt", "b.txt"}); failed != nil {
+		t.Errorf("RemoveObjects after a failure = %v", failed)
+	}
+	objects, err := client.ListObjects("", "")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	assertKeys(t, "ListObjects after RemoveObjects", objects)
+
+	// 迭代器从失败的位置继续，不重复也不遗漏
+	for _, key := range []string{"d/1", "d/2", "d/3"} {
+		putString(t, client, key, key)
+	}
+	flaky.failNext("ListObjectsIter", 1)
+	it := client.ListObjectsIter(context.Background(), ossmod.ListOptions{Prefix: "d/"})
+	if keys := strings.Join(iterKeys(t, it, -1), ","); keys != "d/1,d/2,d/3" {
+		t.Errorf("ListObjectsIter after a failure = %s", keys)
+	}
+}
+
+func TestRetryClientDeadline(t *testing.T) {
+	flaky := newFlakyClient(t)
+	client := oss.NewRetryClient(flaky, oss.RetryPolicy{BaseDelay: time.Hour})
+	flaky.failNext("StatObject", 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.StatObjectCtx(ctx, "a.txt")
+	if !errors.Is(err, errUnavailable) || time.Since(start) > time.Second {
+		t.Errorf("StatObjectCtx = %v after %v, want to give up before the deadline", err, time.Since(start))
+	}
+	if calls := flaky.callCount("StatObject"); calls != 1 {
+		t.Errorf("StatObjectCtx called %d times, want 1", calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	_, notFound := oss.NewMemoryOss("b").StatObject("missing")
+	reset := &url.Error{Op: "Put", URL: "http://oss", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errUnavailable, true},
+		{&oss.Error{Provider: "qiniu", StatusCode: 573, Err: errors.New("too many requests")}, true},
+		{&oss.Error{Provider: "upyun", StatusCode: 429, Err: errors.New("too many requests")}, true},
+		{&oss.Error{Provider: "minio", Code: "XMinioServerNotInitialized", Err: errors.New("initializing")}, true},
+		{&oss.Error{Provider: "aliyun", StatusCode: 400, Code: "InvalidArgument", Err: errors.New("bad request")}, false},
+		{&oss.Error{Provider: "aliyun", Err: reset}, true},
+		{reset, true},
+		{notFound, false},
+		{context.Canceled, false},
+		{&oss.Error{Provider: "tencent", Err: context.DeadlineExceeded}, false},
+		{errors.New("permission denied"), false},
+	}
+	for _, tt := range tests {
+		if got := oss.IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,733 @@
+/**
+ * @Time    :2026/10/18 14:02
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server 基于httptest的S3兼容对象存储模拟服务
+// 阿里云、腾讯云、华为云、Minio的SDK均使用S3风格的XML协议，可直接指向该服务进行测试；
+// 百度云的接口语义与S3相同，但请求与响应体使用JSON，按bce-auth-v1签名识别
+// 请求Host为IP或localhost时按路径风格（/bucket/key）解析，否则取Host的第一段作为存储桶名
+type Server struct {
+	*httptest.Server
+	// MaxKeys 列举时单页返回的最大条目数，设置较小的值可强制SDK分页
+	MaxKeys int
+	// OmitNextMarker 列举结果被截断时不返回NextMarker，模拟只在部分情况下返回续接标记的服务
+	OmitNextMarker bool
+
+	mu      sync.Mutex
+	buckets map[string]*fakeBucket
+	uploads map[string]*fakeUpload
+	seq     int
+}
+
+type fakeBucket struct {
+	created time.Time
+	objects map[string]*fakeObject
+}
+
+// fakeUpload 进行中的分片上传
+type fakeUpload struct {
+	bucket string
+	key    string
+	header http.Header
+	parts  map[int][]byte
+}
+
+type fakeObject struct {
+	data []byte
+	etag string
+	// header 上传时指定的Content-Type等请求头、用户元数据与存储类型，下载时原样返回
+	header   http.Header
+	modified time.Time
+}
+
+// NewServer 启动模拟服务，使用完毕后需调用Close
+func NewServer() *Server {
+	s := &Server{
+		MaxKeys: 1000,
+		buckets: make(map[string]*fakeBucket),
+		uploads: make(map[string]*fakeUpload),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// route 解析请求对应的存储桶与对象名
+func (s *Server) route(r *http.Request) (bucket, key string) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	if net.ParseIP(host) != nil || host == "localhost" {
+		parts := strings.SplitN(p, "/", 2)
+		bucket = parts[0]
+		if len(parts) == 2 {
+			key = parts[1]
+		}
+		return
+	}
+	bucket = strings.SplitN(host, ".", 2)[0]
+	key = p
+	return
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	requestID := fmt.Sprintf("fake-%d", s.seq)
+	for _, h := range []string{"x-amz-request-id", "x-oss-request-id", "x-cos-request-id", "x-obs-request-id", "x-bce-request-id"} {
+		w.Header().Set(h, requestID)
+	}
+	bucket, key := s.route(r)
+	switch {
+	case bucket == "":
+		s.listBuckets(w, r)
+	case key == "":
+		s.serveBucket(w, r, bucket)
+	default:
+		s.serveObject(w, r, bucket, key)
+	}
+}
+
+// writeError 返回S3风格的XML错误，百度云为JSON
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	type errorResult struct {
+		XMLName   xml.Name `xml:"Error" json:"-"`
+		Code      string   `json:"code"`
+		Message   string   `json:"message"`
+		RequestId string   `json:"requestId"`
+	}
+	writeResult(w, r, errorResult{
+		Code:      code,
+		Message:   code,
+		RequestId: w.Header().Get("x-amz-request-id"),
+	})
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	data, _ := xml.Marshal(v)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
+
+// isBos 判断是否为百度云SDK的请求，预签名URL的签名位于authorization参数中
+func isBos(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "bce-auth-v1/") ||
+		strings.HasPrefix(r.URL.Query().Get("authorization"), "bce-auth-v1/")
+}
+
+// writeResult 百度云的请求以JSON返回v，其余以XML返回
+func writeResult(w http.ResponseWriter, r *http.Request, v interface{}) {
+	if isBos(r) {
+		json.NewEncoder(w).Encode(v)
+		return
+	}
+	writeXML(w, v)
+}
+
+// decodeBody 按请求的协议解码请求体
+func decodeBody(r *http.Request, v interface{}) error {
+	if isBos(r) {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+	return xml.NewDecoder(r.Body).Decode(v)
+}
+
+// quoteETag XML协议中的ETag带引号，百度云JSON协议中不带
+func quoteETag(r *http.Request, etag string) string {
+	if isBos(r) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	type bucketXML struct {
+		Name         string
+		CreationDate string
+		Location     string
+	}
+	type listResult struct {
+		XMLName xml.Name    `xml:"ListAllMyBucketsResult"`
+		Prefix  string      `xml:"Prefix"`
+		Buckets []bucketXML `xml:"Buckets>Bucket"`
+	}
+	prefix := r.URL.Query().Get("prefix")
+	var names []string
+	for name := range s.buckets {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := listResult{Prefix: prefix}
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, bucketXML{
+			Name:         name,
+			CreationDate: s.buckets[name].created.UTC().Format(time.RFC3339),
+			Location:     "us-east-1",
+		})
+	}
+	writeXML(w, result)
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	b, exist := s.buckets[bucket]
+	query := r.URL.Query()
+	switch r.Method {
+	case http.MethodPut:
+		if !exist {
+			s.buckets[bucket] = &fakeBucket{created: time.Now(), objects: make(map[string]*fakeObject)}
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodHead:
+		if !exist {
+			writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if !exist {
+			writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+			return
+		}
+		if len(b.objects) > 0 {
+			writeError(w, r, http.StatusConflict, "BucketNotEmpty")
+			return
+		}
+		delete(s.buckets, bucket)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		if _, ok := query["location"]; ok {
+			type location struct {
+				XMLName xml.Name `xml:"LocationConstraint"`
+				Value   string   `xml:",chardata"`
+			}
+			writeXML(w, location{})
+			return
+		}
+		if !exist {
+			writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+			return
+		}
+		s.listObjects(w, r, bucket, b)
+	case http.MethodPost:
+		if _, ok := query["delete"]; !ok {
+			writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+			return
+		}
+		if !exist {
+			writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+			return
+		}
+		s.deleteObjects(w, r, b)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// listObjects 同时支持ListObjects（marker）与ListObjectsV2（list-type=2）
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, bucket string, b *fakeBucket) {
+	type contentXML struct {
+		Key          string `json:"key"`
+		LastModified string `json:"lastModified"`
+		ETag         string `json:"eTag"`
+		Size         int64  `json:"size"`
+		StorageClass string `json:"storageClass"`
+	}
+	type prefixXML struct {
+		Prefix string `json:"prefix"`
+	}
+	type listResult struct {
+		XMLName               xml.Name     `xml:"ListBucketResult" json:"-"`
+		Name                  string       `json:"name"`
+		Prefix                string       `json:"prefix"`
+		Marker                string       `json:"marker"`
+		StartAfter            string       `xml:",omitempty" json:"-"`
+		ContinuationToken     string       `xml:",omitempty" json:"-"`
+		NextContinuationToken string       `xml:",omitempty" json:"-"`
+		KeyCount              int          `json:"-"`
+		MaxKeys               int          `json:"maxKeys"`
+		Delimiter             string       `json:"delimiter"`
+		IsTruncated           bool         `json:"isTruncated"`
+		NextMarker            string       `json:"nextMarker"`
+		Contents              []contentXML `json:"contents"`
+		CommonPrefixes        []prefixXML  `json:"commonPrefixes"`
+	}
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	v2 := query.Get("list-type") == "2"
+	marker := query.Get("marker")
+	if v2 {
+		marker = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			marker = token
+		}
+	}
+	maxKeys := 1000
+	limit := query.Get("max-keys")
+	if isBos(r) {
+		limit = query.Get("maxKeys")
+	}
+	if v, err := strconv.Atoi(limit); err == nil && v > 0 {
+		maxKeys = v
+	}
+	if s.MaxKeys > 0 && maxKeys > s.MaxKeys {
+		maxKeys = s.MaxKeys
+	}
+	result := listResult{
+		Name:              bucket,
+		Prefix:            prefix,
+		Marker:            query.Get("marker"),
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: query.Get("continuation-token"),
+		MaxKeys:           maxKeys,
+		Delimiter:         delimiter,
+	}
+	keys, prefixes, next := b.list(prefix, delimiter, marker, maxKeys)
+	result.KeyCount = len(keys) + len(prefixes)
+	for _, entry := range prefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, prefixXML{Prefix: entry})
+	}
+	for _, k := range keys {
+		o := b.objects[k]
+		result.Contents = append(result.Contents, contentXML{
+			Key:          k,
+			LastModified: o.modified.UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         quoteETag(r, o.etag),
+			Size:         int64(len(o.data)),
+			StorageClass: "STANDARD",
+		})
+	}
+	if next != "" {
+		result.IsTruncated = true
+		if !s.OmitNextMarker {
+			result.NextMarker = next
+		}
+		if v2 {
+			result.NextContinuationToken = next
+		}
+	}
+	writeResult(w, r, result)
+}
+
+// list
+/**
+ *  @Description: 按对象名升序列举marker之后以prefix开头的对象，delimiter不为空时合并为公共前缀
+ *  @receiver b
+ *  @param prefix
+ *  @param delimiter
+ *  @param marker
+ *  @param maxKeys 对象与公共前缀合计的最大条目数
+ *  @return keys
+ *  @return prefixes
+ *  @return next 还有剩余条目时为本页最后一个对象名或公共前缀，否则为空
+ */
+func (b *fakeBucket) list(prefix, delimiter, marker string, maxKeys int) (keys, prefixes []string, next string) {
+	all := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		all = append(all, k)
+	}
+	sort.Strings(all)
+	var last string
+	count := 0
+	for _, k := range all {
+		if !strings.HasPrefix(k, prefix) || k <= marker {
+			continue
+		}
+		entry := k
+		isPrefix := false
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				entry = k[:len(prefix)+i+len(delimiter)]
+				isPrefix = true
+			}
+		}
+		if isPrefix && (entry <= marker || entry == last) {
+			continue
+		}
+		if count == maxKeys {
+			next = last
+			break
+		}
+		count++
+		last = entry
+		if isPrefix {
+			prefixes = append(prefixes, entry)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	b, exist := s.buckets[bucket]
+	if !exist {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	query := r.URL.Query()
+	if _, ok := query["uploads"]; ok && r.Method == http.MethodPost {
+		s.initiateUpload(w, r, bucket, key)
+		return
+	}
+	if uploadID := query.Get("uploadId"); uploadID != "" {
+		s.serveUpload(w, r, b, key, uploadID)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		if source := copySource(r); source != "" {
+			s.copyObject(w, r, b, key, source)
+			return
+		}
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		sum := md5.Sum(data)
+		o := &fakeObject{
+			data:     data,
+			etag:     hex.EncodeToString(sum[:]),
+			header:   objectHeader(r),
+			modified: time.Now(),
+		}
+		b.objects[key] = o
+		writeChecksum(w, data, o.etag)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		o, ok := b.objects[key]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		data, status := o.data, http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" && r.Method == http.MethodGet && !ignoreRange(r, rng, len(o.data)) {
+			start, end, ok := parseRange(rng, len(o.data))
+			if !ok {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(o.data)))
+				writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			data, status = o.data[start:end+1], http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(o.data)))
+		}
+		for name, values := range o.header {
+			w.Header()[name] = values
+		}
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", `"`+o.etag+`"`)
+		w.Header().Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// objectHeader 提取上传请求中需要随对象保存的请求头
+func objectHeader(r *http.Request) http.Header {
+	header := make(http.Header)
+	for name, values := range r.Header {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "content-type", lower == "content-disposition", lower == "cache-control", lower == "content-encoding",
+			strings.HasPrefix(lower, "x-") && (strings.Contains(lower, "-meta-") || strings.HasSuffix(lower, "-storage-class")):
+			header[name] = values
+		}
+	}
+	return header
+}
+
+// deleteObjects 批量删除对象，不存在的对象同样视为删除成功
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, b *fakeBucket) {
+	type object struct {
+		Key string `json:"key"`
+	}
+	type deleteRequest struct {
+		Quiet   bool
+		Objects []object `xml:"Object" json:"objects"`
+	}
+	// 百度云只返回删除失败的对象
+	type deleteResult struct {
+		XMLName xml.Name `xml:"DeleteResult" json:"-"`
+		Deleted []object `json:"-"`
+		Errors  []object `xml:"-" json:"errors"`
+	}
+	var req deleteRequest
+	if err := decodeBody(r, &req); err != nil || len(req.Objects) == 0 {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	var result deleteResult
+	for _, o := range req.Objects {
+		delete(b.objects, o.Key)
+		if !req.Quiet {
+			result.Deleted = append(result.Deleted, o)
+		}
+	}
+	writeResult(w, r, result)
+}
+
+// copySource 返回各SDK复制对象时携带的源对象请求头
+func copySource(r *http.Request) string {
+	for _, name := range []string{"x-amz-copy-source", "x-oss-copy-source", "x-cos-copy-source", "x-obs-copy-source", "x-bce-copy-source"} {
+		if v := r.Header.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// copyObject
+/**
+ *  @Description: 服务端复制对象
+ *  源对象的格式为 /bucket/key（Minio、阿里云、华为云、百度云）或 bucket-appid.cos.region.myqcloud.com/key（腾讯云）
+ *  @receiver s
+ *  @param w
+ *  @param r
+ *  @param b 目标存储桶
+ *  @param key 目标对象名
+ *  @param source 源对象请求头
+ */
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, b *fakeBucket, key, source string) {
+	if i := strings.IndexByte(source, '?'); i >= 0 {
+		source = source[:i]
+	}
+	source, err := url.PathUnescape(strings.TrimPrefix(source, "/"))
+	i := strings.IndexByte(source, '/')
+	if err != nil || i <= 0 {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+	srcBucket, srcKey := source[:i], source[i+1:]
+	if j := strings.Index(srcBucket, ".cos."); j > 0 {
+		srcBucket = srcBucket[:j]
+	}
+	sb, ok := s.buckets[srcBucket]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	src, ok := sb.objects[srcKey]
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	o := *src
+	o.modified = time.Now()
+	b.objects[key] = &o
+	type copyResult struct {
+		XMLName      xml.Name `xml:"CopyObjectResult" json:"-"`
+		LastModified string   `json:"lastModified"`
+		ETag         string   `json:"eTag"`
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	writeResult(w, r, copyResult{
+		LastModified: o.modified.UTC().Format(time.RFC3339),
+		ETag:         quoteETag(r, o.etag),
+	})
+}
+
+// parseRange 解析 "bytes=start-end" 或 "bytes=start-" 形式的Range请求头，end超出时截断到对象末尾
+func parseRange(rng string, size int) (start, end int, ok bool) {
+	spec := strings.TrimPrefix(rng, "bytes=")
+	i := strings.IndexByte(spec, '-')
+	if spec == rng || i <= 0 {
+		return
+	}
+	var err error
+	if start, err = strconv.Atoi(spec[:i]); err != nil || start >= size {
+		return
+	}
+	end = size - 1
+	if spec[i+1:] != "" {
+		if end, err = strconv.Atoi(spec[i+1:]); err != nil || end < start {
+			return
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	ok = true
+	return
+}
+
+// writeChecksum 写入ETag与CRC64响应头，腾讯云、阿里云SDK会校验CRC64
+func writeChecksum(w http.ResponseWriter, data []byte, etag string) {
+	crc := strconv.FormatUint(crc64.Checksum(data, crc64.MakeTable(crc64.ECMA)), 10)
+	w.Header().Set("x-cos-hash-crc64ecma", crc)
+	w.Header().Set("x-oss-hash-crc64ecma", crc)
+	w.Header().Set("ETag", `"`+etag+`"`)
+}
+
+func (s *Server) initiateUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	type initiateResult struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult" json:"-"`
+		Bucket   string   `json:"bucket"`
+		Key      string   `json:"key"`
+		UploadId string   `json:"uploadId"`
+	}
+	s.seq++
+	uploadID := fmt.Sprintf("upload-%d", s.seq)
+	s.uploads[uploadID] = &fakeUpload{
+		bucket: bucket,
+		key:    key,
+		header: objectHeader(r),
+		parts:  make(map[int][]byte),
+	}
+	writeResult(w, r, initiateResult{Bucket: bucket, Key: key, UploadId: uploadID})
+}
+
+// serveUpload 处理上传分片、完成与取消分片上传
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request, b *fakeBucket, key, uploadID string) {
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.key != key {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		if err != nil || partNumber < 1 {
+			writeError(w, r, http.StatusBadRequest, "InvalidArgument")
+			return
+		}
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		upload.parts[partNumber] = data
+		sum := md5.Sum(data)
+		writeChecksum(w, data, hex.EncodeToString(sum[:]))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPost:
+		type completePart struct {
+			PartNumber int    `json:"partNumber"`
+			ETag       string `json:"eTag"`
+		}
+		type completeRequest struct {
+			Parts []completePart `xml:"Part" json:"parts"`
+		}
+		type completeResult struct {
+			XMLName  xml.Name `xml:"CompleteMultipartUploadResult" json:"-"`
+			Location string   `json:"location"`
+			Bucket   string   `json:"bucket"`
+			Key      string   `json:"key"`
+			ETag     string   `json:"eTag"`
+		}
+		var req completeRequest
+		if err := decodeBody(r, &req); err != nil || len(req.Parts) == 0 {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data, sums []byte
+		for _, part := range req.Parts {
+			partData, ok := upload.parts[part.PartNumber]
+			sum := md5.Sum(partData)
+			if !ok || strings.Trim(part.ETag, `"`) != hex.EncodeToString(sum[:]) {
+				writeError(w, r, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			data = append(data, partData...)
+			sums = append(sums, sum[:]...)
+		}
+		sum := md5.Sum(sums)
+		o := &fakeObject{
+			data:     data,
+			etag:     fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(req.Parts)),
+			header:   upload.header,
+			modified: time.Now(),
+		}
+		b.objects[key] = o
+		delete(s.uploads, uploadID)
+		writeChecksum(w, data, o.etag)
+		writeResult(w, r, completeResult{Bucket: upload.bucket, Key: key, ETag: quoteETag(r, o.etag)})
+	case http.MethodDelete:
+		delete(s.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// readBody 读取请求体，兼容Minio在HTTP下使用的aws-chunked流式签名格式
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return ioutil.ReadAll(r.Body)
+	}
+	var buf bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeStr := strings.SplitN(strings.TrimSpace(line), ";", 2)[0]
+		size, err := strconv.ParseInt(sizeStr, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return buf.Bytes(), nil
+		}
+		if _, err = io.CopyN(&buf, br, size); err != nil {
+			return nil, err
+		}
+		if _, err = br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ignoreRange 与阿里云一致：未指定x-oss-range-behavior: standard时，无效的范围被忽略并返回完整的对象
+func ignoreRange(r *http.Request, rng string, size int) bool {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "OSS") || strings.EqualFold(r.Header.Get("x-oss-range-behavior"), "standard") {
+		return false
+	}
+	_, _, ok := parseRange(rng, size)
+	return !ok
+}
@@ -0,0 +1,156 @@
+/**
+ * @Time    :2026/10/18 23:59
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/melf-xyzh/go-oss-client/model"
+	"github.com/melf-xyzh/go-oss-client/oss"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failPutClient 上传指定的对象时返回errInjected
+type failPutClient struct {
+	oss.ClientI
+	key string
+}
+
+func (c *failPutClient) PutObjectStreamCtx(ctx context.Context, objectName string, r io.Reader, size int64, opts ossmod.PutOptions) error {
+	if objectName == c.key {
+		return errInjected
+	}
+	return c.ClientI.PutObjectStreamCtx(ctx, objectName, r, size, opts)
+}
+
+func TestSync(t *testing.T) {
+	src := newBucket(t, func(t *testing.T) oss.ClientI { return oss.NewMemoryOss(bucketName()) })
+	srv := newServer(t)
+	dst := newBucket(t, func(t *testing.T) oss.ClientI {
+		client, err := oss.NewMinioOss(strings.TrimPrefix(srv.URL, "http://"), "ak", "sk", bucketName(), 15, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+	for key, content := range map[string]string{"a.txt": "same", "b.txt": "new", "c.txt": "longer", "d.txt": "src!", "other.txt": "other"} {
+		putString(t, src, key, content)
+	}
+	for key, content := range map[string]string{"a.txt": "same", "c.txt": "short", "d.txt": "dst!", "e.txt": "extra"} {
+		putString(t, dst, key, content)
+	}
+
+	// DryRun只按对象名顺序输出计划执行的操作
+	var actions []string
+	opts := ossmod.SyncOptions{Delete: true, DryRun: true, OnAction: func(action ossmod.SyncAction) {
+		actions = append(actions, fmt.Sprintf("%s %s %s", action.Op, action.Key, action.Reason))
+	}}
+	result, err := oss.Sync(context.Background(), src, dst, opts)
+	if err != nil {
+		t.Fatalf("Sync with DryRun: %v", err)
+	}
+	want := "[copy b.txt new copy c.txt size copy d.txt etag delete e.txt extraneous copy other.txt new]"
+	if got := fmt.Sprint(actions); got != want {
+		t.Errorf("Sync with DryRun actions = %s, want %s", got, want)
+	}
+	if result.Copied != 4 || result.Deleted != 1 || result.Skipped != 1 {
+		t.Errorf("Sync with DryRun = %+v", result)
+	}
+	assertObject(t, dst, "e.txt", "extra")
+	if exist, _ := dst.ObjectExist("b.txt"); exist {
+		t.Error("Sync with DryRun copied b.txt")
+	}
+
+	// 只同步前缀下的对象，单个对象失败不影响其余对象
+	opts = ossmod.SyncOptions{Prefix: "b", Concurrency: 2}
+	result, err = oss.Sync(context.Background(), src, &failPutClient{ClientI: dst, key: "b.txt"}, opts)
+	if !errors.Is(err, errInjected) || len(result.Failed) != 1 || result.Copied != 0 {
+		t.Errorf("Sync with a failing object = %+v, %v", result, err)
+	}
+
+	opts = ossmod.SyncOptions{Delete: true, Concurrency: 2}
+	result, err = oss.Sync(context.Background(), src, dst, opts)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if result.Copied != 4 || result.CopiedBytes != 18 || result.Deleted != 1 || result.Skipped != 1 || result.Failed != nil {
+		t.Errorf("Sync = %+v", result)
+	}
+	objects, err := dst.ListObjects("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, "ListObjects after Sync", objects, "a.txt", "b.txt", "c.txt", "d.txt", "other.txt")
+	assertObject(t, dst, "c.txt", "longer")
+	assertObject(t, dst, "d.txt", "src!")
+
+	// 再次同步时全部跳过
+	result, err = oss.Sync(context.Background(), src, dst, opts)
+	if err != nil || result.Copied != 0 || result.Deleted != 0 || result.Skipped != 5 {
+		t.Errorf("second Sync = %+v, %v", result, err)
+	}
+}
+
+// failListClient 列举时在返回after个对象后以errInjected结束
+type failListClient struct {
+	oss.ClientI
+	after int
+}
+
+func (c *failListClient) ListObjectsIter(ctx context.Context, opts ossmod.ListOptions) oss.ObjectIterator {
+	return &failIterator{ObjectIterator: c.ClientI.ListObjectsIter(ctx, opts), left: c.after}
+}
+
+type failIterator struct {
+	oss.ObjectIterator
+	left int
+	err  error
+}
+
+func (it *failIterator) Next() bool {
+	if it.left == 0 {
+		it.err = errInjected
+		return false
+	}
+	it.left--
+	return it.ObjectIterator.Next()
+}
+
+func (it *failIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.ObjectIterator.Err()
+}
+
+func TestSyncListError(t *testing.T) {
+	src := newBucket(t, func(t *testing.T) oss.ClientI { return oss.NewMemoryOss(bucketName()) })
+	dst := newBucket(t, func(t *testing.T) oss.ClientI { return oss.NewMemoryOss(bucketName()) })
+	keys := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}
+	for _, key := range keys {
+		putString(t, src, key, key)
+		putString(t, dst, key, key)
+	}
+	// 源列举出错时不能把未列出的对象当作多余对象删除
+	opts := ossmod.SyncOptions{Delete: true}
+	result, err := oss.Sync(context.Background(), &failListClient{ClientI: src, after: 1}, dst, opts)
+	if !errors.Is(err, errInjected) || result.Deleted != 0 || result.Copied != 0 {
+		t.Errorf("Sync with a failing source listing = %+v, %v", result, err)
+	}
+	// 目标列举出错时同样不复制任何对象
+	result, err = oss.Sync(context.Background(), src, &failListClient{ClientI: dst, after: 1}, opts)
+	if !errors.Is(err, errInjected) || result.Deleted != 0 || result.Copied != 0 {
+		t.Errorf("Sync with a failing destination listing = %+v, %v", result, err)
+	}
+	objects, err := dst.ListObjects("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, "ListObjects after a failed Sync", objects, keys...)
+}
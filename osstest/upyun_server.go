@@ -0,0 +1,293 @@
+/**
+ * @Time    :2026/10/19 11:05
+ * @Author  :Xiaoyu.Zhang
+ */
+
+package osstest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UpYunServer 基于httptest的又拍云REST接口模拟服务
+// 请求路径为 /<服务名>/<文件路径>，目录由文件路径隐式构成；又拍云的服务只能在控制台创建，任意服务名均视为已存在
+type UpYunServer struct {
+	*httptest.Server
+	// MaxKeys 列目录时单页返回的最大条目数，设置较小的值可强制SDK分页
+	MaxKeys int
+
+	mu      sync.Mutex
+	buckets map[string]*fakeBucket
+	seq     int
+}
+
+// upyunListEnd 列目录已到达末尾时返回的iter
+const upyunListEnd = "g2gCZAAEbmV4dGQAA2VvZg"
+
+// NewUpYunServer 启动模拟服务，使用完毕后需调用Close
+func NewUpYunServer() *UpYunServer {
+	s := &UpYunServer{
+		MaxKeys: 1000,
+		buckets: make(map[string]*fakeBucket),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Host 返回模拟服务的地址，用于设置UpYun.Hosts["host"]
+func (s *UpYunServer) Host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// bucket 返回服务，不存在时创建
+func (s *UpYunServer) bucket(name string) *fakeBucket {
+	b, ok := s.buckets[name]
+	if !ok {
+		b = &fakeBucket{created: time.Now(), objects: make(map[string]*fakeObject)}
+		s.buckets[name] = b
+	}
+	return b
+}
+
+// route 解析 /<服务名>/<文件路径>，路径以"/"结尾时表示目录
+func (s *UpYunServer) route(p string) (b *fakeBucket, key string, isDir bool) {
+	parts := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 2)
+	b = s.bucket(parts[0])
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	isDir = key == "" || strings.HasSuffix(key, "/")
+	key = strings.TrimSuffix(key, "/")
+	return
+}
+
+func (s *UpYunServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	w.Header().Set("X-Request-Id", fmt.Sprintf("fake-%d", s.seq))
+	b, key, isDir := s.route(r.URL.Path)
+	switch r.Method {
+	case http.MethodPut:
+		if source := r.Header.Get("X-Upyun-Copy-Source"); source != "" {
+			s.copyObject(w, r, b, key, source, false)
+			return
+		}
+		if source := r.Header.Get("X-Upyun-Move-Source"); source != "" {
+			s.copyObject(w, r, b, key, source, true)
+			return
+		}
+		if isDir {
+			writeUpYunError(w, r, http.StatusBadRequest, 40011061, "file name is invalid")
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			writeUpYunError(w, r, http.StatusBadRequest, 40000001, "incomplete body")
+			return
+		}
+		header := objectHeader(r)
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/octet-stream")
+		}
+		sum := md5.Sum(data)
+		b.objects[key] = &fakeObject{data: data, etag: hex.EncodeToString(sum[:]), header: header, modified: time.Now()}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		if strings.EqualFold(r.Header.Get("X-Upyun-Folder"), "true") {
+			s.list(w, r, b, key)
+			return
+		}
+		s.getObject(w, r, b, key)
+	case http.MethodHead:
+		s.headObject(w, r, b, key)
+	case http.MethodDelete:
+		if _, ok := b.objects[key]; !ok {
+			writeUpYunError(w, r, http.StatusNotFound, 40400001, "file or directory not found")
+			return
+		}
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeUpYunError(w, r, http.StatusMethodNotAllowed, 40500001, "method not allowed")
+	}
+}
+
+// writeUpYunError 返回又拍云的JSON错误
+func writeUpYunError(w http.ResponseWriter, r *http.Request, status, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	json.NewEncoder(w).Encode(struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		ID   string `json:"id"`
+	}{code, msg, w.Header().Get("X-Request-Id")})
+}
+
+// copyObject 复制或移动文件，源为 /<服务名>/<转义后的文件路径>，元数据随文件复制
+func (s *UpYunServer) copyObject(w http.ResponseWriter, r *http.Request, b *fakeBucket, key, source string, move bool) {
+	p, err := url.PathUnescape(source)
+	if err != nil {
+		writeUpYunError(w, r, http.StatusBadRequest, 40000001, "invalid source")
+		return
+	}
+	src, srcKey, _ := s.route(p)
+	o, ok := src.objects[srcKey]
+	if !ok {
+		writeUpYunError(w, r, http.StatusNotFound, 40400001, "file or directory not found")
+		return
+	}
+	header := make(http.Header)
+	for name, values := range o.header {
+		header[name] = append([]string(nil), values...)
+	}
+	b.objects[key] = &fakeObject{data: o.data, etag: o.etag, header: header, modified: time.Now()}
+	if move {
+		delete(src.objects, srcKey)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *UpYunServer) getObject(w http.ResponseWriter, r *http.Request, b *fakeBucket, key string) {
+	o, ok := b.objects[key]
+	if !ok {
+		writeUpYunError(w, r, http.StatusNotFound, 40400001, "file or directory not found")
+		return
+	}
+	data, status := o.data, http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end, ok := parseRange(rng, len(o.data))
+		if !ok {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(o.data)))
+			writeUpYunError(w, r, http.StatusRequestedRangeNotSatisfiable, 41600001, "range not satisfiable")
+			return
+		}
+		data, status = o.data[start:end+1], http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(o.data)))
+	}
+	for name, values := range o.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("ETag", `"`+o.etag+`"`)
+	w.Header().Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// headObject 获取文件信息，通过x-upyun-file-*响应头返回
+func (s *UpYunServer) headObject(w http.ResponseWriter, r *http.Request, b *fakeBucket, key string) {
+	o, ok := b.objects[key]
+	if !ok {
+		if _, _, isDir := s.children(b, key); isDir {
+			w.Header().Set("x-upyun-file-type", "folder")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		writeUpYunError(w, r, http.StatusNotFound, 40400001, "file or directory not found")
+		return
+	}
+	for name, values := range o.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("x-upyun-file-type", "file")
+	w.Header().Set("x-upyun-file-size", strconv.Itoa(len(o.data)))
+	w.Header().Set("x-upyun-file-date", strconv.FormatInt(o.modified.Unix(), 10))
+	w.Header().Set("Content-Md5", o.etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// children 列出目录dir下一级的文件与子目录，exist表示目录是否存在，根目录总是存在
+func (s *UpYunServer) children(b *fakeBucket, dir string) (files, dirs []string, exist bool) {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	seen := make(map[string]bool)
+	for k := range b.objects {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		rest := k[len(prefix):]
+		if i := strings.Index(rest, "/"); i >= 0 {
+			if !seen[rest[:i]] {
+				seen[rest[:i]] = true
+				dirs = append(dirs, rest[:i])
+			}
+			continue
+		}
+		files = append(files, rest)
+	}
+	exist = dir == "" || len(files) > 0 || len(dirs) > 0
+	return
+}
+
+// list 列目录，每页最多返回X-List-Limit个条目，iter为下一页的起始位置
+func (s *UpYunServer) list(w http.ResponseWriter, r *http.Request, b *fakeBucket, dir string) {
+	type fileJSON struct {
+		Type         string `json:"type"`
+		Name         string `json:"name"`
+		Length       int64  `json:"length"`
+		LastModified int64  `json:"last_modified"`
+	}
+	type listResult struct {
+		Files []fileJSON `json:"files"`
+		Iter  string     `json:"iter"`
+	}
+	files, dirs, exist := s.children(b, dir)
+	if !exist {
+		writeUpYunError(w, r, http.StatusNotFound, 40400001, "file or directory not found")
+		return
+	}
+	var entries []fileJSON
+	for _, name := range dirs {
+		entries = append(entries, fileJSON{Type: "folder", Name: name})
+	}
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	for _, name := range files {
+		o := b.objects[prefix+name]
+		entries = append(entries, fileJSON{
+			Type:         o.header.Get("Content-Type"),
+			Name:         name,
+			Length:       int64(len(o.data)),
+			LastModified: o.modified.Unix(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	limit, _ := strconv.Atoi(r.Header.Get("X-List-Limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	if s.MaxKeys > 0 && limit > s.MaxKeys {
+		limit = s.MaxKeys
+	}
+	start, _ := strconv.Atoi(r.Header.Get("X-List-Iter"))
+	if start > len(entries) {
+		start = len(entries)
+	}
+	result := listResult{Files: entries[start:], Iter: upyunListEnd}
+	if len(result.Files) > limit {
+		result.Files = result.Files[:limit]
+		result.Iter = strconv.Itoa(start + limit)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}